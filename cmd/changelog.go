@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"automelonloaderinstallergo/internal/changelog"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	changelogOwner string
+	changelogRepo  string
+	changelogFrom  string
+	changelogTo    string
+	changelogToken string
+)
+
+func newChangelogCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "changelog",
+		Short: "Print the release notes between two versions, newest first",
+		Long: "Print the combined release notes of every release after --from up to and including --to\n" +
+			"as Markdown, newest first. Breaking changes are summarised at the top.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(cmd.Context(), viper.GetDuration("timeouts.refresh"))
+			defer cancel()
+
+			src, err := newSource(cmd)
+			if err != nil {
+				return err
+			}
+			cred, err := resolveGitHubCredential(cmd, changelogToken)
+			if err != nil {
+				return err
+			}
+			owner := flagOrConfig(cmd, "owner", changelogOwner)
+			repo := flagOrConfig(cmd, "repo", changelogRepo)
+
+			c, err := changelog.Collect(ctx, src, owner, repo, changelogFrom, changelogTo, cred.Token)
+			if err != nil {
+				return err
+			}
+			return newPrinter(cmd).Print(c, func(w io.Writer) error {
+				_, err := fmt.Fprint(w, c.Markdown())
+				return err
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&changelogOwner, "owner", "", "GitHub repository owner (default: owner from config)")
+	cmd.Flags().StringVar(&changelogRepo, "repo", "", "GitHub repository name (default: repo from config)")
+	cmd.Flags().StringVar(&changelogFrom, "from", "", "Installed version; its own notes are not included (required)")
+	cmd.Flags().StringVar(&changelogTo, "to", "", "Target version (default: newest release)")
+	cmd.Flags().StringVar(&changelogToken, "token", "", "GitHub token (optional; overrides all other token sources)")
+
+	_ = cmd.MarkFlagRequired("from")
+
+	return cmd
+}
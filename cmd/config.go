@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"automelonloaderinstallergo/config"
+	"automelonloaderinstallergo/internal/output"
+	"automelonloaderinstallergo/internal/releases"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+var configLocal bool
+
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect and edit the configuration file",
+		Long: "Inspect and edit the configuration.\n\n" +
+			"Settings are read from " + config.FileName + " in the user config directory and then the\n" +
+			"current directory; AMLINSTALL_<KEY> environment variables and flags override both.\n" +
+			"Nested keys use dots on the command line and underscores in the environment,\n" +
+			"e.g. timeouts.refresh and AMLINSTALL_TIMEOUTS_REFRESH.",
+	}
+
+	get := &cobra.Command{
+		Use:   "get [key]",
+		Short: "Print the effective value of a key, or all settings",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := newPrinter(cmd)
+			if len(args) == 0 {
+				settings := config.Redacted(viper.AllSettings())
+				return p.Print(settings, func(w io.Writer) error {
+					out, err := yaml.Marshal(settings)
+					if err != nil {
+						return fmt.Errorf("encode config: %w", err)
+					}
+					_, err = w.Write(out)
+					return err
+				})
+			}
+
+			key := args[0]
+			if !config.IsKnownKey(key) && !config.IsSection(key) {
+				return output.Usage(fmt.Errorf("unknown config key %q (known keys: %s)", key, strings.Join(config.Keys(), ", ")))
+			}
+			v := config.Redacted(map[string]any{key: viper.Get(key)})[key]
+			entry := struct {
+				Key   string `json:"key"`
+				Value any    `json:"value"`
+			}{key, v}
+			return p.Print(entry, func(w io.Writer) error {
+				return printValue(w, key, v)
+			})
+		},
+	}
+
+	set := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Write a key to the user config file (or ./config.yaml with --local)",
+		Long: "Write a key to the user config file, or to ./config.yaml with --local.\n\n" +
+			"List keys such as sources take a comma-separated value; timeouts take Go durations\n" +
+			"such as 45s or 5m. The games list can only be changed with `config edit`.",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := configTarget()
+			if err != nil {
+				return err
+			}
+			if err := config.SetInFile(path, args[0], args[1]); err != nil {
+				return err
+			}
+			result := struct {
+				Key  string `json:"key"`
+				File string `json:"file"`
+			}{args[0], path}
+			return newPrinter(cmd).Print(result, func(w io.Writer) error {
+				_, err := fmt.Fprintf(w, "Set %s in %s\n", result.Key, result.File)
+				return err
+			})
+		},
+	}
+	set.Flags().BoolVar(&configLocal, "local", false, "Write to ./config.yaml instead of the user config file")
+
+	path := &cobra.Command{
+		Use:   "path",
+		Short: "List the config files consulted, lowest precedence first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			type configFile struct {
+				Path   string `json:"path"`
+				Loaded bool   `json:"loaded"`
+			}
+			loaded := make(map[string]bool)
+			for _, p := range config.LoadedFiles() {
+				loaded[p] = true
+			}
+			var files []configFile
+			for _, p := range config.SearchPaths() {
+				files = append(files, configFile{Path: p, Loaded: loaded[p]})
+			}
+			return newPrinter(cmd).Print(files, func(w io.Writer) error {
+				for _, f := range files {
+					state := "not found"
+					if f.Loaded {
+						state = "loaded"
+					}
+					fmt.Fprintf(w, "%s (%s)\n", f.Path, state)
+				}
+				return nil
+			})
+		},
+	}
+
+	validate := &cobra.Command{
+		Use:   "validate",
+		Short: "Check the effective configuration for errors",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateConfig(); err != nil {
+				return err
+			}
+			result := struct {
+				Valid bool `json:"valid"`
+			}{true}
+			return newPrinter(cmd).Print(result, func(w io.Writer) error {
+				_, err := fmt.Fprintln(w, "Configuration is valid.")
+				return err
+			})
+		},
+	}
+
+	edit := &cobra.Command{
+		Use:   "edit",
+		Short: "Open the user config file (or ./config.yaml with --local) in $VISUAL or $EDITOR",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := configTarget()
+			if err != nil {
+				return err
+			}
+			if err := ensureConfigFile(path); err != nil {
+				return err
+			}
+			if err := runEditor(path); err != nil {
+				return err
+			}
+
+			// Re-read what was just saved so validation sees the edited values.
+			if err := config.Init(); err != nil {
+				return err
+			}
+			return validateConfig()
+		},
+	}
+	edit.Flags().BoolVar(&configLocal, "local", false, "Edit ./config.yaml instead of the user config file")
+
+	cmd.AddCommand(get, set, path, validate, edit)
+	return cmd
+}
+
+// flagOrConfig returns the value of the named flag when it was set on the command
+// line, and the configuration key of the same name otherwise.
+func flagOrConfig(cmd *cobra.Command, name, flagValue string) string {
+	if cmd.Flags().Changed(name) {
+		return flagValue
+	}
+	return viper.GetString(name)
+}
+
+// configTarget returns the file written by `config set` and `config edit`.
+func configTarget() (string, error) {
+	if configLocal {
+		return config.LocalFile(), nil
+	}
+	path := config.UserFile()
+	if path == "" {
+		return "", errors.New("cannot determine the user config directory; use --local")
+	}
+	return path, nil
+}
+
+func validateConfig() error {
+	c, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if err := c.Validate(releases.KnownKind); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return nil
+}
+
+// printValue writes a single setting as text: lists and maps as YAML, scalars as is.
+func printValue(w io.Writer, key string, v any) error {
+	switch v.(type) {
+	case []any, []string, map[string]any:
+		out, err := yaml.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("encode %s: %w", key, err)
+		}
+		_, err = w.Write(out)
+		return err
+	default:
+		_, err := fmt.Fprintln(w, v)
+		return err
+	}
+}
+
+// ensureConfigFile creates path with a short header when it does not exist yet.
+func ensureConfigFile(path string) error {
+	if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	header := "# amlinstall configuration. Run `app config get` to see every key and its effective value.\n"
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("mkdir: %w", err)
+	}
+	return os.WriteFile(path, []byte(header), 0o600)
+}
+
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	fields := strings.Fields(editor)
+	c := exec.Command(fields[0], append(fields[1:], path)...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("run editor %q: %w", editor, err)
+	}
+	return nil
+}
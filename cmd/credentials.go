@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"automelonloaderinstallergo/internal/credentials"
+	"automelonloaderinstallergo/internal/logger"
+	"automelonloaderinstallergo/internal/redact"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// addCredentialFlags registers the token source flags shared by the TUI and every
+// subcommand, and binds them to their configuration keys.
+func addCredentialFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().String("token-file", "", "Read the GitHub token from this file")
+	cmd.PersistentFlags().String("token-cmd", "", "Run this shell command and use its output as the GitHub token")
+
+	_ = viper.BindPFlag("token_file", cmd.PersistentFlags().Lookup("token-file"))
+	_ = viper.BindPFlag("token_cmd", cmd.PersistentFlags().Lookup("token-cmd"))
+}
+
+// newResolver returns the credential resolver configured by flags and configuration.
+// flagToken is the value of a command's --token flag, if it has one.
+func newResolver(flagToken string) credentials.Resolver {
+	return credentials.Resolver{
+		Token:     flagToken,
+		TokenFile: viper.GetString("token_file"),
+		TokenCmd:  viper.GetString("token_cmd"),
+	}
+}
+
+// resolveGitHubCredential resolves the GitHub token and logs which source supplied it.
+func resolveGitHubCredential(cmd *cobra.Command, flagToken string) (credentials.Credential, error) {
+	cred, err := newResolver(flagToken).Resolve(cmd.Context(), credentials.DefaultHost)
+	if err != nil {
+		return cred, err
+	}
+	if cred.Token != "" {
+		redact.AddSecret(cred.Token)
+		logger.Log.Info("Using GitHub token.", "source", cred.Source)
+	}
+	return cred, nil
+}
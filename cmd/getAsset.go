@@ -3,13 +3,12 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"os"
 	"path/filepath"
-	"time"
 
 	"automelonloaderinstallergo/internal/releases"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var (
@@ -19,50 +18,77 @@ var (
 	getAssetAsset  string
 	getAssetOutput string
 	getAssetToken  string
+	getAssetList   bool
 )
 
 func newGetAssetCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "getAsset",
-		Short: "Download a specific release asset by tag from GitHub",
+		Short: "Download a specific release asset by tag",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx, cancel := context.WithTimeout(cmd.Context(), 2*time.Minute)
+			ctx, cancel := context.WithTimeout(cmd.Context(), viper.GetDuration("timeouts.download"))
 			defer cancel()
 
-			token := resolveToken(getAssetToken)
+			cred, err := resolveGitHubCredential(cmd, getAssetToken)
+			if err != nil {
+				return err
+			}
+			src, err := newSource(cmd)
+			if err != nil {
+				return err
+			}
+
+			owner := flagOrConfig(cmd, "owner", getAssetOwner)
+			repo := flagOrConfig(cmd, "repo", getAssetRepo)
+			if getAssetList {
+				// --asset only filters the listing here; it is not defaulted from config.
+				return printAssets(ctx, cmd, src, owner, repo, getAssetTag, getAssetAsset, cred.Token)
+			}
+
+			asset, err := releases.FindAsset(ctx, src, owner, repo, getAssetTag, flagOrConfig(cmd, "asset", getAssetAsset), cred.Token)
+			if err != nil {
+				return err
+			}
 			out := getAssetOutput
 			if out == "" {
-				out = filepath.Join(".", "downloads", getAssetAsset)
+				out = filepath.Join(viper.GetString("output_dir"), asset.Name)
 			}
 
-			src := releases.NewGitHubSource()
-			if err := src.DownloadAsset(ctx, getAssetOwner, getAssetRepo, getAssetTag, getAssetAsset, out, token); err != nil {
+			d, err := releases.DownloadFile(ctx, src, owner, repo, getAssetTag, asset, out, cred.Token)
+			if err != nil {
 				return err
 			}
 
-			fmt.Fprintln(cmd.OutOrStdout(), "Downloaded:", out)
-			return nil
+			p := newPrinter(cmd)
+			if !p.Structured() {
+				fmt.Fprintln(cmd.OutOrStdout(), "Downloaded:", out)
+				return nil
+			}
+			res := downloadResult{Tag: getAssetTag, Asset: asset.Name, Path: d.Path, Size: d.Size, SHA256: d.SHA256, URL: asset.URL}
+			return p.Print(res, nil)
 		},
 	}
 
-	cmd.Flags().StringVar(&getAssetOwner, "owner", "", "GitHub repository owner (required)")
-	cmd.Flags().StringVar(&getAssetRepo, "repo", "", "GitHub repository name (required)")
+	cmd.Flags().StringVar(&getAssetOwner, "owner", "", "GitHub repository owner (default: owner from config)")
+	cmd.Flags().StringVar(&getAssetRepo, "repo", "", "GitHub repository name (default: repo from config)")
 	cmd.Flags().StringVar(&getAssetTag, "tag", "", "GitHub release tag (required)")
-	cmd.Flags().StringVar(&getAssetAsset, "asset", "", "Release asset filename (required)")
-	cmd.Flags().StringVar(&getAssetOutput, "output", "", "Output path (optional; defaults to ./downloads/<asset>)")
-	cmd.Flags().StringVar(&getAssetToken, "token", "", "GitHub token (optional; overrides GITHUB_TOKEN)")
+	cmd.Flags().StringVar(&getAssetAsset, "asset", "", "Release asset filename or glob matching exactly one asset, e.g. 'MelonLoader.x*.zip' (default: asset from config)")
+	cmd.Flags().StringVar(&getAssetOutput, "output", "", "Output path (optional; defaults to <output_dir>/<asset>)")
+	cmd.Flags().BoolVar(&getAssetList, "list", false, "List the release's assets (filtered by --asset if given) instead of downloading")
+	cmd.Flags().StringVar(&getAssetToken, "token", "", "GitHub token (optional; overrides all other token sources)")
 
-	_ = cmd.MarkFlagRequired("owner")
-	_ = cmd.MarkFlagRequired("repo")
 	_ = cmd.MarkFlagRequired("tag")
-	_ = cmd.MarkFlagRequired("asset")
 
 	return cmd
 }
 
-func resolveToken(flagToken string) string {
-	if flagToken != "" {
-		return flagToken
-	}
-	return os.Getenv("GITHUB_TOKEN")
+// downloadResult is the structured output of getAsset.
+type downloadResult struct {
+	Tag    string `json:"tag"`
+	Asset  string `json:"asset"`
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	// URL is the asset's download URL, when the source lists it.
+	URL string `json:"url,omitempty"`
 }
@@ -3,46 +3,133 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"time"
+	"io"
+	"regexp"
 
+	"automelonloaderinstallergo/internal/output"
 	"automelonloaderinstallergo/internal/releases"
+	"automelonloaderinstallergo/internal/version"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var (
-	getTagsOwner string
-	getTagsRepo  string
-	getTagsToken string
+	getTagsOwner        string
+	getTagsRepo         string
+	getTagsToken        string
+	getTagsSort         string
+	getTagsInclude      []string
+	getTagsExclude      []string
+	getTagsNoPrerelease bool
+	getTagsLimit        int
+	getTagsLatest       bool
+	getTagsConstraint   string
 )
 
 func newGetTagsCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "getTags",
-		Short: "List tags from a remote GitHub repository",
+		Short: "List tags from a remote repository",
+		Long: "List tags from a remote repository, newest version first.\n\n" +
+			"Filters apply in this order: --include/--exclude, --no-prerelease and --constraint,\n" +
+			"then --sort and --limit. --latest prints only the newest stable tag that passes the\n" +
+			"filters (or the newest prerelease if none is stable).",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Second)
+			ctx, cancel := context.WithTimeout(cmd.Context(), viper.GetDuration("timeouts.refresh"))
 			defer cancel()
 
-			src := releases.NewGitHubSource()
-			tags, err := src.ListTags(ctx, getTagsOwner, getTagsRepo, getTagsToken)
+			q, err := getTagsQuery()
+			if err != nil {
+				return output.Usage(err)
+			}
+
+			src, err := newSource(cmd)
+			if err != nil {
+				return err
+			}
+			cred, err := resolveGitHubCredential(cmd, getTagsToken)
 			if err != nil {
 				return err
 			}
+			owner := flagOrConfig(cmd, "owner", getTagsOwner)
+			repo := flagOrConfig(cmd, "repo", getTagsRepo)
+			tags, err := src.ListTags(ctx, owner, repo, cred.Token)
+			if err != nil {
+				return err
+			}
+			if q.Sort == releases.SortDate {
+				if q.Dates, err = releases.TagDates(ctx, src, owner, repo, cred.Token); err != nil {
+					return fmt.Errorf("sort by date: %w", err)
+				}
+			}
 
-			for _, t := range tags {
-				fmt.Fprintln(cmd.OutOrStdout(), t)
+			infos := releases.QueryTags(tags, q)
+			if getTagsLatest && len(infos) == 0 {
+				return fmt.Errorf("no tag of %s/%s matches the filters: %w", owner, repo, releases.ErrNotFound)
 			}
-			return nil
+			return newPrinter(cmd).Print(infos, func(w io.Writer) error {
+				for _, t := range infos {
+					fmt.Fprintln(w, t.Tag)
+				}
+				return nil
+			})
 		},
 	}
 
-	cmd.Flags().StringVar(&getTagsOwner, "owner", "", "GitHub repository owner (required)")
-	cmd.Flags().StringVar(&getTagsRepo, "repo", "", "GitHub repository name (required)")
-	cmd.Flags().StringVar(&getTagsToken, "token", "", "GitHub token (optional; not used for git ls-remote, provided for symmetry)")
-
-	_ = cmd.MarkFlagRequired("owner")
-	_ = cmd.MarkFlagRequired("repo")
+	cmd.Flags().StringVar(&getTagsOwner, "owner", "", "GitHub repository owner (default: owner from config)")
+	cmd.Flags().StringVar(&getTagsRepo, "repo", "", "GitHub repository name (default: repo from config)")
+	cmd.Flags().StringVar(&getTagsToken, "token", "", "GitHub token (optional; authenticates git ls-remote over HTTPS for private repositories)")
+	cmd.Flags().StringVar(&getTagsSort, "sort", "semver", "Order: semver (newest version first), lexical (byte order) or date (newest release first)")
+	cmd.Flags().StringArrayVar(&getTagsInclude, "include", nil, "Only list tags matching this regular expression (repeatable; any may match)")
+	cmd.Flags().StringArrayVar(&getTagsExclude, "exclude", nil, "Skip tags matching this regular expression (repeatable)")
+	cmd.Flags().BoolVar(&getTagsNoPrerelease, "no-prerelease", false, "Skip prereleases and nightly CI builds")
+	cmd.Flags().IntVar(&getTagsLimit, "limit", 0, "List at most N tags (0: no limit)")
+	cmd.Flags().BoolVar(&getTagsLatest, "latest", false, "Print only the newest stable tag that passes the filters")
+	cmd.Flags().StringVar(&getTagsConstraint, "constraint", "", "Only list versions satisfying this constraint, e.g. '>=0.6, <0.7' or '~0.6.2'")
 
 	return cmd
 }
+
+// getTagsQuery builds the tag query from the getTags flags.
+func getTagsQuery() (releases.TagQuery, error) {
+	sortBy, err := releases.ParseTagSort(getTagsSort)
+	if err != nil {
+		return releases.TagQuery{}, err
+	}
+	if getTagsLimit < 0 {
+		return releases.TagQuery{}, fmt.Errorf("--limit must not be negative")
+	}
+	q := releases.TagQuery{
+		Sort:         sortBy,
+		NoPrerelease: getTagsNoPrerelease,
+		Latest:       getTagsLatest,
+		Limit:        getTagsLimit,
+	}
+	if q.Include, err = compilePatterns("--include", getTagsInclude); err != nil {
+		return q, err
+	}
+	if q.Exclude, err = compilePatterns("--exclude", getTagsExclude); err != nil {
+		return q, err
+	}
+	if getTagsConstraint != "" {
+		c, err := version.ParseConstraint(getTagsConstraint)
+		if err != nil {
+			return q, err
+		}
+		q.Constraint = &c
+	}
+	return q, nil
+}
+
+func compilePatterns(flag string, exprs []string) ([]*regexp.Regexp, error) {
+	out := make([]*regexp.Regexp, 0, len(exprs))
+	for _, e := range exprs {
+		re, err := regexp.Compile(e)
+		if err != nil {
+			return nil, fmt.Errorf("%s %q: %w", flag, e, err)
+		}
+		out = append(out, re)
+	}
+	return out, nil
+}
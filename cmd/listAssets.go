@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"automelonloaderinstallergo/internal/releases"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	listAssetsOwner string
+	listAssetsRepo  string
+	listAssetsTag   string
+	listAssetsAsset string
+	listAssetsToken string
+)
+
+func newListAssetsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "listAssets",
+		Short: "List the assets of a release, optionally filtered by a glob",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(cmd.Context(), viper.GetDuration("timeouts.refresh"))
+			defer cancel()
+
+			src, err := newSource(cmd)
+			if err != nil {
+				return err
+			}
+			cred, err := resolveGitHubCredential(cmd, listAssetsToken)
+			if err != nil {
+				return err
+			}
+			owner := flagOrConfig(cmd, "owner", listAssetsOwner)
+			repo := flagOrConfig(cmd, "repo", listAssetsRepo)
+			return printAssets(ctx, cmd, src, owner, repo, listAssetsTag, listAssetsAsset, cred.Token)
+		},
+	}
+
+	cmd.Flags().StringVar(&listAssetsOwner, "owner", "", "GitHub repository owner (default: owner from config)")
+	cmd.Flags().StringVar(&listAssetsRepo, "repo", "", "GitHub repository name (default: repo from config)")
+	cmd.Flags().StringVar(&listAssetsTag, "tag", "", "Release tag (required)")
+	cmd.Flags().StringVar(&listAssetsAsset, "asset", "", "Only list assets matching this glob, e.g. 'MelonLoader.x*.zip'")
+	cmd.Flags().StringVar(&listAssetsToken, "token", "", "GitHub token (optional; overrides all other token sources)")
+
+	_ = cmd.MarkFlagRequired("tag")
+
+	return cmd
+}
+
+// printAssets writes the assets of owner/repo@tag matching pattern as a table, or
+// in the structured output format.
+func printAssets(ctx context.Context, cmd *cobra.Command, src releases.Source, owner, repo, tag, pattern, token string) error {
+	assets, err := releases.ListAssets(ctx, src, owner, repo, tag, token)
+	if err != nil {
+		return err
+	}
+	assets, err = releases.MatchAssets(assets, pattern)
+	if err != nil {
+		return err
+	}
+	if len(assets) == 0 {
+		return fmt.Errorf("no assets in %s/%s@%s match %q", owner, repo, tag, pattern)
+	}
+
+	return newPrinter(cmd).Print(assets, func(w io.Writer) error {
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tSIZE\tDOWNLOADS")
+		for _, a := range assets {
+			downloads := "-"
+			if a.DownloadCount > 0 {
+				downloads = fmt.Sprint(a.DownloadCount)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", a.Name, a.HumanSize(), downloads)
+		}
+		return tw.Flush()
+	})
+}
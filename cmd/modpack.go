@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"automelonloaderinstallergo/internal/ghrel"
+	"automelonloaderinstallergo/internal/modpack"
+	"automelonloaderinstallergo/internal/thunderstore"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	modpackName    string
+	modpackConfigs bool
+	modpackShare   bool
+	modpackDryRun  bool
+)
+
+// modpackExport is the output of modpack export when writing a file or share code.
+type modpackExport struct {
+	Path      string `json:"path,omitempty"`
+	ShareCode string `json:"share_code,omitempty"`
+	Mods      int    `json:"mods"`
+	Configs   int    `json:"configs"`
+	// NotShared lists the files a YAML modpack or share code cannot reproduce.
+	NotShared []string `json:"not_shared,omitempty"`
+}
+
+func newModpackCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "modpack",
+		Short: "Export a game's mods as a shareable modpack, or import one",
+		Long: "Export a game's MelonLoader version, mods, plugins and user libraries, with their source,\n" +
+			"version and SHA-256, and optionally its UserData configs, as a modpack; and recreate a\n" +
+			"modpack in another game directory.\n\n" +
+			"A modpack is a YAML file, a self-contained zip that also holds the files, or a share code\n" +
+			"to paste into chat. YAML files and share codes only reproduce files installed from GitHub\n" +
+			"releases or Thunderstore packages; share codes carry no configs.",
+	}
+	cmd.PersistentFlags().StringVar(&modsGame, "game", "", "Configured game name or game directory")
+
+	export := &cobra.Command{
+		Use:   "export [file.yaml|file.zip]",
+		Short: "Describe the game's mods as a modpack",
+		Long: "Describe the game's mods as a modpack. A file ending in .zip is a self-contained zip;\n" +
+			"any other file is YAML. Without a file, the YAML is written to standard output, or\n" +
+			"the modpack in --output-format.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := resolveGame(modsGame)
+			if err != nil {
+				return err
+			}
+			p, err := modpack.Export(g, modpack.ExportOptions{Name: modpackName, Configs: modpackConfigs})
+			if err != nil {
+				return err
+			}
+			if len(args) == 0 && !modpackShare {
+				return newPrinter(cmd).Print(p, p.WriteYAML)
+			}
+
+			out := modpackExport{Mods: len(p.Mods), Configs: len(p.Configs)}
+			zipped := len(args) == 1 && strings.EqualFold(filepath.Ext(args[0]), ".zip")
+			if !zipped || modpackShare {
+				for _, m := range p.Mods {
+					if !m.Reproducible() {
+						out.NotShared = append(out.NotShared, m.File)
+					}
+				}
+			}
+			if len(args) == 1 {
+				out.Path = args[0]
+				err := ghrel.WriteFileAtomically(out.Path, func(f *os.File) error {
+					if zipped {
+						return p.WriteZip(f)
+					}
+					return p.WriteYAML(f)
+				})
+				if err != nil {
+					return fmt.Errorf("write modpack: %w", err)
+				}
+			}
+			if modpackShare {
+				if out.ShareCode, err = p.ShareCode(); err != nil {
+					return err
+				}
+			}
+
+			return newPrinter(cmd).Print(out, func(w io.Writer) error {
+				if out.Path != "" {
+					fmt.Fprintf(w, "Wrote modpack of %d mods and %d configs to %s\n", out.Mods, out.Configs, out.Path)
+				}
+				if out.ShareCode != "" {
+					fmt.Fprintln(w, out.ShareCode)
+				}
+				for _, f := range out.NotShared {
+					fmt.Fprintf(w, "warning: %s was not installed from a release or package; export a .zip to share it\n", f)
+				}
+				return nil
+			})
+		},
+	}
+	export.Flags().StringVar(&modpackName, "name", "", "Name of the modpack")
+	export.Flags().BoolVar(&modpackConfigs, "configs", false, "Include the files under UserData")
+	export.Flags().BoolVar(&modpackShare, "share", false, "Print a share code for chat")
+
+	imp := &cobra.Command{
+		Use:   "import <file|share-code>",
+		Short: "Recreate a modpack in the game",
+		Long: "Recreate a modpack file or share code in the game directory.\n\n" +
+			"Files the game lacks, or has in another version, are taken from the modpack zip, or\n" +
+			"else downloaded from their GitHub release through the configured --source, or from\n" +
+			"their Thunderstore package on the thunderstore_url site. Every file must match the\n" +
+			"modpack's SHA-256. Enabled states are set as in the modpack, and its configs replace\n" +
+			"the game's. Other installed files are kept.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := resolveGame(modsGame)
+			if err != nil {
+				return err
+			}
+			p, err := modpack.Load(args[0])
+			if err != nil {
+				return err
+			}
+			ctx, cancel := context.WithTimeout(cmd.Context(), viper.GetDuration("timeouts.download"))
+			defer cancel()
+
+			cred, err := resolveGitHubCredential(cmd, "")
+			if err != nil {
+				return err
+			}
+			src, err := newSource(cmd)
+			if err != nil {
+				return err
+			}
+			scanner, err := modScanner()
+			if err != nil {
+				return err
+			}
+			im := modpack.Importer{
+				Source:   src,
+				Token:    cred.Token,
+				Packages: thunderstore.NewClient(viper.GetString("thunderstore_url")),
+				Force:    modsForce,
+				Scan:     scanner,
+				DryRun:   modpackDryRun,
+			}
+			res, err := im.Import(ctx, g, p)
+			if err != nil {
+				return err
+			}
+
+			err = newPrinter(cmd).Print(res, func(w io.Writer) error {
+				tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(tw, "KIND\tFILE\tVERSION\tFROM\tSTATUS")
+				for _, r := range res.Mods {
+					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Mod.Kind, r.Mod.File, orDash(r.Mod.Version), orDash(r.From), r.Status)
+				}
+				if err := tw.Flush(); err != nil {
+					return err
+				}
+				verb := "Wrote"
+				if modpackDryRun {
+					verb = "Would write"
+				}
+				for _, c := range res.Configs {
+					fmt.Fprintf(w, "%s config UserData/%s\n", verb, c)
+				}
+				for _, r := range res.Mods {
+					if r.Error != "" {
+						fmt.Fprintf(w, "error: %s: %s\n", r.Mod.File, r.Error)
+					}
+					if r.Scan != nil {
+						printScanReport(w, r.Mod.File, *r.Scan)
+					}
+				}
+				for _, msg := range res.Warnings {
+					fmt.Fprintf(w, "warning: %s\n", msg)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if n := res.Failed(); n > 0 {
+				return fmt.Errorf("%d of %d mods could not be imported", n, len(res.Mods))
+			}
+			return nil
+		},
+	}
+	imp.Flags().BoolVar(&modpackDryRun, "dry-run", false, "Only show what would be installed")
+	imp.Flags().BoolVar(&modsForce, "force", false, "Install files built for another game or MelonLoader version")
+
+	cmd.AddCommand(export, imp)
+	return cmd
+}
@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"automelonloaderinstallergo/config"
+	"automelonloaderinstallergo/internal/mods"
+	"automelonloaderinstallergo/internal/output"
+	"automelonloaderinstallergo/internal/thunderstore"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	modsGame    string
+	modsKind    string
+	modsReplace bool
+	modsForce   bool
+)
+
+func newModsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mods",
+		Short: "Manage a game's mods, plugins and user libraries",
+		Long: "Manage the files in a game's Mods, Plugins and UserLibs folders.\n\n" +
+			"Disabled files are moved under Mods/Disabled, where MelonLoader does not load them.\n" +
+			"Every change is recorded in the game's install manifest, " + mods.ManifestPath + ".\n\n" +
+			"--game takes the name of a game from the games config key or a game directory;\n" +
+			"it may be omitted when exactly one game is configured.\n\n" +
+			"Mods are checked against the game named in <Game>_Data/app.info and the installed\n" +
+			"MelonLoader version, using their MelonGame and VerifyLoaderVersion attributes.",
+	}
+	cmd.PersistentFlags().StringVar(&modsGame, "game", "", "Configured game name or game directory")
+	cmd.PersistentFlags().String("thunderstore-url", thunderstore.DefaultURL, "Thunderstore site packages are installed from")
+	_ = viper.BindPFlag("thunderstore_url", cmd.PersistentFlags().Lookup("thunderstore-url"))
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List mods, plugins and user libraries with their state",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, kind, err := modsTarget()
+			if err != nil {
+				return err
+			}
+			all, err := g.List()
+			if err != nil {
+				return err
+			}
+			list := []mods.Mod{}
+			for _, m := range all {
+				if kind == "" || m.Kind == kind {
+					list = append(list, m)
+				}
+			}
+			return newPrinter(cmd).Print(list, func(w io.Writer) error {
+				if len(list) == 0 {
+					_, err := fmt.Fprintf(w, "No mods installed in %s\n", g.Dir)
+					return err
+				}
+				tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(tw, "KIND\tNAME\tVERSION\tSTATE\tTRACKED\tFILE")
+				for _, m := range list {
+					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", m.Kind, m.DisplayName(), modVersion(m), modState(m), yesNo(m.Tracked), m.Name)
+				}
+				if err := tw.Flush(); err != nil {
+					return err
+				}
+				for _, m := range list {
+					printModWarnings(w, m)
+				}
+				return nil
+			})
+		},
+	}
+
+	add := &cobra.Command{
+		Use:   "add <file>...",
+		Short: "Copy files into the game's Mods folder (or another --kind)",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, kind, err := modsTarget()
+			if err != nil {
+				return err
+			}
+			if kind == "" {
+				kind = mods.KindMod
+			}
+			scanner, err := modScanner()
+			if err != nil {
+				return err
+			}
+			var added []mods.Mod
+			for _, src := range args {
+				m, err := g.Add(src, kind, mods.AddOptions{Replace: modsReplace, Force: modsForce, Scan: scanner})
+				if err != nil {
+					return err
+				}
+				added = append(added, m)
+			}
+			return printModChanges(cmd, "Added", added)
+		},
+	}
+	add.Flags().BoolVar(&modsReplace, "replace", false, "Overwrite files that are already installed")
+	add.Flags().BoolVar(&modsForce, "force", false, "Install mods built for another game or MelonLoader version")
+	add.Flags().Bool("scan", false, "Scan files for risky references first, as with the scan.enabled key")
+	_ = viper.BindPFlag("scan.enabled", add.Flags().Lookup("scan"))
+
+	cmd.AddCommand(list, add,
+		newModChangeCmd("remove <name>...", "Delete installed files", "Removed", mods.Game.Remove),
+		newModChangeCmd("enable <name>...", "Move disabled files back into place", "Enabled", mods.Game.Enable),
+		newModChangeCmd("disable <name>...", "Move files under Mods/Disabled", "Disabled", mods.Game.Disable),
+		newModsOriginCmd(),
+		newModsUpdateCmd(),
+		newModsScanCmd(),
+	)
+	for _, c := range cmd.Commands() {
+		c.Flags().StringVar(&modsKind, "kind", "", "Limit to one kind: mod, plugin or userlib (default: any; add: mod)")
+	}
+	// Packages say where their files go, so install and import take no --kind.
+	cmd.AddCommand(newModsInstallCmd(), newModsImportCmd())
+	// Conflicts span every kind.
+	cmd.AddCommand(newModsDoctorCmd())
+	return cmd
+}
+
+// newModChangeCmd returns a subcommand applying change to each file named in its
+// arguments. Names match case-insensitively, with or without the extension.
+func newModChangeCmd(use, short, verb string, change func(mods.Game, string, mods.Kind) (mods.Mod, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, kind, err := modsTarget()
+			if err != nil {
+				return err
+			}
+			var changed []mods.Mod
+			for _, name := range args {
+				m, err := change(g, name, kind)
+				if err != nil {
+					return err
+				}
+				changed = append(changed, m)
+			}
+			return printModChanges(cmd, verb, changed)
+		},
+	}
+}
+
+func printModChanges(cmd *cobra.Command, verb string, ms []mods.Mod) error {
+	return newPrinter(cmd).Print(ms, func(w io.Writer) error {
+		for _, m := range ms {
+			fmt.Fprintf(w, "%s %s %s (%s)\n", verb, m.Kind, m.Name, m.Path)
+			printModWarnings(w, m)
+			if m.Scan != nil {
+				printScanReport(w, m.DisplayName(), *m.Scan)
+			}
+		}
+		return nil
+	})
+}
+
+// modsTarget resolves the --game and --kind flags.
+func modsTarget() (mods.Game, mods.Kind, error) {
+	var kind mods.Kind
+	if modsKind != "" {
+		k, err := mods.ParseKind(modsKind)
+		if err != nil {
+			return mods.Game{}, "", output.Usage(err)
+		}
+		kind = k
+	}
+	g, err := resolveGame(modsGame)
+	return g, kind, err
+}
+
+// resolveGame finds a game by configured name or directory. An empty name selects
+// the only configured game.
+func resolveGame(name string) (mods.Game, error) {
+	games, err := configuredGames()
+	if err != nil {
+		return mods.Game{}, err
+	}
+	if name == "" {
+		switch len(games) {
+		case 0:
+			return mods.Game{}, output.Usage(errors.New("no games configured; pass --game with a game directory or add one with `config edit`"))
+		case 1:
+			return games[0], nil
+		default:
+			var names []string
+			for _, g := range games {
+				names = append(names, g.Name)
+			}
+			return mods.Game{}, output.Usage(fmt.Errorf("several games are configured; pass --game (one of %s)", strings.Join(names, ", ")))
+		}
+	}
+	for _, g := range games {
+		if strings.EqualFold(g.Name, name) {
+			return g, nil
+		}
+	}
+	if fi, err := os.Stat(name); err == nil && fi.IsDir() {
+		return mods.Game{Dir: name}, nil
+	}
+	return mods.Game{}, output.Usage(fmt.Errorf("--game %q is neither a configured game nor a directory", name))
+}
+
+// configuredGames returns the games config key as mods.Games, expanding a leading
+// "~" in their paths.
+func configuredGames() ([]mods.Game, error) {
+	c, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	home, _ := os.UserHomeDir()
+	games := make([]mods.Game, 0, len(c.Games))
+	for _, g := range c.Games {
+		dir := g.Path
+		if home != "" && (dir == "~" || strings.HasPrefix(dir, "~/")) {
+			dir = filepath.Join(home, dir[1:])
+		}
+		games = append(games, mods.Game{Name: g.Name, Dir: dir})
+	}
+	return games, nil
+}
+
+func modState(m mods.Mod) string {
+	if m.Enabled {
+		return "enabled"
+	}
+	return "disabled"
+}
+
+// printModWarnings writes one line per compatibility warning of m.
+func printModWarnings(w io.Writer, m mods.Mod) {
+	for _, msg := range m.Warnings {
+		fmt.Fprintf(w, "warning: %s: %s\n", m.DisplayName(), msg)
+	}
+}
+
+// modVersion returns the version a melon declares, or "-".
+func modVersion(m mods.Mod) string {
+	if m.Info == nil || m.Info.Version == "" {
+		return "-"
+	}
+	return m.Info.Version
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"automelonloaderinstallergo/internal/mods"
+
+	"github.com/spf13/cobra"
+)
+
+var modsDoctorKeep []string
+
+func newModsDoctorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Find assemblies defined by more than one file",
+		Long: "Find .NET assemblies that more than one enabled file in Mods, Plugins and UserLibs\n" +
+			"defines, by assembly name, version and public key token, and files that ship their\n" +
+			"own copy of a library MelonLoader already provides, such as 0Harmony or\n" +
+			"Il2CppInterop. Only one assembly of a name is loaded, so such files break mods\n" +
+			"depending on which copy wins.\n\n" +
+			"--keep names the copy to keep, by its path as listed or its file name; the other\n" +
+			"copies in Mods, Plugins and UserLibs are disabled. MelonLoader's own files are never\n" +
+			"changed, so keeping one of them disables every copy in the game's folders.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := resolveGame(modsGame)
+			if err != nil {
+				return err
+			}
+			if len(modsDoctorKeep) > 0 {
+				var disabled []mods.Mod
+				for _, path := range modsDoctorKeep {
+					ms, err := g.Keep(path)
+					disabled = append(disabled, ms...)
+					if err != nil {
+						return err
+					}
+				}
+				return printModChanges(cmd, "Disabled", disabled)
+			}
+
+			conflicts, err := g.Conflicts()
+			if err != nil {
+				return err
+			}
+			if conflicts == nil {
+				conflicts = []mods.Conflict{}
+			}
+			return newPrinter(cmd).Print(conflicts, func(w io.Writer) error {
+				if len(conflicts) == 0 {
+					_, err := fmt.Fprintf(w, "No conflicting assemblies in %s\n", g.Dir)
+					return err
+				}
+				tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(tw, "ASSEMBLY\tCONFLICT\tPATH\tVERSION\tTOKEN")
+				for _, c := range conflicts {
+					for _, cp := range c.Copies {
+						fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", c.Assembly, c.Kind, cp.Path, cp.Version, orDash(cp.PublicKeyToken))
+					}
+				}
+				if err := tw.Flush(); err != nil {
+					return err
+				}
+				_, err := fmt.Fprintln(w, "Pass --keep <path> to keep one copy of an assembly and disable the others.")
+				return err
+			})
+		},
+	}
+	cmd.Flags().StringArrayVar(&modsDoctorKeep, "keep", nil, "Keep this copy of a conflicting assembly and disable the others (repeatable)")
+	return cmd
+}
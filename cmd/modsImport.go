@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"automelonloaderinstallergo/internal/mods"
+	"automelonloaderinstallergo/internal/thunderstore"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// profileImportResult is the output of mods import.
+type profileImportResult struct {
+	Profile string `json:"profile"`
+	mods.PackagePlan
+	// Configs are the UserData files copied from the profile.
+	Configs []string `json:"configs,omitempty"`
+	// Disabled lists the packages the profile has disabled.
+	Disabled []thunderstore.Ref `json:"disabled,omitempty"`
+}
+
+func newModsImportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import <profile.r2z>",
+		Short: "Reproduce an r2modman or Thunderstore Mod Manager profile export",
+		Long: "Reproduce a profile exported by r2modman or Thunderstore Mod Manager (a .r2z file)\n" +
+			"in the game directory.\n\n" +
+			"Every package in the profile's export.r2x is installed at its listed version, with\n" +
+			"its dependencies, as by `mods install`; packages installed at that version are kept.\n" +
+			"Packages disabled in the profile are disabled, and the profile's UserData config files\n" +
+			"replace the game's. Config files outside UserData are not imported.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, _, err := modsTarget()
+			if err != nil {
+				return err
+			}
+			p, err := thunderstore.ReadProfile(args[0])
+			if err != nil {
+				return err
+			}
+			ctx, cancel := context.WithTimeout(cmd.Context(), viper.GetDuration("timeouts.download"))
+			defer cancel()
+
+			client := thunderstore.NewClient(viper.GetString("thunderstore_url"))
+			installed, err := g.Packages()
+			if err != nil {
+				return err
+			}
+			roots, err := p.Roots(ctx, client, installed)
+			if err != nil {
+				return err
+			}
+
+			pres, err := installPackages(ctx, client, g, roots)
+			if err != nil {
+				return err
+			}
+			res := profileImportResult{Profile: p.Name, PackagePlan: pres}
+			for _, f := range p.Files {
+				if top, _, _ := strings.Cut(f, "/"); !strings.EqualFold(top, "UserData") {
+					res.Warnings = append(res.Warnings, "config file "+f+" is outside UserData and was not imported")
+				} else if modsInstallDryRun {
+					res.Configs = append(res.Configs, f)
+				}
+			}
+			if !modsInstallDryRun {
+				if res.Configs, err = g.ImportUserData(args[0]); err != nil {
+					return err
+				}
+			}
+			for _, pm := range p.Mods {
+				if pm.Ref.IsMelonLoader() {
+					continue
+				}
+				if !pm.Enabled {
+					res.Disabled = append(res.Disabled, pm.Ref)
+				}
+				if modsInstallDryRun {
+					continue
+				}
+				if _, err := g.SetPackageEnabled(pm.Ref, pm.Enabled); err != nil {
+					return err
+				}
+			}
+
+			return newPrinter(cmd).Print(res, func(w io.Writer) error {
+				printPackagePlan(w, res.PackagePlan)
+				verb, disabled := "Copied", "Disabled"
+				if modsInstallDryRun {
+					verb, disabled = "Would copy", "Would disable"
+				}
+				for _, f := range res.Configs {
+					fmt.Fprintf(w, "%s config %s\n", verb, f)
+				}
+				for _, r := range res.Disabled {
+					fmt.Fprintf(w, "%s %s\n", disabled, r)
+				}
+				printPackageWarnings(w, res.PackagePlan)
+				return nil
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&modsInstallDryRun, "dry-run", false, "Only show what would be installed")
+	cmd.Flags().BoolVar(&modsForce, "force", false, "Install packages built for another game or MelonLoader version")
+	return cmd
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"automelonloaderinstallergo/internal/mods"
+	"automelonloaderinstallergo/internal/output"
+	"automelonloaderinstallergo/internal/thunderstore"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var modsInstallDryRun bool
+
+func newModsInstallCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "install <package.zip|Namespace-Name[-1.2.3]>...",
+		Short: "Install Thunderstore packages and their dependencies",
+		Long: "Install Thunderstore packages, given as package archives or as Namespace-Name with an\n" +
+			"optional version, together with the packages they depend on.\n\n" +
+			"Dependencies are looked up on the Thunderstore site in the thunderstore_url config key.\n" +
+			"A dependency's version is a minimum: the highest version any package requires is\n" +
+			"installed, and packages already installed at that version or later are kept.\n" +
+			"Dependency cycles and requirements on different major versions are errors.\n\n" +
+			"The files under Mods/, Plugins/ and UserLibs/ in a package go to those folders,\n" +
+			"UserData/ files are copied unless they exist, and other .dll files go to Mods.\n" +
+			"Files an earlier version of a package installed that the new one lacks are removed,\n" +
+			"unless changed since.\n" +
+			"A dependency on LavaGang-MelonLoader is checked against the installed MelonLoader.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, _, err := modsTarget()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := context.WithTimeout(cmd.Context(), viper.GetDuration("timeouts.download"))
+			defer cancel()
+
+			client := thunderstore.NewClient(viper.GetString("thunderstore_url"))
+			var roots []thunderstore.Node
+			for _, arg := range args {
+				n, err := packageNode(ctx, client, arg)
+				if err != nil {
+					return err
+				}
+				roots = append(roots, n)
+			}
+
+			res, err := installPackages(ctx, client, g, roots)
+			if err != nil {
+				return err
+			}
+			return newPrinter(cmd).Print(res, func(w io.Writer) error {
+				printPackagePlan(w, res)
+				printPackageWarnings(w, res)
+				return nil
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&modsInstallDryRun, "dry-run", false, "Only show what would be installed")
+	cmd.Flags().BoolVar(&modsForce, "force", false, "Install packages built for another game or MelonLoader version")
+	return cmd
+}
+
+// packageNode returns the node of a package argument: a package archive, or a
+// Namespace-Name[-version] reference looked up with client.
+func packageNode(ctx context.Context, client *thunderstore.Client, arg string) (thunderstore.Node, error) {
+	if fi, err := os.Stat(arg); err == nil && !fi.IsDir() || strings.EqualFold(filepath.Ext(arg), ".zip") {
+		m, err := thunderstore.ReadManifest(arg)
+		if err != nil {
+			return thunderstore.Node{}, fmt.Errorf("%s: %w", arg, err)
+		}
+		deps, err := m.Deps()
+		if err != nil {
+			return thunderstore.Node{}, err
+		}
+		return thunderstore.Node{Ref: thunderstore.LocalRef(arg, m), Deps: deps, Path: arg}, nil
+	}
+	ref, err := thunderstore.ParseRef(arg)
+	if err != nil {
+		return thunderstore.Node{}, output.Usage(err)
+	}
+	return client.Node(ctx, ref)
+}
+
+// installPackages installs roots and their dependencies in g, or only plans the
+// install with --dry-run.
+func installPackages(ctx context.Context, client *thunderstore.Client, g mods.Game, roots []thunderstore.Node) (mods.PackagePlan, error) {
+	if modsInstallDryRun {
+		return g.PlanPackages(ctx, client, roots)
+	}
+	scanner, err := modScanner()
+	if err != nil {
+		return mods.PackagePlan{}, err
+	}
+	return g.InstallPackages(ctx, client, roots, mods.AddOptions{Force: modsForce, Scan: scanner})
+}
+
+// printPackagePlan writes the packages installed, or to be installed on a dry run,
+// and the required packages that were already installed.
+func printPackagePlan(w io.Writer, res mods.PackagePlan) {
+	if res.Installed == nil {
+		for _, n := range res.Plan.Install {
+			from := "download"
+			if n.Path != "" {
+				from = n.Path
+			}
+			fmt.Fprintf(w, "Would install %s (%s)\n", n.Ref, from)
+		}
+	}
+	for _, pi := range res.Installed {
+		fmt.Fprintf(w, "Installed %s\n", pi.Package)
+		for _, m := range pi.Mods {
+			fmt.Fprintf(w, "  %s %s (%s)\n", m.Kind, m.Name, m.Path)
+		}
+		for _, f := range pi.Files {
+			fmt.Fprintf(w, "  file %s\n", f)
+		}
+		for _, m := range pi.Mods {
+			printModWarnings(w, m)
+			if m.Scan != nil {
+				printScanReport(w, m.DisplayName(), *m.Scan)
+			}
+		}
+	}
+	for _, r := range res.Plan.Satisfied {
+		fmt.Fprintf(w, "Already installed: %s\n", r)
+	}
+}
+
+func printPackageWarnings(w io.Writer, res mods.PackagePlan) {
+	for _, msg := range res.Warnings {
+		fmt.Fprintf(w, "warning: %s\n", msg)
+	}
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"automelonloaderinstallergo/config"
+	"automelonloaderinstallergo/internal/modscan"
+
+	"github.com/spf13/cobra"
+)
+
+// scanResult is the output of mods scan for one file.
+type scanResult struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	modscan.Report
+}
+
+func newModsScanCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "scan [name|file]...",
+		Short: "Check mods for risky references such as starting processes or network access",
+		Long: "Check installed mods, or the named installed mods or files, for references to risky\n" +
+			"features: starting processes, HTTP and sockets, P/Invoke into kernel32 or ntdll,\n" +
+			"Assembly.Load(byte[]), the registry, and embedded native programs. Only the assembly's\n" +
+			"metadata is read; nothing is run. A clean report does not make a mod safe.\n\n" +
+			"References matching a scan.allow pattern are not reported, and those matching a\n" +
+			"scan.deny pattern fail the command. With scan.enabled, or --scan, `mods add` scans\n" +
+			"files the same way and refuses denied ones.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, kind, err := modsTarget()
+			if err != nil {
+				return err
+			}
+			c, err := config.Load()
+			if err != nil {
+				return err
+			}
+			scanner := modscan.Scanner{Allow: c.Scan.Allow, Deny: c.Scan.Deny}
+
+			var targets []scanResult
+			if len(args) == 0 {
+				all, err := g.List()
+				if err != nil {
+					return err
+				}
+				for _, m := range all {
+					if kind == "" || m.Kind == kind {
+						targets = append(targets, scanResult{Name: m.Name, Path: m.Path})
+					}
+				}
+			}
+			for _, arg := range args {
+				if fi, err := os.Stat(arg); err == nil && fi.Mode().IsRegular() {
+					targets = append(targets, scanResult{Name: filepath.Base(arg), Path: arg})
+					continue
+				}
+				m, err := g.Find(arg, kind)
+				if err != nil {
+					return err
+				}
+				targets = append(targets, scanResult{Name: m.Name, Path: m.Path})
+			}
+
+			results := []scanResult{}
+			var denied []error
+			for _, r := range targets {
+				if r.Report, err = scanner.ScanFile(r.Path); err != nil {
+					return err
+				}
+				if err := r.Err(r.Name); err != nil {
+					denied = append(denied, err)
+				}
+				results = append(results, r)
+			}
+			err = newPrinter(cmd).Print(results, func(w io.Writer) error {
+				if len(results) == 0 {
+					_, err := fmt.Fprintf(w, "No mods installed in %s\n", g.Dir)
+					return err
+				}
+				for _, r := range results {
+					if !r.Native && len(r.Findings) == 0 {
+						fmt.Fprintf(w, "%s: no risky references\n", r.Name)
+						continue
+					}
+					printScanReport(w, r.Name, r.Report)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			switch len(denied) {
+			case 0:
+				return nil
+			case 1:
+				return denied[0]
+			default:
+				return fmt.Errorf("%d files are %w by scan rules; the first: %v", len(denied), modscan.ErrDenied, denied[0])
+			}
+		},
+	}
+}
+
+// modScanner returns the scanner of the commands installing files, or nil when
+// scan.enabled is off.
+func modScanner() (*modscan.Scanner, error) {
+	c, err := config.Load()
+	if err != nil || !c.Scan.Enabled {
+		return nil, err
+	}
+	return &modscan.Scanner{Allow: c.Scan.Allow, Deny: c.Scan.Deny}, nil
+}
+
+// printScanReport writes one line per finding of the scan of the file name.
+func printScanReport(w io.Writer, name string, r modscan.Report) {
+	if r.Native {
+		fmt.Fprintf(w, "warning: %s is not a .NET assembly and was not scanned\n", name)
+	}
+	for _, f := range r.Findings {
+		label := "risk"
+		if f.Denied {
+			label = "denied"
+		}
+		fmt.Fprintf(w, "%s: %s: %s\n", label, name, f)
+	}
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"automelonloaderinstallergo/internal/mods"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	modsOriginAsset      string
+	modsOriginPrerelease bool
+	modsOriginConstraint string
+	modsUpdateCheck      bool
+)
+
+func newModsOriginCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "origin <name> <owner/repo>",
+		Short: "Declare the GitHub releases a mod is updated from",
+		Long: "Declare the GitHub releases a mod is updated from, in the game's mods file (" + mods.OriginsPath + ").\n\n" +
+			"--asset names the release asset or a glob matching exactly one asset. A .zip asset\n" +
+			"must contain the mod's file. The file may also be edited by hand.",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, kind, err := modsTarget()
+			if err != nil {
+				return err
+			}
+			o := mods.Origin{
+				Name:       args[0],
+				Kind:       kind,
+				Repo:       args[1],
+				Asset:      modsOriginAsset,
+				Prerelease: modsOriginPrerelease,
+				Constraint: modsOriginConstraint,
+			}
+			if err := g.SetOrigin(o); err != nil {
+				return err
+			}
+			return newPrinter(cmd).Print(o, func(w io.Writer) error {
+				_, err := fmt.Fprintf(w, "%s is updated from %s (%s)\n", o.Name, o.Repo, o.Asset)
+				return err
+			})
+		},
+	}
+	cmd.Flags().StringVar(&modsOriginAsset, "asset", "", "Release asset name or glob, e.g. 'CoolMod-*.zip' (required)")
+	cmd.Flags().BoolVar(&modsOriginPrerelease, "prerelease", false, "Update to prereleases too")
+	cmd.Flags().StringVar(&modsOriginConstraint, "constraint", "", "Only update to versions satisfying e.g. '<2' or '^1.4'")
+	_ = cmd.MarkFlagRequired("asset")
+	return cmd
+}
+
+func newModsUpdateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "update [name]...",
+		Short: "Update mods from the GitHub releases declared in the mods file",
+		Long: "Update mods to the newest release of the origins declared in the game's mods file\n" +
+			"(" + mods.OriginsPath + "; see `mods origin`), or of the named mods only.\n\n" +
+			"Releases are listed and downloaded through the configured --source, like MelonLoader\n" +
+			"itself. An updated file replaces the installed one and keeps its name and state.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, kind, err := modsTarget()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := context.WithTimeout(cmd.Context(), viper.GetDuration("timeouts.download"))
+			defer cancel()
+
+			cred, err := resolveGitHubCredential(cmd, "")
+			if err != nil {
+				return err
+			}
+			src, err := newSource(cmd)
+			if err != nil {
+				return err
+			}
+			scanner, err := modScanner()
+			if err != nil {
+				return err
+			}
+			u := mods.Updater{Source: src, Token: cred.Token, Force: modsForce, Scan: scanner}
+			updates, err := u.Check(ctx, g, args, kind)
+			if err != nil {
+				return err
+			}
+
+			failed := 0
+			for i := range updates {
+				if !modsUpdateCheck {
+					_ = u.Apply(ctx, g, &updates[i])
+				}
+				if updates[i].Status == mods.UpdateFailed {
+					failed++
+				}
+			}
+
+			err = newPrinter(cmd).Print(updates, func(w io.Writer) error {
+				if len(updates) == 0 {
+					_, err := fmt.Fprintf(w, "No mod origins declared in %s\n", mods.OriginsPath)
+					return err
+				}
+				tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(tw, "NAME\tINSTALLED\tLATEST\tSTATUS")
+				for _, up := range updates {
+					name := up.Origin.Name
+					if up.Mod.Name != "" {
+						name = up.Mod.DisplayName()
+					}
+					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", name, orDash(up.Installed), orDash(up.Tag), up.Status)
+				}
+				if err := tw.Flush(); err != nil {
+					return err
+				}
+				for _, up := range updates {
+					if up.Error != "" {
+						fmt.Fprintf(w, "error: %s: %s\n", up.Origin.Name, up.Error)
+					}
+					printModWarnings(w, up.Mod)
+					if up.Mod.Scan != nil {
+						printScanReport(w, up.Mod.DisplayName(), *up.Mod.Scan)
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d mods could not be updated", failed, len(updates))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&modsUpdateCheck, "check", false, "Only report available updates")
+	cmd.Flags().BoolVar(&modsForce, "force", false, "Install updates built for another game or MelonLoader version")
+	return cmd
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"automelonloaderinstallergo/internal/output"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// newPrinter returns a printer for the --output-format flag (or output_format key).
+// An invalid format is rejected before any command runs, so here it falls back to text.
+func newPrinter(cmd *cobra.Command) *output.Printer {
+	f, err := output.ParseFormat(viper.GetString("output_format"))
+	if err != nil {
+		f = output.Text
+	}
+	return output.NewPrinter(cmd.OutOrStdout(), f)
+}
@@ -1,21 +1,81 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
+	"net/http"
 	"os"
 
 	"automelonloaderinstallergo/tui"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
 
 	"automelonloaderinstallergo/config"
+	"automelonloaderinstallergo/internal/credentials"
 	"automelonloaderinstallergo/internal/logger"
+	"automelonloaderinstallergo/internal/mods"
+	"automelonloaderinstallergo/internal/modscan"
+	"automelonloaderinstallergo/internal/output"
+	"automelonloaderinstallergo/internal/redact"
+	"automelonloaderinstallergo/internal/releases"
+	"automelonloaderinstallergo/internal/thunderstore"
+	"automelonloaderinstallergo/internal/version"
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "app",
 	Short: "A TUI-first MelonLoader Automated Installer with sane Linux packaging.",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		format, err := output.ParseFormat(viper.GetString("output_format"))
+		if err != nil {
+			return err
+		}
+		// Scripts get the error object alone, without the usage text.
+		cmd.SilenceUsage = format != output.Text
+
+		// Cobra checks required flags only after this hook; check them first so a
+		// missing flag is reported as a usage error like other invalid invocations.
+		if err := cmd.ValidateRequiredFlags(); err != nil {
+			return err
+		}
+		commandStarted = true
+
+		if viper.GetBool("trace_http") {
+			return traceHTTP(cmd)
+		}
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := tui.Run(); err != nil {
+		src, err := newSource(cmd)
+		if err != nil {
+			logger.Log.Error("open release source", "err", err)
+			os.Exit(1)
+		}
+		cred, err := newResolver("").Resolve(cmd.Context(), credentials.DefaultHost)
+		if err != nil {
+			logger.Log.Error("resolve GitHub token", "err", err)
+			os.Exit(1)
+		}
+		redact.AddSecret(cred.Token)
+		games, err := configuredGames()
+		if err != nil {
+			logger.Log.Error("load games", "err", err)
+			os.Exit(1)
+		}
+		if err := tui.Run(tui.Options{
+			Source:          src,
+			Credential:      cred,
+			Owner:           viper.GetString("owner"),
+			Repo:            viper.GetString("repo"),
+			Asset:           viper.GetString("asset"),
+			OutputDir:       viper.GetString("output_dir"),
+			RefreshTimeout:  viper.GetDuration("timeouts.refresh"),
+			DownloadTimeout: viper.GetDuration("timeouts.download"),
+			Theme:           viper.GetString("theme"),
+			Games:           games,
+		}); err != nil {
 			logger.Log.Error("run tui", "err", err)
 			os.Exit(1)
 		}
@@ -23,16 +83,106 @@ var rootCmd = &cobra.Command{
 }
 
 func Execute() {
-	// NOTE: scaffolded config.Init() returns no value.
-	config.Init()
-
-	if err := rootCmd.Execute(); err != nil {
+	if err := config.Init(); err != nil {
+		logger.Log.Error("load config", "err", err)
 		os.Exit(1)
 	}
+	if err := version.Configure(version.Options{
+		TagPrefixes: viper.GetStringSlice("tag_prefixes"),
+		CalVer:      viper.GetBool("calver"),
+	}); err != nil {
+		logger.Log.Error("load config", "err", err)
+		os.Exit(1)
+	}
+
+	err := rootCmd.Execute()
+	if err == nil {
+		return
+	}
+	if !commandStarted {
+		// Cobra failed before running the command: an unknown command, flag or argument.
+		// The flags may not have been parsed, so look for --output-format directly.
+		err = output.Usage(err)
+		scanOutputFormat(os.Args[1:])
+	}
+	if p := newPrinter(rootCmd); p.Structured() {
+		_ = p.PrintError(err)
+	} else {
+		rootCmd.PrintErrln("Error:", err.Error())
+	}
+	os.Exit(1)
+}
+
+// scanOutputFormat applies an --output-format flag found in args, ignoring every
+// other flag, so usage errors are reported in the requested format.
+func scanOutputFormat(args []string) {
+	fs := pflag.NewFlagSet("output-format", pflag.ContinueOnError)
+	fs.ParseErrorsWhitelist.UnknownFlags = true
+	fs.SetOutput(io.Discard)
+	format := fs.String("output-format", "", "")
+	if err := fs.Parse(args); err == nil && fs.Changed("output-format") {
+		viper.Set("output_format", *format)
+	}
+}
+
+// traceHTTP makes http.DefaultTransport log every request, to the trace_http_file
+// file if set and otherwise to stderr. The TUI draws over stderr, so it is traced
+// only to a file.
+func traceHTTP(cmd *cobra.Command) error {
+	logf := logger.Log.Info
+	if path := viper.GetString("trace_http_file"); path != "" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return fmt.Errorf("open HTTP trace file: %w", err)
+		}
+		logf = logger.New(f).Info
+	} else if !cmd.HasParent() { // the root command runs the TUI
+		return nil
+	}
+	http.DefaultTransport = redact.TraceTransport(http.DefaultTransport, logf)
+	return nil
+}
+
+// registerErrorCodes maps the sentinel errors of the internal packages to the
+// codes of structured error objects.
+func registerErrorCodes() {
+	output.RegisterCode(releases.ErrNotFound, output.CodeNotFound)
+	output.RegisterCode(mods.ErrNotFound, output.CodeNotFound)
+	output.RegisterCode(thunderstore.ErrNotFound, output.CodeNotFound)
+	output.RegisterCode(mods.ErrIncompatible, output.CodeIncompatible)
+	output.RegisterCode(modscan.ErrDenied, output.CodeDenied)
+	output.RegisterCode(releases.ErrRateLimited, output.CodeRateLimited)
+	output.RegisterCode(releases.ErrUnavailable, output.CodeUnavailable)
 }
 
+// commandStarted is set once flags and arguments have been accepted, so Execute can
+// tell usage errors from failures of the command itself.
+var commandStarted bool
+
 func init() {
+	// Errors printed by cobra go through the same redaction as the logger.
+	rootCmd.SetErr(redact.NewWriter(os.Stderr))
+	// Execute prints errors itself, as text or in the structured output format.
+	rootCmd.SilenceErrors = true
+	registerErrorCodes()
+
+	rootCmd.PersistentFlags().String("output-format", "text", "Output format for command results and errors: text, json, yaml or ndjson")
+	_ = viper.BindPFlag("output_format", rootCmd.PersistentFlags().Lookup("output-format"))
+
+	rootCmd.PersistentFlags().Bool("trace-http", false, "Log HTTP request and response metadata (redacted) to stderr")
+	_ = viper.BindPFlag("trace_http", rootCmd.PersistentFlags().Lookup("trace-http"))
+	rootCmd.PersistentFlags().String("trace-http-file", "", "Append the --trace-http log to this file instead of stderr; the TUI is traced only to a file")
+	_ = viper.BindPFlag("trace_http_file", rootCmd.PersistentFlags().Lookup("trace-http-file"))
+
+	addSourceFlags(rootCmd)
+	addCredentialFlags(rootCmd)
+
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(newGetTagsCmd())
 	rootCmd.AddCommand(newGetAssetCmd())
+	rootCmd.AddCommand(newListAssetsCmd())
+	rootCmd.AddCommand(newChangelogCmd())
+	rootCmd.AddCommand(newConfigCmd())
+	rootCmd.AddCommand(newModsCmd())
+	rootCmd.AddCommand(newModpackCmd())
 }
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"strings"
+
+	"automelonloaderinstallergo/internal/redact"
+	"automelonloaderinstallergo/internal/releases"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// addSourceFlags registers the release-source selection flags shared by the TUI and
+// every subcommand, and binds them to their configuration keys.
+func addSourceFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().String("source", releases.KindGitHub, "Release source: github, index, local, nightly, oci or an amlinstall-source-<name> plugin; a comma-separated list falls back in order")
+	cmd.PersistentFlags().String("git-protocol", releases.GitProtocolHTTPS, "Protocol for listing GitHub tags: https (uses the GitHub token) or ssh (git@github.com:owner/repo.git)")
+	cmd.PersistentFlags().String("index-url", "", "JSON index URL for --source index (may contain {owner} and {repo})")
+	cmd.PersistentFlags().String("local-dir", "", "Directory for --source local (default: user cache dir)")
+	cmd.PersistentFlags().String("oci-registry", "", "Registry base URL for --source oci, e.g. https://registry.internal:5000")
+	cmd.PersistentFlags().String("oci-repository", "", "Repository name template for --source oci (default: {owner}/{repo})")
+	cmd.PersistentFlags().String("nightly-branch", "", "Branch whose successful CI runs --source nightly lists (default: all branches)")
+
+	_ = viper.BindPFlag("source", cmd.PersistentFlags().Lookup("source"))
+	_ = viper.BindPFlag("git_protocol", cmd.PersistentFlags().Lookup("git-protocol"))
+	_ = viper.BindPFlag("index_url", cmd.PersistentFlags().Lookup("index-url"))
+	_ = viper.BindPFlag("local_dir", cmd.PersistentFlags().Lookup("local-dir"))
+	_ = viper.BindPFlag("oci_registry", cmd.PersistentFlags().Lookup("oci-registry"))
+	_ = viper.BindPFlag("oci_repository", cmd.PersistentFlags().Lookup("oci-repository"))
+	_ = viper.BindPFlag("nightly_branch", cmd.PersistentFlags().Lookup("nightly-branch"))
+}
+
+// newSource builds the releases.Source selected by flags or configuration.
+//
+// An explicit --source flag wins; otherwise a "sources" list in the config file
+// defines the fallback order, and "source" is used when no list is configured.
+func newSource(cmd *cobra.Command) (releases.Source, error) {
+	kinds := viper.GetStringSlice("sources")
+	if cmd.Flags().Changed("source") || len(kinds) == 0 {
+		kinds = strings.Split(viper.GetString("source"), ",")
+	}
+
+	redact.AddSecret(viper.GetString("oci_password"))
+
+	return releases.OpenChain(kinds, releases.Options{
+		GitProtocol:   viper.GetString("git_protocol"),
+		IndexURL:      viper.GetString("index_url"),
+		LocalDir:      viper.GetString("local_dir"),
+		NightlyBranch: viper.GetString("nightly_branch"),
+		OCIRegistry:   viper.GetString("oci_registry"),
+		OCIRepository: viper.GetString("oci_repository"),
+		OCIUsername:   viper.GetString("oci_username"),
+		OCIPassword:   viper.GetString("oci_password"),
+	})
+}
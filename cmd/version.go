@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/spf13/cobra"
 )
@@ -9,7 +10,14 @@ import (
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print the version number of automelonlaoderinstallergo",
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("automelonlaoderinstallergo ~ 0.0.1")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		info := struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		}{"automelonlaoderinstallergo", "0.0.1"}
+		return newPrinter(cmd).Print(info, func(w io.Writer) error {
+			_, err := fmt.Fprintf(w, "%s ~ %s\n", info.Name, info.Version)
+			return err
+		})
 	},
 }
@@ -1,19 +1,358 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
 	"automelonloaderinstallergo/internal/logger"
+
 	"github.com/spf13/viper"
 )
 
-func Init() {
-	viper.SetConfigName("config") // config.yaml
+// EnvPrefix is prepended to every configuration key to form its environment
+// variable, e.g. AMLINSTALL_INDEX_URL or AMLINSTALL_TIMEOUTS_REFRESH.
+const EnvPrefix = "AMLINSTALL"
+
+// FileName is the name of both the user and the project-local configuration file.
+const FileName = "config.yaml"
+
+// Config is the typed application configuration.
+//
+// Values are resolved with the usual precedence: command-line flags, then
+// AMLINSTALL_* environment variables, then the project-local ./config.yaml, then the
+// user file in $XDG_CONFIG_HOME/amlinstall, then built-in defaults.
+type Config struct {
+	Owner     string `mapstructure:"owner"`
+	Repo      string `mapstructure:"repo"`
+	Asset     string `mapstructure:"asset"`
+	OutputDir string `mapstructure:"output_dir"`
+
+	Source        string   `mapstructure:"source"`
+	Sources       []string `mapstructure:"sources"`
+	GitProtocol   string   `mapstructure:"git_protocol"`
+	IndexURL      string   `mapstructure:"index_url"`
+	LocalDir      string   `mapstructure:"local_dir"`
+	NightlyBranch string   `mapstructure:"nightly_branch"`
+	OCIRegistry   string   `mapstructure:"oci_registry"`
+	OCIRepository string   `mapstructure:"oci_repository"`
+	OCIUsername   string   `mapstructure:"oci_username"`
+	OCIPassword   string   `mapstructure:"oci_password"`
+
+	// ThunderstoreURL is the Thunderstore site mods install packages from.
+	ThunderstoreURL string `mapstructure:"thunderstore_url"`
+
+	TokenFile string `mapstructure:"token_file"`
+	TokenCmd  string `mapstructure:"token_cmd"`
+	TraceHTTP bool   `mapstructure:"trace_http"`
+	// TraceHTTPFile, if set, receives the TraceHTTP log instead of stderr.
+	TraceHTTPFile string `mapstructure:"trace_http_file"`
+
+	OutputFormat string `mapstructure:"output_format"`
+
+	// TagPrefixes and CalVer configure version parsing; see version.Options.
+	TagPrefixes []string `mapstructure:"tag_prefixes"`
+	CalVer      bool     `mapstructure:"calver"`
+
+	Timeouts Timeouts `mapstructure:"timeouts"`
+	Theme    string   `mapstructure:"theme"`
+	Games    []Game   `mapstructure:"games"`
+	Scan     Scan     `mapstructure:"scan"`
+}
+
+// Timeouts bounds long-running network operations.
+type Timeouts struct {
+	Refresh  time.Duration `mapstructure:"refresh"`
+	Download time.Duration `mapstructure:"download"`
+}
+
+// Scan configures the safety scan of the files mods add, install, update and
+// import and modpack import install; see modscan.
+type Scan struct {
+	Enabled bool     `mapstructure:"enabled"`
+	Allow   []string `mapstructure:"allow"`
+	Deny    []string `mapstructure:"deny"`
+}
+
+// Game is a MelonLoader-enabled game installation known to the tool.
+type Game struct {
+	Name string `mapstructure:"name"`
+	Path string `mapstructure:"path"`
+}
+
+// Themes accepted by the theme key.
+var Themes = []string{"dark", "light"}
+
+// OutputFormats accepted by the output_format key.
+var OutputFormats = []string{"text", "json", "yaml", "ndjson"}
+
+// defaults lists every known key with its default value. It doubles as the set of
+// keys accepted by `config get` and `config set`.
+var defaults = map[string]any{
+	"owner":            "LavaGang",
+	"repo":             "MelonLoader",
+	"asset":            "MelonLoader.x64.zip",
+	"output_dir":       filepath.Join(".", "downloads"),
+	"source":           "github",
+	"sources":          []string{},
+	"git_protocol":     "https",
+	"index_url":        "",
+	"local_dir":        "",
+	"nightly_branch":   "",
+	"oci_registry":     "",
+	"oci_repository":   "",
+	"oci_username":     "",
+	"oci_password":     "",
+	"thunderstore_url": "https://thunderstore.io",
+	"token_file":       "",
+	"token_cmd":        "",
+	"trace_http":       false,
+	"trace_http_file":  "",
+	"output_format":    "text",
+	"tag_prefixes":     []string{},
+	"calver":           false,
+	"timeouts": map[string]any{
+		"refresh":  30 * time.Second,
+		"download": 2 * time.Minute,
+	},
+	"theme": "dark",
+	"games": []any{},
+	"scan": map[string]any{
+		"enabled": false,
+		"allow":   []string{},
+		"deny":    []string{},
+	},
+}
+
+// loadedFiles records the configuration files read by Init, lowest precedence first.
+var loadedFiles []string
+
+// Init registers defaults and environment binding, then reads the user and
+// project-local configuration files. Missing files are not an error; malformed
+// files are.
+func Init() error {
+	for k, v := range defaults {
+		viper.SetDefault(k, v)
+	}
+
+	viper.SetEnvPrefix(EnvPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
 	viper.SetConfigType("yaml")
-	viper.AddConfigPath(".")
 
-	err := viper.ReadInConfig()
-	if err != nil {
+	loadedFiles = nil
+	for _, path := range SearchPaths() {
+		f, err := os.Open(path)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return fmt.Errorf("open config %s: %w", path, err)
+		}
+		// The first file replaces any previously read settings so Init can be called
+		// again after a file has been edited; later files are layered on top.
+		if len(loadedFiles) == 0 {
+			err = viper.ReadConfig(f)
+		} else {
+			err = viper.MergeConfig(f)
+		}
+		_ = f.Close()
+		if err != nil {
+			return fmt.Errorf("parse config %s: %w", path, err)
+		}
+		loadedFiles = append(loadedFiles, path)
+	}
+
+	if len(loadedFiles) == 0 {
 		logger.Log.Info("No config file found; using defaults.")
 	}
+	return nil
+}
+
+// Load decodes the effective configuration.
+func Load() (Config, error) {
+	var c Config
+	if err := viper.Unmarshal(&c); err != nil {
+		return c, fmt.Errorf("decode config: %w", err)
+	}
+	return c, nil
+}
+
+// UserFile returns the path of the per-user configuration file,
+// $XDG_CONFIG_HOME/amlinstall/config.yaml (or the platform equivalent).
+func UserFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "amlinstall", FileName)
+}
+
+// LocalFile returns the path of the project-local configuration file.
+func LocalFile() string {
+	return filepath.Join(".", FileName)
+}
+
+// SearchPaths returns the configuration files consulted by Init, lowest precedence first.
+func SearchPaths() []string {
+	var paths []string
+	if u := UserFile(); u != "" {
+		paths = append(paths, u)
+	}
+	return append(paths, LocalFile())
+}
+
+// LoadedFiles returns the configuration files read by Init, lowest precedence first.
+func LoadedFiles() []string {
+	return append([]string(nil), loadedFiles...)
+}
+
+// Keys returns every known top-level and nested configuration key, sorted.
+func Keys() []string {
+	var keys []string
+	for k, v := range defaults {
+		if m, ok := v.(map[string]any); ok {
+			for sub := range m {
+				keys = append(keys, k+"."+sub)
+			}
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// IsSection reports whether key holds nested keys, such as timeouts.
+func IsSection(key string) bool {
+	_, ok := defaults[key].(map[string]any)
+	return ok
+}
+
+// IsKnownKey reports whether key is a configuration key (see Keys).
+func IsKnownKey(key string) bool {
+	for _, k := range Keys() {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate reports every problem found in c. sourceKnown decides whether a source
+// kind exists (built-in or plugin); it is injected to keep this package free of
+// release backends.
+func (c Config) Validate(sourceKnown func(kind string) bool) error {
+	var errs []error
+	add := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if strings.TrimSpace(c.Owner) == "" {
+		add("owner must not be empty")
+	}
+	if strings.TrimSpace(c.Repo) == "" {
+		add("repo must not be empty")
+	}
+	if strings.TrimSpace(c.OutputDir) == "" {
+		add("output_dir must not be empty")
+	}
+
+	kinds := c.Sources
+	if len(kinds) == 0 {
+		kinds = strings.Split(c.Source, ",")
+	}
+	for _, k := range kinds {
+		k = strings.ToLower(strings.TrimSpace(k))
+		switch {
+		case k == "":
+			add("source list contains an empty entry")
+		case sourceKnown != nil && !sourceKnown(k):
+			add("unknown source %q", k)
+		case k == "index" && strings.TrimSpace(c.IndexURL) == "":
+			add("source index requires index_url")
+		case k == "oci" && strings.TrimSpace(c.OCIRegistry) == "":
+			add("source oci requires oci_registry")
+		}
+	}
+
+	if u, err := url.Parse(c.ThunderstoreURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		add("thunderstore_url must be an http or https URL, got %q", c.ThunderstoreURL)
+	}
+
+	switch strings.ToLower(c.GitProtocol) {
+	case "", "https", "ssh":
+	default:
+		add("git_protocol must be https or ssh, got %q", c.GitProtocol)
+	}
+
+	if c.TokenFile != "" && c.TokenCmd != "" {
+		add("token_file and token_cmd are mutually exclusive")
+	}
+
+	if c.Timeouts.Refresh <= 0 {
+		add("timeouts.refresh must be positive")
+	}
+	if c.Timeouts.Download <= 0 {
+		add("timeouts.download must be positive")
+	}
+
+	validTheme := false
+	for _, t := range Themes {
+		if c.Theme == t {
+			validTheme = true
+		}
+	}
+	if !validTheme {
+		add("theme must be one of %s, got %q", strings.Join(Themes, ", "), c.Theme)
+	}
+
+	validFormat := c.OutputFormat == ""
+	for _, f := range OutputFormats {
+		if strings.ToLower(c.OutputFormat) == f {
+			validFormat = true
+		}
+	}
+	if !validFormat {
+		add("output_format must be one of %s, got %q", strings.Join(OutputFormats, ", "), c.OutputFormat)
+	}
+
+	for _, p := range c.TagPrefixes {
+		if _, err := regexp.Compile(p); err != nil {
+			add("tag_prefixes: %q is not a valid regular expression: %v", p, err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i, g := range c.Games {
+		if strings.TrimSpace(g.Name) == "" {
+			add("games[%d]: name must not be empty", i)
+		} else if seen[g.Name] {
+			add("games[%d]: duplicate name %q", i, g.Name)
+		}
+		seen[g.Name] = true
+		if strings.TrimSpace(g.Path) == "" {
+			add("games[%d]: path must not be empty", i)
+		}
+	}
+
+	for i, p := range c.Scan.Allow {
+		if strings.TrimSpace(p) == "" {
+			add("scan.allow[%d] must not be empty", i)
+		}
+	}
+	for i, p := range c.Scan.Deny {
+		if strings.TrimSpace(p) == "" {
+			add("scan.deny[%d] must not be empty", i)
+		}
+	}
 
-	viper.SetDefault("app.theme", "dark")
+	return errors.Join(errs...)
 }
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// setup isolates viper and the config search paths in temporary directories and
+// returns the user config file path.
+func setup(t *testing.T) string {
+	t.Helper()
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+	return UserFile()
+}
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInitPrecedence(t *testing.T) {
+	user := setup(t)
+	write(t, user, "owner: user-owner\nrepo: user-repo\ntimeouts:\n  refresh: 45s\ngames:\n  - name: BONELAB\n    path: /games/bonelab\n")
+	write(t, LocalFile(), "repo: local-repo\n")
+	t.Setenv("AMLINSTALL_TIMEOUTS_DOWNLOAD", "5m")
+
+	if err := Init(); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	c, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if c.Owner != "user-owner" || c.Repo != "local-repo" || c.Asset != "MelonLoader.x64.zip" {
+		t.Fatalf("owner/repo/asset=%q/%q/%q", c.Owner, c.Repo, c.Asset)
+	}
+	if c.Timeouts.Refresh != 45*time.Second || c.Timeouts.Download != 5*time.Minute {
+		t.Fatalf("timeouts=%+v", c.Timeouts)
+	}
+	if len(c.Games) != 1 || c.Games[0].Name != "BONELAB" {
+		t.Fatalf("games=%+v", c.Games)
+	}
+	if got := LoadedFiles(); len(got) != 2 || got[0] != user {
+		t.Fatalf("LoadedFiles=%v", got)
+	}
+	if err := c.Validate(nil); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+}
+
+func TestInitMalformed(t *testing.T) {
+	setup(t)
+	write(t, LocalFile(), "owner: [unterminated\n")
+
+	if err := Init(); err == nil || !strings.Contains(err.Error(), "parse config") {
+		t.Fatalf("err=%v; want parse error", err)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	setup(t)
+	if err := Init(); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	for _, key := range []string{"owner", "timeouts.refresh", "scan.enabled", "scan.deny"} {
+		if !IsKnownKey(key) || IsSection(key) {
+			t.Errorf("%s: known %v, section %v; want a key", key, IsKnownKey(key), IsSection(key))
+		}
+	}
+	for _, key := range []string{"timeouts", "scan"} {
+		if IsKnownKey(key) || !IsSection(key) {
+			t.Errorf("%s: known %v, section %v; want a section", key, IsKnownKey(key), IsSection(key))
+		}
+	}
+	// config get prints a section as a map of its keys.
+	if scan, ok := viper.Get("scan").(map[string]any); !ok || scan["enabled"] != false {
+		t.Errorf("scan = %#v", viper.Get("scan"))
+	}
+	if IsKnownKey("nope") || IsSection("nope") {
+		t.Error("nope is known")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c := Config{
+		Owner:        "o",
+		Repo:         "r",
+		OutputDir:    "out",
+		Source:       "index,bogus",
+		Timeouts:     Timeouts{Refresh: time.Second},
+		Theme:        "blue",
+		OutputFormat: "xml",
+		TagPrefixes:  []string{"ml-", "(unclosed"},
+		Games:        []Game{{Name: "a", Path: "/a"}, {Name: "a"}},
+
+		ThunderstoreURL: "ftp://thunderstore.io",
+		Scan:            Scan{Deny: []string{"pinvoke:*", " "}},
+	}
+	err := c.Validate(func(kind string) bool { return kind != "bogus" })
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, want := range []string{
+		"source index requires index_url",
+		`unknown source "bogus"`,
+		"timeouts.download must be positive",
+		"theme must be one of",
+		`output_format must be one of text, json, yaml, ndjson, got "xml"`,
+		`tag_prefixes: "(unclosed" is not a valid regular expression`,
+		`duplicate name "a"`,
+		"games[1]: path must not be empty",
+		`thunderstore_url must be an http or https URL, got "ftp://thunderstore.io"`,
+		"scan.deny[1] must not be empty",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("missing %q in:\n%v", want, err)
+		}
+	}
+}
+
+func TestSetInFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "amlinstall", FileName)
+
+	for _, kv := range [][2]string{
+		{"owner", "me"},
+		{"timeouts.refresh", "90s"},
+		{"sources", "github, local"},
+		{"trace_http", "true"},
+	} {
+		if err := SetInFile(path, kv[0], kv[1]); err != nil {
+			t.Fatalf("SetInFile(%s): %v", kv[0], err)
+		}
+	}
+
+	doc, err := readFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc["owner"] != "me" || doc["trace_http"] != true {
+		t.Fatalf("doc=%v", doc)
+	}
+	if got := doc["timeouts"].(map[string]any)["refresh"]; got != "1m30s" {
+		t.Fatalf("timeouts.refresh=%v", got)
+	}
+	if got := doc["sources"].([]any); len(got) != 2 || got[1] != "local" {
+		t.Fatalf("sources=%v", got)
+	}
+
+	for _, kv := range [][2]string{
+		{"nope", "x"},
+		{"trace_http", "maybe"},
+		{"timeouts.download", "soon"},
+		{"games", "x"},
+	} {
+		if err := SetInFile(path, kv[0], kv[1]); err == nil {
+			t.Errorf("SetInFile(%s=%s) succeeded; want error", kv[0], kv[1])
+		}
+	}
+}
@@ -1,4 +1,5 @@
-// Package config initializes application configuration using Viper.
-// The scaffolded implementation may read config files and environment variables
-// and set defaults used by the CLI and TUI.
+// Package config defines the typed application configuration and loads it with Viper.
+// Settings come from the user file in $XDG_CONFIG_HOME/amlinstall/config.yaml, a
+// project-local ./config.yaml, AMLINSTALL_* environment variables and command-line
+// flags, in increasing order of precedence.
 package config
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"automelonloaderinstallergo/internal/redact"
+
+	"gopkg.in/yaml.v3"
+)
+
+// secretKeys are masked by Redacted.
+var secretKeys = map[string]bool{
+	"oci_password": true,
+}
+
+// IsSecretKey reports whether key holds a credential that should not be printed.
+func IsSecretKey(key string) bool {
+	return secretKeys[key]
+}
+
+// ParseValue converts the command-line representation of a value for key into the
+// type stored in the configuration file: comma-separated lists for list keys,
+// booleans, and Go durations for timeouts. The game list cannot be set this way.
+func ParseValue(key, raw string) (any, error) {
+	if !IsKnownKey(key) {
+		return nil, fmt.Errorf("unknown config key %q (known keys: %s)", key, strings.Join(Keys(), ", "))
+	}
+
+	switch lookupDefault(key).(type) {
+	case bool:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: want true or false, got %q", key, raw)
+		}
+		return v, nil
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: want a duration such as 45s or 5m, got %q", key, raw)
+		}
+		return d.String(), nil
+	case []string:
+		var items []string
+		for _, s := range strings.Split(raw, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				items = append(items, s)
+			}
+		}
+		if items == nil {
+			items = []string{}
+		}
+		return items, nil
+	case []any:
+		return nil, fmt.Errorf("%s cannot be set from the command line; use `config edit`", key)
+	default:
+		return raw, nil
+	}
+}
+
+// SetInFile sets key to the parsed raw value in the YAML file at path, creating the
+// file and its directory when needed. Other keys in the file are preserved; comments
+// are not.
+func SetInFile(path, key, raw string) error {
+	v, err := ParseValue(key, raw)
+	if err != nil {
+		return err
+	}
+
+	doc, err := readFile(path)
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(key, ".")
+	m := doc
+	for _, p := range parts[:len(parts)-1] {
+		child, ok := m[p].(map[string]any)
+		if !ok {
+			child = make(map[string]any)
+			m[p] = child
+		}
+		m = child
+	}
+	m[parts[len(parts)-1]] = v
+
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("mkdir: %w", err)
+	}
+	// The file may hold registry credentials, so keep it private to the user.
+	if err := os.WriteFile(path, out, 0o600); err != nil {
+		return fmt.Errorf("write config %s: %w", path, err)
+	}
+	return nil
+}
+
+// Redacted returns settings with the values of secret keys masked. Durations are
+// written as strings such as "30s" so they read the same in YAML and JSON.
+func Redacted(settings map[string]any) map[string]any {
+	out := make(map[string]any, len(settings))
+	for k, v := range settings {
+		switch tv := v.(type) {
+		case map[string]any:
+			v = Redacted(tv)
+		case time.Duration:
+			v = tv.String()
+		}
+		if IsSecretKey(k) && v != "" {
+			v = redact.Placeholder
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// readFile decodes the YAML file at path into a map. A missing or empty file yields
+// an empty map.
+func readFile(path string) (map[string]any, error) {
+	doc := make(map[string]any)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return doc, nil
+		}
+		return nil, fmt.Errorf("read config %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	if doc == nil {
+		doc = make(map[string]any)
+	}
+	return doc, nil
+}
+
+// lookupDefault returns the default value for a possibly nested key.
+func lookupDefault(key string) any {
+	top, sub, nested := strings.Cut(key, ".")
+	v := defaults[top]
+	if nested {
+		if m, ok := v.(map[string]any); ok {
+			return m[sub]
+		}
+		return nil
+	}
+	return v
+}
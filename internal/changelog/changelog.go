@@ -0,0 +1,203 @@
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"automelonloaderinstallergo/internal/releases"
+	"automelonloaderinstallergo/internal/version"
+)
+
+// Changelog holds the releases after From up to and including To, newest first.
+type Changelog struct {
+	From    string  `json:"from"`
+	To      string  `json:"to"`
+	Entries []Entry `json:"entries"`
+}
+
+// Entry is one release of a Changelog.
+type Entry struct {
+	releases.Release
+
+	// Breaking lists the lines of the release notes that describe breaking changes.
+	Breaking []string `json:"breaking,omitempty"`
+}
+
+var (
+	headingRe      = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	breakingWordRe = regexp.MustCompile(`(?i)\bbreaking\b`)
+	listMarkerRe   = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+`)
+)
+
+// Range returns the tags whose versions lie after from and up to and including to,
+// ordered newest first with version.Greater. Versions are compared after
+// version.NormalizeTag, so "v0.6.5" and "0.6.5" are equivalent. Nightly CI tags and
+// tags that are not version-like are skipped. An empty to selects the newest tag.
+func Range(tags []string, from, to string) ([]string, error) {
+	from = version.NormalizeTag(from)
+	to = version.NormalizeTag(to)
+	if from == "" {
+		return nil, fmt.Errorf("changelog: from version is required")
+	}
+	if to != "" && !version.Greater(to, from) {
+		return nil, fmt.Errorf("changelog: %s is not newer than %s", to, from)
+	}
+
+	var out []string
+	for _, t := range tags {
+		v := version.NormalizeTag(t)
+		if releases.IsNightlyTag(t) || !isVersion(v) {
+			continue
+		}
+		if !version.Greater(v, from) {
+			continue
+		}
+		if to != "" && version.Greater(v, to) {
+			continue
+		}
+		out = append(out, t)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return version.Greater(version.NormalizeTag(out[i]), version.NormalizeTag(out[j]))
+	})
+	return out, nil
+}
+
+// isVersion reports whether v sorts as a version rather than falling back to
+// lexical order in version.Greater.
+func isVersion(v string) bool {
+	_, err := version.Parse(v)
+	return err == nil
+}
+
+// Collect lists the tags of owner/repo and fetches the release notes of every
+// release in Range(tags, from, to).
+func Collect(ctx context.Context, src releases.Source, owner, repo, from, to, githubToken string) (Changelog, error) {
+	tags, err := src.ListTags(ctx, owner, repo, githubToken)
+	if err != nil {
+		return Changelog{}, err
+	}
+	inRange, err := Range(tags, from, to)
+	if err != nil {
+		return Changelog{}, err
+	}
+	if len(inRange) == 0 {
+		return Changelog{}, fmt.Errorf("changelog: no releases of %s/%s after %s", owner, repo, version.NormalizeTag(from))
+	}
+
+	rels := make([]releases.Release, 0, len(inRange))
+	for _, tag := range inRange {
+		rel, err := releases.GetRelease(ctx, src, owner, repo, tag, githubToken)
+		if err != nil {
+			return Changelog{}, fmt.Errorf("changelog: release %s: %w", tag, err)
+		}
+		if rel.Tag == "" {
+			rel.Tag = tag
+		}
+		rels = append(rels, rel)
+	}
+	return New(from, rels), nil
+}
+
+// New builds a Changelog from releases already ordered newest first, such as those
+// fetched for the tags returned by Range.
+func New(from string, rels []releases.Release) Changelog {
+	c := Changelog{From: version.NormalizeTag(from)}
+	if len(rels) > 0 {
+		c.To = version.NormalizeTag(rels[0].Tag)
+	}
+	for _, rel := range rels {
+		c.Entries = append(c.Entries, Entry{Release: rel, Breaking: Breaking(rel.Body)})
+	}
+	return c
+}
+
+// Breaking returns the lines of a Markdown release body that describe breaking
+// changes: every non-blank line under a heading mentioning "breaking" (until the
+// next heading of the same or a higher level), and any other line that mentions
+// "breaking" itself. List markers are removed.
+func Breaking(body string) []string {
+	var (
+		out          []string
+		sectionLevel int // heading level of the breaking section we are in, or 0
+	)
+	for _, line := range strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if m := headingRe.FindStringSubmatch(trimmed); m != nil {
+			level := len(m[1])
+			switch {
+			case breakingWordRe.MatchString(m[2]):
+				sectionLevel = level
+			case sectionLevel != 0 && level <= sectionLevel:
+				sectionLevel = 0
+			}
+			continue
+		}
+		if trimmed == "" {
+			continue
+		}
+		if sectionLevel != 0 || breakingWordRe.MatchString(trimmed) {
+			out = append(out, listMarkerRe.ReplaceAllString(trimmed, ""))
+		}
+	}
+	return out
+}
+
+// HasBreaking reports whether any entry lists breaking changes.
+func (c Changelog) HasBreaking() bool {
+	for _, e := range c.Entries {
+		if len(e.Breaking) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Markdown renders the changelog as a single Markdown document. Breaking changes
+// are summarised at the top and repeated in bold at the start of each release.
+func (c Changelog) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Changes from %s to %s\n\n", c.From, c.To)
+
+	if c.HasBreaking() {
+		b.WriteString("## ⚠ Breaking changes\n\n")
+		for _, e := range c.Entries {
+			for _, line := range e.Breaking {
+				fmt.Fprintf(&b, "- **%s:** %s\n", version.NormalizeTag(e.Tag), line)
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	for _, e := range c.Entries {
+		title := version.NormalizeTag(e.Tag)
+		if e.Name != "" && e.Name != e.Tag && e.Name != title {
+			title += " — " + e.Name
+		}
+		if !e.PublishedAt.IsZero() {
+			title += " (" + e.PublishedAt.Format("2006-01-02") + ")"
+		}
+		if e.Prerelease {
+			title += " [prerelease]"
+		}
+		fmt.Fprintf(&b, "## %s\n\n", title)
+
+		for _, line := range e.Breaking {
+			fmt.Fprintf(&b, "- **⚠ BREAKING:** %s\n", line)
+		}
+		if len(e.Breaking) > 0 {
+			b.WriteString("\n")
+		}
+
+		body := strings.TrimSpace(e.Body)
+		if body == "" {
+			body = "*No release notes.*"
+		}
+		b.WriteString(body)
+		b.WriteString("\n\n")
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
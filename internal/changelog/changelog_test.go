@@ -0,0 +1,121 @@
+package changelog
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"automelonloaderinstallergo/internal/releases"
+)
+
+func TestRange(t *testing.T) {
+	tags := []string{"v0.5.7", "v0.6.1", "v0.6.2", "v0.6.5", "v0.6.4", "v0.6.3", "v0.7.0-beta.1", "nightly-99", "latest"}
+
+	got, err := Range(tags, "0.6.1", "v0.6.5")
+	if err != nil {
+		t.Fatalf("Range: %v", err)
+	}
+	if want := []string{"v0.6.5", "v0.6.4", "v0.6.3", "v0.6.2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+
+	got, _ = Range(tags, "v0.6.4", "")
+	if want := []string{"v0.7.0-beta.1", "v0.6.5"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("open-ended range: got %v; want %v", got, want)
+	}
+
+	if _, err := Range(tags, "0.6.5", "0.6.1"); err == nil {
+		t.Fatal("expected an error for a reversed range")
+	}
+	if _, err := Range(tags, "", "0.6.5"); err == nil {
+		t.Fatal("expected an error without from")
+	}
+}
+
+func TestBreaking(t *testing.T) {
+	body := "## Changes\n" +
+		"- Faster startup\n" +
+		"- BREAKING: removed the legacy console\n" +
+		"## Breaking Changes\n" +
+		"- Mods must target net6\n" +
+		"\n" +
+		"### Details\n" +
+		"Rebuild against the new references.\n" +
+		"## Fixes\n" +
+		"- Fixed a crash\n"
+
+	want := []string{
+		"BREAKING: removed the legacy console",
+		"Mods must target net6",
+		"Rebuild against the new references.",
+	}
+	if got := Breaking(body); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Breaking=%q; want %q", got, want)
+	}
+	if got := Breaking("- Fixed a crash"); got != nil {
+		t.Fatalf("expected no breaking lines, got %q", got)
+	}
+}
+
+type notesSource struct {
+	tags  []string
+	notes map[string]string
+}
+
+func (s notesSource) ListTags(context.Context, string, string, string) ([]string, error) {
+	return s.tags, nil
+}
+
+func (s notesSource) DownloadAsset(context.Context, string, string, string, string, string, string) error {
+	return nil
+}
+
+func (s notesSource) GetRelease(_ context.Context, _, _, tag, _ string) (releases.Release, error) {
+	return releases.Release{
+		Tag:         tag,
+		Name:        "Release " + tag,
+		PublishedAt: time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC),
+		Body:        s.notes[tag],
+	}, nil
+}
+
+func TestCollectAndMarkdown(t *testing.T) {
+	src := notesSource{
+		tags: []string{"v0.6.1", "v0.6.2", "v0.6.3"},
+		notes: map[string]string{
+			"v0.6.2": "- Added Proton support",
+			"v0.6.3": "## Breaking\n- Dropped net35 mods",
+		},
+	}
+
+	c, err := Collect(context.Background(), src, "o", "r", "v0.6.1", "", "")
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if c.From != "0.6.1" || c.To != "0.6.3" || len(c.Entries) != 2 || !c.HasBreaking() {
+		t.Fatalf("changelog=%+v", c)
+	}
+
+	md := c.Markdown()
+	for _, want := range []string{
+		"# Changes from 0.6.1 to 0.6.3",
+		"## ⚠ Breaking changes",
+		"- **0.6.3:** Dropped net35 mods",
+		"## 0.6.3 — Release v0.6.3 (2024-08-01)",
+		"- **⚠ BREAKING:** Dropped net35 mods",
+		"- Added Proton support",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown missing %q:\n%s", want, md)
+		}
+	}
+	if strings.Index(md, "## 0.6.3") > strings.Index(md, "## 0.6.2") {
+		t.Errorf("entries must be newest first:\n%s", md)
+	}
+
+	if _, err := Collect(context.Background(), src, "o", "r", "v0.6.3", "", ""); err == nil {
+		t.Fatal("expected an error when nothing is newer")
+	}
+}
@@ -0,0 +1,4 @@
+// Package changelog collects the release notes published between two versions,
+// newest first, and picks out breaking-change sections so upgrades can be reviewed
+// before downloading.
+package changelog
@@ -0,0 +1,278 @@
+package clrmeta
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+// Element types (ECMA-335 §II.23.1.16) used in attribute signatures and values.
+const (
+	elemBoolean   = 0x02
+	elemChar      = 0x03
+	elemI1        = 0x04
+	elemU1        = 0x05
+	elemI2        = 0x06
+	elemU2        = 0x07
+	elemI4        = 0x08
+	elemU4        = 0x09
+	elemI8        = 0x0A
+	elemU8        = 0x0B
+	elemR4        = 0x0C
+	elemR8        = 0x0D
+	elemString    = 0x0E
+	elemValueType = 0x11
+	elemClass     = 0x12
+	elemObject    = 0x1C
+	elemSZArray   = 0x1D
+	elemCModReqd  = 0x1F
+	elemCModOpt   = 0x20
+	elemType      = 0x50 // System.Type, in attribute values
+	elemBoxed     = 0x51 // a boxed object, in attribute values
+	elemEnum      = 0x55 // an enum, in named argument types
+)
+
+// paramType is a constructor parameter type. Enums are replaced by their
+// underlying type and System.Type by elemType.
+type paramType struct {
+	elem byte
+	arr  *paramType // element type of elemSZArray
+}
+
+// decodeAttribute decodes a custom attribute value (ECMA-335 §II.23.3) using the
+// constructor signature sig.
+func (m *metadata) decodeAttribute(sig, value []byte) ([]any, map[string]any, error) {
+	params, err := m.ctorParams(sig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("constructor signature: %w", err)
+	}
+
+	c := &cursor{b: value}
+	if c.u16() != 0x0001 {
+		return nil, nil, errors.New("attribute value: bad prolog")
+	}
+	args := make([]any, 0, len(params))
+	for _, p := range params {
+		v, err := readValue(c, p)
+		if err != nil {
+			return nil, nil, fmt.Errorf("attribute argument %d: %w", len(args), err)
+		}
+		args = append(args, v)
+	}
+
+	n := int(c.u16())
+	var named map[string]any
+	for i := 0; i < n && c.err == nil; i++ {
+		c.skip(1) // FIELD or PROPERTY
+		p, err := readFieldOrPropType(c)
+		if err != nil {
+			return args, named, fmt.Errorf("named argument %d: %w", i, err)
+		}
+		name, err := readSerString(c)
+		if err != nil || name == nil {
+			return args, named, fmt.Errorf("named argument %d: bad name", i)
+		}
+		v, err := readValue(c, p)
+		if err != nil {
+			return args, named, fmt.Errorf("named argument %s: %w", *name, err)
+		}
+		if named == nil {
+			named = make(map[string]any, n)
+		}
+		named[*name] = v
+	}
+	if c.err != nil {
+		return args, named, fmt.Errorf("attribute value: %w", c.err)
+	}
+	return args, named, nil
+}
+
+// ctorParams parses a constructor's MethodDefSig or MethodRefSig (ECMA-335
+// §II.23.2.1) into its parameter types.
+func (m *metadata) ctorParams(sig []byte) ([]paramType, error) {
+	c := &cursor{b: sig}
+	if conv := c.u8(); conv&0x10 != 0 { // generic
+		c.compressed()
+	}
+	n := c.compressed()
+	if _, err := m.sigType(c); err != nil { // return type (void)
+		return nil, err
+	}
+	if c.err != nil || n > uint32(len(sig)) {
+		return nil, errTruncated
+	}
+	params := make([]paramType, 0, n)
+	for i := uint32(0); i < n; i++ {
+		p, err := m.sigType(c)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, p)
+	}
+	return params, nil
+}
+
+// sigType parses one type of a signature, as far as attribute parameters need.
+func (m *metadata) sigType(c *cursor) (paramType, error) {
+	for {
+		e := c.u8()
+		if c.err != nil {
+			return paramType{}, c.err
+		}
+		switch {
+		case e == 0x01 || (e >= elemBoolean && e <= elemString) || e == elemObject:
+			return paramType{elem: e}, nil
+		case e == elemCModReqd || e == elemCModOpt:
+			c.compressed()
+			continue
+		case e == elemSZArray:
+			inner, err := m.sigType(c)
+			if err != nil {
+				return paramType{}, err
+			}
+			return paramType{elem: elemSZArray, arr: &inner}, nil
+		case e == elemClass:
+			// The only class an attribute argument may have is System.Type.
+			c.compressed()
+			return paramType{elem: elemType}, nil
+		case e == elemValueType:
+			// The only value types an attribute argument may have are enums.
+			tab, row := m.tables.decode(typeDefOrRef, c.compressed())
+			return paramType{elem: m.enumUnderlying(tab, row)}, nil
+		}
+		return paramType{}, fmt.Errorf("unsupported element type %#x", e)
+	}
+}
+
+// enumUnderlying returns the underlying type of an enum. Enums defined in this
+// assembly are looked up; those from other assemblies are assumed to be int32,
+// the default and by far the most common.
+func (m *metadata) enumUnderlying(tab int, row uint32) byte {
+	t := m.tables
+	if tab != tabTypeDef || !t.valid(tabTypeDef, row) {
+		return elemI4
+	}
+	// An enum's first field is its instance field "value__".
+	field := t.cell(tabTypeDef, row, 4)
+	if !t.valid(tabField, field) || m.str(t.cell(tabField, field, 1)) != "value__" {
+		return elemI4
+	}
+	sig, err := m.blobAt(t.cell(tabField, field, 2))
+	if err != nil || len(sig) < 2 || sig[0] != 0x06 || sig[1] < elemBoolean || sig[1] > elemU8 {
+		return elemI4
+	}
+	return sig[1]
+}
+
+// readFieldOrPropType reads the type of a named argument.
+func readFieldOrPropType(c *cursor) (paramType, error) {
+	e := c.u8()
+	switch {
+	case c.err != nil:
+		return paramType{}, c.err
+	case e >= elemBoolean && e <= elemString, e == elemType, e == elemBoxed:
+		return paramType{elem: e}, nil
+	case e == elemSZArray:
+		inner, err := readFieldOrPropType(c)
+		if err != nil {
+			return paramType{}, err
+		}
+		return paramType{elem: elemSZArray, arr: &inner}, nil
+	case e == elemEnum:
+		// The enum's type name follows; its underlying type is not recorded.
+		if _, err := readSerString(c); err != nil {
+			return paramType{}, err
+		}
+		return paramType{elem: elemI4}, nil
+	}
+	return paramType{}, fmt.Errorf("unsupported element type %#x", e)
+}
+
+// readValue reads one FixedArg or named argument value of type p.
+func readValue(c *cursor, p paramType) (any, error) {
+	var v any
+	switch p.elem {
+	case elemBoolean:
+		v = c.u8() != 0
+	case elemChar:
+		v = rune(c.u16())
+	case elemI1:
+		v = int64(int8(c.u8()))
+	case elemU1:
+		v = uint64(c.u8())
+	case elemI2:
+		v = int64(int16(c.u16()))
+	case elemU2:
+		v = uint64(c.u16())
+	case elemI4:
+		v = int64(int32(c.u32()))
+	case elemU4:
+		v = uint64(c.u32())
+	case elemI8:
+		v = int64(c.u64())
+	case elemU8:
+		v = c.u64()
+	case elemR4:
+		v = float64(math.Float32frombits(c.u32()))
+	case elemR8:
+		v = math.Float64frombits(c.u64())
+	case elemString, elemType:
+		s, err := readSerString(c)
+		if err != nil {
+			return nil, err
+		}
+		if s == nil {
+			return nil, nil
+		}
+		v = *s
+	case elemObject, elemBoxed:
+		inner, err := readFieldOrPropType(c)
+		if err != nil {
+			return nil, err
+		}
+		return readValue(c, inner)
+	case elemSZArray:
+		n := c.u32()
+		if n == 0xFFFFFFFF {
+			return nil, nil
+		}
+		if c.err != nil || int(n) > len(c.b)-c.off {
+			return nil, errTruncated
+		}
+		arr := make([]any, 0, n)
+		for i := uint32(0); i < n; i++ {
+			e, err := readValue(c, *p.arr)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, e)
+		}
+		v = arr
+	default:
+		return nil, fmt.Errorf("unsupported element type %#x", p.elem)
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	return v, nil
+}
+
+// readSerString reads a SerString: nil for a null string (0xFF), otherwise a
+// compressed length and UTF-8 bytes.
+func readSerString(c *cursor) (*string, error) {
+	if c.off < len(c.b) && c.b[c.off] == 0xFF {
+		c.off++
+		return nil, nil
+	}
+	n := c.compressed()
+	b := c.bytes(int(n))
+	if c.err != nil {
+		return nil, c.err
+	}
+	if !utf8.Valid(b) {
+		return nil, errors.New("string is not UTF-8")
+	}
+	s := string(b)
+	return &s, nil
+}
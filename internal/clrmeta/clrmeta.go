@@ -0,0 +1,437 @@
+package clrmeta
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"debug/pe"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrNotAssembly reports a file that is not a .NET assembly, such as a native DLL.
+var ErrNotAssembly = errors.New("not a .NET assembly")
+
+// Assembly is the metadata read from a .NET assembly.
+type Assembly struct {
+	// Name and Version come from the Assembly table, e.g. "CoolMod" and "1.2.3.0".
+	Name    string
+	Version string
+	// PublicKeyToken is the hex token of a strong-named assembly's public key, e.g.
+	// "b77a5c561934e089"; it is empty for assemblies without one.
+	PublicKeyToken string
+	// Attributes are the assembly-level custom attributes, in table order.
+	Attributes []Attribute
+	// References are the assemblies, types, members and native functions the
+	// assembly refers to, and its embedded resources.
+	References References
+}
+
+// Attribute is a decoded custom attribute.
+//
+// Argument values are bool, rune (char), int64 (signed integers and enums),
+// uint64 (unsigned integers), float64, string, nil (a null string, type or array)
+// and []any (arrays). System.Type arguments are their serialised type names.
+type Attribute struct {
+	// Type is the attribute's full type name, e.g. "MelonLoader.MelonInfoAttribute".
+	Type string
+	// Args are the constructor arguments in order; Named are the field and property
+	// assignments.
+	Args  []any
+	Named map[string]any
+	// Err is set when the value could not be decoded, e.g. because it uses an enum
+	// whose underlying type is defined in another assembly and is not int32.
+	Err error
+}
+
+// Attrs returns the attributes of the given full type name.
+func (a Assembly) Attrs(typeName string) []Attribute {
+	var out []Attribute
+	for _, at := range a.Attributes {
+		if at.Type == typeName {
+			out = append(out, at)
+		}
+	}
+	return out
+}
+
+// ReadFile reads the metadata of the assembly at name.
+func ReadFile(name string) (Assembly, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return Assembly{}, err
+	}
+	defer f.Close()
+	a, err := Read(f)
+	if err != nil {
+		return Assembly{}, fmt.Errorf("read %s: %w", name, err)
+	}
+	return a, nil
+}
+
+// Read reads the metadata of the assembly in r.
+func Read(r io.ReaderAt) (Assembly, error) {
+	var mz [2]byte
+	if _, err := r.ReadAt(mz[:], 0); err != nil || string(mz[:]) != "MZ" {
+		return Assembly{}, ErrNotAssembly
+	}
+	f, err := pe.NewFile(r)
+	if err != nil {
+		return Assembly{}, fmt.Errorf("%w: %v", ErrNotAssembly, err)
+	}
+	defer f.Close()
+
+	// Data directory 14 locates the CLI header of managed images.
+	var dir pe.DataDirectory
+	switch oh := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		if oh.NumberOfRvaAndSizes > pe.IMAGE_DIRECTORY_ENTRY_COM_DESCRIPTOR {
+			dir = oh.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_COM_DESCRIPTOR]
+		}
+	case *pe.OptionalHeader64:
+		if oh.NumberOfRvaAndSizes > pe.IMAGE_DIRECTORY_ENTRY_COM_DESCRIPTOR {
+			dir = oh.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_COM_DESCRIPTOR]
+		}
+	}
+	if dir.VirtualAddress == 0 || dir.Size < 16 {
+		return Assembly{}, ErrNotAssembly
+	}
+
+	cli, err := readRVA(f, dir.VirtualAddress, min(dir.Size, 32))
+	if err != nil {
+		return Assembly{}, fmt.Errorf("CLI header: %w", err)
+	}
+	// cb, runtime version, then the metadata directory.
+	mdRVA := binary.LittleEndian.Uint32(cli[8:])
+	mdSize := binary.LittleEndian.Uint32(cli[12:])
+	md, err := readRVA(f, mdRVA, mdSize)
+	if err != nil {
+		return Assembly{}, fmt.Errorf("metadata: %w", err)
+	}
+
+	m, err := parseMetadata(md)
+	if err != nil {
+		return Assembly{}, err
+	}
+	a, err := m.assembly()
+	if err != nil {
+		return a, err
+	}
+	// Then flags, the entry point and the resources directory.
+	var res func(uint32) (int64, []byte, error)
+	if len(cli) >= 32 {
+		res = resourceReader(f, binary.LittleEndian.Uint32(cli[24:]), binary.LittleEndian.Uint32(cli[28:]))
+	}
+	a.References = m.references(res)
+	return a, nil
+}
+
+// maxMetadata bounds the metadata read from one file; real mods have far less.
+const maxMetadata = 64 << 20
+
+// readRVA reads size bytes at a relative virtual address.
+func readRVA(f *pe.File, rva, size uint32) ([]byte, error) {
+	if size > maxMetadata {
+		return nil, fmt.Errorf("RVA %#x: %d bytes is too large", rva, size)
+	}
+	for _, s := range f.Sections {
+		if rva < s.VirtualAddress || uint64(rva)+uint64(size) > uint64(s.VirtualAddress)+uint64(max(s.VirtualSize, s.Size)) {
+			continue
+		}
+		b := make([]byte, size)
+		if _, err := s.ReadAt(b, int64(rva-s.VirtualAddress)); err != nil {
+			return nil, fmt.Errorf("RVA %#x: %w", rva, err)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("RVA %#x is outside every section", rva)
+}
+
+// metadata holds the streams of a metadata root.
+type metadata struct {
+	strings []byte
+	blob    []byte
+	tables  *tables
+}
+
+// parseMetadata parses the metadata root (ECMA-335 §II.24.2.1) and its streams.
+func parseMetadata(md []byte) (*metadata, error) {
+	c := &cursor{b: md}
+	if c.u32() != 0x424A5342 { // "BSJB"
+		return nil, errors.New("metadata: bad signature")
+	}
+	c.skip(8) // major, minor, reserved
+	c.skip(int(c.u32()))
+	c.skip(2) // flags
+	n := int(c.u16())
+
+	m := &metadata{}
+	var tableStream []byte
+	for i := 0; i < n && c.err == nil; i++ {
+		off, size := c.u32(), c.u32()
+		start := c.off
+		name := c.cstring()
+		c.off = start + (len(name)+4)&^3 // the name is padded to a multiple of four
+		if uint64(off)+uint64(size) > uint64(len(md)) {
+			return nil, fmt.Errorf("metadata: stream %s is out of range", name)
+		}
+		data := md[off : off+size]
+		switch name {
+		case "#Strings":
+			m.strings = data
+		case "#Blob":
+			m.blob = data
+		case "#~", "#-":
+			tableStream = data
+		}
+	}
+	if c.err != nil {
+		return nil, fmt.Errorf("metadata: %w", c.err)
+	}
+	if tableStream == nil {
+		return nil, errors.New("metadata: no #~ stream")
+	}
+	t, err := parseTables(tableStream)
+	if err != nil {
+		return nil, fmt.Errorf("metadata: %w", err)
+	}
+	m.tables = t
+	return m, nil
+}
+
+// str returns the #Strings heap entry at off.
+func (m *metadata) str(off uint32) string {
+	if off >= uint32(len(m.strings)) {
+		return ""
+	}
+	s := m.strings[off:]
+	if i := bytes.IndexByte(s, 0); i >= 0 {
+		s = s[:i]
+	}
+	return string(s)
+}
+
+// blobAt returns the #Blob heap entry at off.
+func (m *metadata) blobAt(off uint32) ([]byte, error) {
+	if off >= uint32(len(m.blob)) {
+		return nil, fmt.Errorf("blob %#x is out of range", off)
+	}
+	c := &cursor{b: m.blob, off: int(off)}
+	n := c.compressed()
+	b := c.bytes(int(n))
+	return b, c.err
+}
+
+// assembly collects the Assembly row and the custom attributes attached to it.
+func (m *metadata) assembly() (Assembly, error) {
+	t := m.tables
+	var a Assembly
+	if t.rows[tabAssembly] == 0 {
+		return a, fmt.Errorf("%w: no Assembly table (a netmodule?)", ErrNotAssembly)
+	}
+	a.Name = m.str(t.cell(tabAssembly, 1, 7))
+	a.Version = fmt.Sprintf("%d.%d.%d.%d",
+		t.cell(tabAssembly, 1, 1), t.cell(tabAssembly, 1, 2), t.cell(tabAssembly, 1, 3), t.cell(tabAssembly, 1, 4))
+	key, err := m.blobAt(t.cell(tabAssembly, 1, 6))
+	if err != nil {
+		return a, fmt.Errorf("public key: %w", err)
+	}
+	a.PublicKeyToken = publicKeyToken(key)
+
+	for row := uint32(1); row <= t.rows[tabCustomAttribute]; row++ {
+		parent, prow := t.decode(hasCustomAttribute, t.cell(tabCustomAttribute, row, 0))
+		if parent != tabAssembly || prow != 1 {
+			continue
+		}
+		typ, sig, err := m.ctor(t.cell(tabCustomAttribute, row, 1))
+		if err != nil {
+			return a, fmt.Errorf("custom attribute %d: %w", row, err)
+		}
+		at := Attribute{Type: typ}
+		if value, err := m.blobAt(t.cell(tabCustomAttribute, row, 2)); err != nil {
+			at.Err = err
+		} else {
+			at.Args, at.Named, at.Err = m.decodeAttribute(sig, value)
+		}
+		a.Attributes = append(a.Attributes, at)
+	}
+	return a, nil
+}
+
+// publicKeyToken returns the token of a public key: the last eight bytes of its
+// SHA-1 hash, reversed.
+func publicKeyToken(key []byte) string {
+	if len(key) == 0 {
+		return ""
+	}
+	sum := sha1.Sum(key)
+	token := make([]byte, 8)
+	for i := range token {
+		token[i] = sum[len(sum)-1-i]
+	}
+	return hex.EncodeToString(token)
+}
+
+// ctor resolves a CustomAttributeType coded index to the attribute's type name and
+// its constructor's signature.
+func (m *metadata) ctor(v uint32) (string, []byte, error) {
+	t := m.tables
+	tab, row := t.decode(customAttributeType, v)
+	if !t.valid(tab, row) {
+		return "", nil, fmt.Errorf("bad constructor %#x", v)
+	}
+	switch tab {
+	case tabMethodDef:
+		sig, err := m.blobAt(t.cell(tabMethodDef, row, 4))
+		return m.typeDefName(t.ownerOf(row), 0), sig, err
+	case tabMemberRef:
+		sig, err := m.blobAt(t.cell(tabMemberRef, row, 2))
+		if err != nil {
+			return "", nil, err
+		}
+		ptab, prow := t.decode(memberRefParent, t.cell(tabMemberRef, row, 0))
+		if !t.valid(ptab, prow) {
+			return "", nil, fmt.Errorf("bad constructor parent %#x", v)
+		}
+		return m.typeName(ptab, prow), sig, nil
+	}
+	return "", nil, fmt.Errorf("bad constructor %#x", v)
+}
+
+// typeName names a TypeDef or TypeRef row; other tables yield "".
+func (m *metadata) typeName(tab int, row uint32) string {
+	switch tab {
+	case tabTypeDef:
+		return m.typeDefName(row, 0)
+	case tabTypeRef:
+		return m.typeRefName(row, 0)
+	}
+	return ""
+}
+
+// maxNesting bounds the nested type chains followed when naming a type, so that
+// malformed metadata cannot loop.
+const maxNesting = 8
+
+func (m *metadata) typeDefName(row uint32, depth int) string {
+	t := m.tables
+	if !t.valid(tabTypeDef, row) || depth > maxNesting {
+		return ""
+	}
+	name := qualify(m.str(t.cell(tabTypeDef, row, 2)), m.str(t.cell(tabTypeDef, row, 1)))
+	if outer := t.enclosing(row); outer != 0 {
+		return m.typeDefName(outer, depth+1) + "+" + name
+	}
+	return name
+}
+
+func (m *metadata) typeRefName(row uint32, depth int) string {
+	t := m.tables
+	if !t.valid(tabTypeRef, row) || depth > maxNesting {
+		return ""
+	}
+	name := qualify(m.str(t.cell(tabTypeRef, row, 2)), m.str(t.cell(tabTypeRef, row, 1)))
+	if tab, outer := t.decode(resolutionScope, t.cell(tabTypeRef, row, 0)); tab == tabTypeRef {
+		return m.typeRefName(outer, depth+1) + "+" + name
+	}
+	return name
+}
+
+func qualify(ns, name string) string {
+	if ns == "" {
+		return name
+	}
+	return ns + "." + name
+}
+
+// cursor reads little-endian values from b, recording the first out-of-range read
+// in err; reads after an error return zero values.
+type cursor struct {
+	b   []byte
+	off int
+	err error
+}
+
+var errTruncated = errors.New("truncated data")
+
+func (c *cursor) bytes(n int) []byte {
+	if c.err != nil {
+		return nil
+	}
+	if n < 0 || c.off+n > len(c.b) {
+		c.err = errTruncated
+		return nil
+	}
+	b := c.b[c.off : c.off+n]
+	c.off += n
+	return b
+}
+
+func (c *cursor) skip(n int) { c.bytes(n) }
+
+func (c *cursor) u8() uint8 {
+	if b := c.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (c *cursor) u16() uint16 {
+	if b := c.bytes(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (c *cursor) u32() uint32 {
+	if b := c.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (c *cursor) u64() uint64 {
+	if b := c.bytes(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+// compressed reads a compressed unsigned integer (ECMA-335 §II.23.2).
+func (c *cursor) compressed() uint32 {
+	b0 := uint32(c.u8())
+	switch {
+	case b0&0x80 == 0:
+		return b0
+	case b0&0xC0 == 0x80:
+		return (b0&0x3F)<<8 | uint32(c.u8())
+	case b0&0xE0 == 0xC0:
+		b := c.bytes(3)
+		if b == nil {
+			return 0
+		}
+		return (b0&0x1F)<<24 | uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])
+	}
+	if c.err == nil {
+		c.err = errors.New("bad compressed integer")
+	}
+	return 0
+}
+
+// cstring reads a NUL-terminated string.
+func (c *cursor) cstring() string {
+	if c.err != nil {
+		return ""
+	}
+	i := bytes.IndexByte(c.b[c.off:], 0)
+	if i < 0 {
+		c.err = errTruncated
+		return ""
+	}
+	s := string(c.b[c.off : c.off+i])
+	c.off += i + 1
+	return s
+}
@@ -0,0 +1,171 @@
+package clrmeta
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// The fixtures are built from testdata/src; see build.sh there.
+
+func readFixture(t *testing.T, name string) Assembly {
+	t.Helper()
+	a, err := ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("ReadFile(%s): %v", name, err)
+	}
+	return a
+}
+
+func attr(t *testing.T, a Assembly, typeName string) Attribute {
+	t.Helper()
+	got := a.Attrs(typeName)
+	if len(got) != 1 {
+		t.Fatalf("%s: %d %s attributes", a.Name, len(got), typeName)
+	}
+	if got[0].Err != nil {
+		t.Fatalf("%s: %v", typeName, got[0].Err)
+	}
+	return got[0]
+}
+
+func TestReadMemberRefAttributes(t *testing.T) {
+	a := readFixture(t, "CoolMod.dll")
+	if a.Name != "CoolMod" || a.Version != "1.2.3.0" {
+		t.Fatalf("name/version = %q/%q", a.Name, a.Version)
+	}
+
+	info := attr(t, a, "MelonLoader.MelonInfoAttribute")
+	want := []any{"CoolMod.Main", "Cool Mod", "1.2.3", "Jane Doe", "https://example.com/coolmod"}
+	if !reflect.DeepEqual(info.Args, want) {
+		t.Fatalf("MelonInfo args = %#v", info.Args)
+	}
+
+	games := a.Attrs("MelonLoader.MelonGameAttribute")
+	if len(games) != 2 || !reflect.DeepEqual(games[1].Args, []any{"Kinetic Games", "Phasmophobia"}) {
+		t.Fatalf("MelonGame = %+v", games)
+	}
+	if c := attr(t, a, "MelonLoader.MelonColorAttribute"); !reflect.DeepEqual(c.Args, []any{int64(255), int64(200), int64(100), int64(50)}) {
+		t.Fatalf("MelonColor args = %#v", c.Args)
+	}
+	if v := attr(t, a, "MelonLoader.VerifyLoaderVersionAttribute"); !reflect.DeepEqual(v.Args, []any{int64(0), int64(6), int64(0), true}) {
+		t.Fatalf("VerifyLoaderVersion args = %#v", v.Args)
+	}
+
+	tf := attr(t, a, "System.Runtime.Versioning.TargetFrameworkAttribute")
+	if tf.Named["FrameworkDisplayName"] != ".NET 6.0" {
+		t.Fatalf("TargetFramework named = %#v", tf.Named)
+	}
+}
+
+func TestReadNullsAndEnums(t *testing.T) {
+	a := readFixture(t, "LegacyPlugin.dll")
+
+	info := attr(t, a, "MelonLoader.MelonInfoAttribute")
+	want := []any{"LegacyPlugin.Plugin", "Legacy Plugin", int64(2), int64(0), int64(1), "-beta", "Someone", nil}
+	if !reflect.DeepEqual(info.Args, want) {
+		t.Fatalf("MelonInfo args = %#v", info.Args)
+	}
+	if g := attr(t, a, "MelonLoader.MelonGameAttribute"); !reflect.DeepEqual(g.Args, []any{nil, nil}) {
+		t.Fatalf("MelonGame args = %#v", g.Args)
+	}
+	// ConsoleColor.Cyan, an enum defined in another assembly.
+	if c := attr(t, a, "MelonLoader.MelonColorAttribute"); !reflect.DeepEqual(c.Args, []any{int64(11)}) {
+		t.Fatalf("MelonColor args = %#v", c.Args)
+	}
+}
+
+func TestReadMethodDefAttribute(t *testing.T) {
+	a := readFixture(t, "SelfContained.dll")
+
+	info := attr(t, a, "MelonLoader.MelonInfoAttribute")
+	if !reflect.DeepEqual(info.Args, []any{"SelfContained.Thing", "Self Contained", "0.1.0", nil}) {
+		t.Fatalf("args = %#v", info.Args)
+	}
+	want := map[string]any{"Tags": []any{"a", "b"}, "Priority": int64(-3)}
+	if !reflect.DeepEqual(info.Named, want) {
+		t.Fatalf("named = %#v", info.Named)
+	}
+}
+
+func TestReadReferences(t *testing.T) {
+	r := readFixture(t, "RiskyMod.dll").References
+	has := func(list []string, s string) bool {
+		for _, x := range list {
+			if x == s {
+				return true
+			}
+		}
+		return false
+	}
+	for _, m := range []string{
+		"System.Diagnostics.Process::Start(string)",
+		"System.Net.Http.HttpClient::GetStringAsync(string)",
+		"System.Reflection.Assembly::Load(byte[])",
+		"System.IntPtr::Zero",
+		"MelonLoader.MelonInfoAttribute::.ctor(System.Type, string, string, string, string)",
+	} {
+		if !has(r.Members, m) {
+			t.Errorf("no member %s in %q", m, r.Members)
+		}
+	}
+	if !has(r.Types, "System.Net.Http.HttpClient") || !has(r.Types, "System.Threading.Tasks.Task`1") {
+		t.Errorf("types %q", r.Types)
+	}
+	if !has(r.Assemblies, "System.Net.Http") {
+		t.Errorf("assemblies %q", r.Assemblies)
+	}
+	if want := []PInvoke{{"kernel32.dll", "VirtualAlloc"}}; !reflect.DeepEqual(r.PInvokes, want) {
+		t.Errorf("P/Invokes %+v", r.PInvokes)
+	}
+	if want := []Resource{{"payload.bin", 26, true}, {"readme.txt", 11, false}}; !reflect.DeepEqual(r.Resources, want) {
+		t.Errorf("resources %+v", r.Resources)
+	}
+}
+
+func TestPublicKeyToken(t *testing.T) {
+	if a := readFixture(t, "CoolMod.dll"); a.PublicKeyToken != "" {
+		t.Errorf("unsigned assembly has token %q", a.PublicKeyToken)
+	}
+	// The ECMA standard public key of mscorlib and System.
+	ecma := []byte{0, 0, 0, 0, 0, 0, 0, 0, 4, 0, 0, 0, 0, 0, 0, 0}
+	if got := publicKeyToken(ecma); got != "b77a5c561934e089" {
+		t.Errorf("token = %q", got)
+	}
+}
+
+func TestReadNotAssembly(t *testing.T) {
+	dll, err := os.ReadFile(filepath.Join("testdata", "CoolMod.dll"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, b := range map[string][]byte{
+		"text":  []byte("hello"),
+		"empty": nil,
+		"MZ":    []byte("MZ\x00\x00"),
+	} {
+		if _, err := Read(bytes.NewReader(b)); !errors.Is(err, ErrNotAssembly) {
+			t.Errorf("%s: got %v; want ErrNotAssembly", name, err)
+		}
+	}
+	if _, err := Read(bytes.NewReader(dll[:len(dll)/2])); err == nil {
+		t.Error("truncated assembly: expected an error")
+	}
+}
+
+// FuzzRead checks that malformed input yields errors rather than panics.
+func FuzzRead(f *testing.F) {
+	for _, name := range []string{"CoolMod.dll", "LegacyPlugin.dll", "SelfContained.dll", "RiskyMod.dll"} {
+		b, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		_, _ = Read(bytes.NewReader(b))
+	})
+}
@@ -0,0 +1,12 @@
+// Package clrmeta reads the ECMA-335 metadata of .NET assemblies without running
+// .NET.
+//
+// It implements just enough of the format to identify an assembly, decode its
+// assembly-level custom attributes and list what it refers to: the PE CLI header,
+// the metadata root and its #~, #Strings and #Blob streams, the TypeRef, TypeDef,
+// MethodDef, MemberRef, CustomAttribute, Assembly and AssemblyRef tables, and the
+// ImplMap and ManifestResource tables of P/Invokes and embedded resources. It is
+// used to read MelonLoader's MelonInfo, MelonGame and MelonColor attributes from
+// mod DLLs, to tell which files define the same assembly, and to scan mods for
+// risky references.
+package clrmeta
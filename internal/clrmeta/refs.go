@@ -0,0 +1,268 @@
+package clrmeta
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// References are what an assembly uses from outside itself, as recorded in its
+// metadata. They say what the assembly can call, not what it does call at run
+// time.
+type References struct {
+	// Assemblies are the names of the referenced assemblies, e.g. "System.Net.Http".
+	Assemblies []string
+	// Types are the full names of the referenced types, e.g.
+	// "System.Net.Http.HttpClient". Nested types are joined with "+".
+	Types []string
+	// Members are the referenced methods, as "Type::Name(params)" with C# type
+	// names such as "System.Diagnostics.Process::Start(string)", and fields, as
+	// "Type::Name".
+	Members []string
+	// PInvokes are the native functions the assembly imports.
+	PInvokes []PInvoke
+	// Resources are the resources embedded in the assembly.
+	Resources []Resource
+}
+
+// PInvoke is a native function imported with DllImport.
+type PInvoke struct {
+	// Module is the library as written in the assembly, e.g. "kernel32" or
+	// "kernel32.dll"; Entry is the imported function's name.
+	Module string
+	Entry  string
+}
+
+// Resource is a resource embedded in an assembly.
+type Resource struct {
+	Name string
+	Size int64
+	// Native is set when the resource is itself a Windows or ELF executable or
+	// library.
+	Native bool
+}
+
+// references collects the references of m. res reads the embedded resource at an
+// offset in the CLI resources section; it may be nil.
+func (m *metadata) references(res func(off uint32) (int64, []byte, error)) References {
+	t := m.tables
+	var r References
+	for row := uint32(1); row <= t.rows[tabAssemblyRef]; row++ {
+		r.Assemblies = append(r.Assemblies, m.str(t.cell(tabAssemblyRef, row, 6)))
+	}
+	for row := uint32(1); row <= t.rows[tabTypeRef]; row++ {
+		r.Types = append(r.Types, m.typeRefName(row, 0))
+	}
+	for row := uint32(1); row <= t.rows[tabMemberRef]; row++ {
+		if s := m.memberRefString(row); s != "" {
+			r.Members = append(r.Members, s)
+		}
+	}
+	for row := uint32(1); row <= t.rows[tabImplMap]; row++ {
+		mod := t.cell(tabImplMap, row, 3)
+		r.PInvokes = append(r.PInvokes, PInvoke{
+			Module: m.str(t.cell(tabModuleRef, mod, 0)),
+			Entry:  m.str(t.cell(tabImplMap, row, 2)),
+		})
+	}
+	for row := uint32(1); row <= t.rows[tabManifestResource]; row++ {
+		if t.cell(tabManifestResource, row, 3) != 0 {
+			continue // in another file of a multi-file assembly
+		}
+		rs := Resource{Name: m.str(t.cell(tabManifestResource, row, 2))}
+		if res != nil {
+			if size, head, err := res(t.cell(tabManifestResource, row, 0)); err == nil {
+				rs.Size = size
+				rs.Native = bytes.HasPrefix(head, []byte("MZ")) || bytes.HasPrefix(head, []byte("\x7fELF"))
+			}
+		}
+		r.Resources = append(r.Resources, rs)
+	}
+	return r
+}
+
+// resourceReader returns a function reading the size and first bytes of the
+// resource at an offset in the resources section at rva.
+func resourceReader(f *pe.File, rva, size uint32) func(off uint32) (int64, []byte, error) {
+	if rva == 0 || size == 0 {
+		return nil
+	}
+	return func(off uint32) (int64, []byte, error) {
+		if uint64(off)+4 > uint64(size) {
+			return 0, nil, fmt.Errorf("resource %#x is out of range", off)
+		}
+		b, err := readRVA(f, rva+off, 4)
+		if err != nil {
+			return 0, nil, err
+		}
+		n := binary.LittleEndian.Uint32(b)
+		head, err := readRVA(f, rva+off+4, min(n, 4, size-off-4))
+		return int64(n), head, err
+	}
+}
+
+// memberRefString formats MemberRef row as "Type::Name(params)" or "Type::Name",
+// or returns "" if its parent is not a type.
+func (m *metadata) memberRefString(row uint32) string {
+	t := m.tables
+	var typ string
+	switch tab, prow := t.decode(memberRefParent, t.cell(tabMemberRef, row, 0)); tab {
+	case tabTypeRef, tabTypeDef:
+		typ = m.typeName(tab, prow)
+	case tabTypeSpec:
+		if sig, err := m.blobAt(t.cell(tabTypeSpec, prow, 0)); err == nil {
+			typ, _ = m.typeString(&cursor{b: sig}, 0)
+		}
+	}
+	if typ == "" {
+		return ""
+	}
+	s := typ + "::" + m.str(t.cell(tabMemberRef, row, 1))
+	sig, err := m.blobAt(t.cell(tabMemberRef, row, 2))
+	if err != nil || len(sig) == 0 || sig[0]&0x0F == 0x06 { // a field
+		return s
+	}
+	params, err := m.methodParams(&cursor{b: sig}, 0)
+	if err != nil {
+		return s + "(?)"
+	}
+	return s + "(" + strings.Join(params, ", ") + ")"
+}
+
+// methodParams reads a method signature (ECMA-335 §II.23.2.1-3) and returns its
+// parameter types.
+func (m *metadata) methodParams(c *cursor, depth int) ([]string, error) {
+	if conv := c.u8(); conv&0x10 != 0 { // generic
+		c.compressed()
+	}
+	n := c.compressed()
+	if c.err != nil || n > uint32(len(c.b)) {
+		return nil, errTruncated
+	}
+	if _, err := m.typeString(c, depth); err != nil { // return type
+		return nil, err
+	}
+	params := make([]string, 0, n)
+	for i := uint32(0); i < n; i++ {
+		if c.off < len(c.b) && c.b[c.off] == elemSentinel {
+			c.skip(1)
+			params = append(params, "...")
+		}
+		p, err := m.typeString(c, depth)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, p)
+	}
+	return params, nil
+}
+
+// Further element types (ECMA-335 §II.23.1.16) of method and type signatures.
+const (
+	elemVoid        = 0x01
+	elemPtr         = 0x0F
+	elemByRef       = 0x10
+	elemVar         = 0x13
+	elemArray       = 0x14
+	elemGenericInst = 0x15
+	elemTypedByRef  = 0x16
+	elemI           = 0x18
+	elemU           = 0x19
+	elemFnPtr       = 0x1B
+	elemMVar        = 0x1E
+	elemSentinel    = 0x41
+	elemPinned      = 0x45
+)
+
+// csharpNames are the C# names of the primitive element types.
+var csharpNames = map[byte]string{
+	elemVoid: "void", elemBoolean: "bool", elemChar: "char", elemI1: "sbyte", elemU1: "byte",
+	elemI2: "short", elemU2: "ushort", elemI4: "int", elemU4: "uint", elemI8: "long", elemU8: "ulong",
+	elemR4: "float", elemR8: "double", elemString: "string", elemObject: "object",
+	elemTypedByRef: "typedref", elemI: "nint", elemU: "nuint",
+}
+
+// typeString reads one type of a signature and formats it with C# names, e.g.
+// "byte[]" or "System.Collections.Generic.List<string>".
+func (m *metadata) typeString(c *cursor, depth int) (string, error) {
+	if depth > maxNesting {
+		return "", fmt.Errorf("signature nested too deeply")
+	}
+	for {
+		e := c.u8()
+		if c.err != nil {
+			return "", c.err
+		}
+		if name, ok := csharpNames[e]; ok {
+			return name, nil
+		}
+		switch e {
+		case elemCModReqd, elemCModOpt:
+			c.compressed()
+			continue
+		case elemPinned:
+			continue
+		case elemPtr:
+			inner, err := m.typeString(c, depth+1)
+			return inner + "*", err
+		case elemByRef:
+			inner, err := m.typeString(c, depth+1)
+			return inner + "&", err
+		case elemSZArray:
+			inner, err := m.typeString(c, depth+1)
+			return inner + "[]", err
+		case elemArray:
+			inner, err := m.typeString(c, depth+1)
+			rank := c.compressed()
+			for i := c.compressed(); i > 0 && c.err == nil; i-- { // sizes
+				c.compressed()
+			}
+			for i := c.compressed(); i > 0 && c.err == nil; i-- { // lower bounds
+				c.compressed()
+			}
+			if rank > 32 {
+				return "", fmt.Errorf("array rank %d", rank)
+			}
+			return inner + "[" + strings.Repeat(",", int(max(rank, 1)-1)) + "]", err
+		case elemValueType, elemClass:
+			tab, row := m.tables.decode(typeDefOrRef, c.compressed())
+			if name := m.typeName(tab, row); name != "" {
+				return name, c.err
+			}
+			return "?", c.err
+		case elemVar:
+			return fmt.Sprintf("!%d", c.compressed()), c.err
+		case elemMVar:
+			return fmt.Sprintf("!!%d", c.compressed()), c.err
+		case elemGenericInst:
+			generic, err := m.typeString(c, depth+1) // CLASS or VALUETYPE and the type
+			if err != nil {
+				return "", err
+			}
+			if i := strings.LastIndexByte(generic, '`'); i >= 0 {
+				generic = generic[:i] // Task`1 -> Task
+			}
+			n := c.compressed()
+			if n > uint32(len(c.b)) {
+				return "", errTruncated
+			}
+			args := make([]string, 0, n)
+			for i := uint32(0); i < n; i++ {
+				a, err := m.typeString(c, depth+1)
+				if err != nil {
+					return "", err
+				}
+				args = append(args, a)
+			}
+			return generic + "<" + strings.Join(args, ", ") + ">", nil
+		case elemFnPtr:
+			if _, err := m.methodParams(c, depth+1); err != nil {
+				return "", err
+			}
+			return "fnptr", nil
+		}
+		return "", fmt.Errorf("unsupported element type %#x", e)
+	}
+}
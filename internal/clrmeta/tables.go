@@ -0,0 +1,292 @@
+package clrmeta
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Metadata tables (ECMA-335 §II.22) referred to by name.
+const (
+	tabModule             = 0x00
+	tabTypeRef            = 0x01
+	tabTypeDef            = 0x02
+	tabField              = 0x04
+	tabMethodDef          = 0x06
+	tabParam              = 0x08
+	tabInterfaceImpl      = 0x09
+	tabMemberRef          = 0x0A
+	tabConstant           = 0x0B
+	tabCustomAttribute    = 0x0C
+	tabDeclSecurity       = 0x0E
+	tabStandAloneSig      = 0x11
+	tabEvent              = 0x14
+	tabProperty           = 0x17
+	tabModuleRef          = 0x1A
+	tabTypeSpec           = 0x1B
+	tabImplMap            = 0x1C
+	tabAssembly           = 0x20
+	tabAssemblyRef        = 0x23
+	tabFile               = 0x26
+	tabExportedType       = 0x27
+	tabManifestResource   = 0x28
+	tabNestedClass        = 0x29
+	tabGenericParam       = 0x2A
+	tabMethodSpec         = 0x2B
+	tabGenericParamConstr = 0x2C
+
+	numTables = 0x2D
+	none      = -1 // an unused coded index tag
+)
+
+// Coded indexes (ECMA-335 §II.24.2.6): the tables a tag selects, in tag order.
+type codedIndex struct {
+	bits   uint
+	tables []int
+}
+
+var (
+	typeDefOrRef       = codedIndex{2, []int{tabTypeDef, tabTypeRef, tabTypeSpec}}
+	hasConstant        = codedIndex{2, []int{tabField, tabParam, tabProperty}}
+	hasCustomAttribute = codedIndex{5, []int{
+		tabMethodDef, tabField, tabTypeRef, tabTypeDef, tabParam, tabInterfaceImpl, tabMemberRef,
+		tabModule, tabDeclSecurity, tabProperty, tabEvent, tabStandAloneSig, tabModuleRef, tabTypeSpec,
+		tabAssembly, tabAssemblyRef, tabFile, tabExportedType, tabManifestResource, tabGenericParam,
+		tabGenericParamConstr, tabMethodSpec,
+	}}
+	hasFieldMarshal     = codedIndex{1, []int{tabField, tabParam}}
+	hasDeclSecurity     = codedIndex{2, []int{tabTypeDef, tabMethodDef, tabAssembly}}
+	memberRefParent     = codedIndex{3, []int{tabTypeDef, tabTypeRef, tabModuleRef, tabMethodDef, tabTypeSpec}}
+	hasSemantics        = codedIndex{1, []int{tabEvent, tabProperty}}
+	methodDefOrRef      = codedIndex{1, []int{tabMethodDef, tabMemberRef}}
+	memberForwarded     = codedIndex{1, []int{tabField, tabMethodDef}}
+	implementation      = codedIndex{2, []int{tabFile, tabAssemblyRef, tabExportedType}}
+	customAttributeType = codedIndex{3, []int{none, none, tabMethodDef, tabMemberRef, none}}
+	resolutionScope     = codedIndex{2, []int{tabModule, tabModuleRef, tabAssemblyRef, tabTypeRef}}
+	typeOrMethodDef     = codedIndex{1, []int{tabTypeDef, tabMethodDef}}
+)
+
+// column describes one column of a table row.
+type column struct {
+	kind  colKind
+	table int         // for colIndex
+	coded *codedIndex // for colCoded
+}
+
+type colKind int
+
+const (
+	colU16 colKind = iota
+	colU32
+	colString
+	colGUID
+	colBlob
+	colIndex
+	colCoded
+)
+
+var (
+	u16  = column{kind: colU16}
+	u32  = column{kind: colU32}
+	str  = column{kind: colString}
+	guid = column{kind: colGUID}
+	blob = column{kind: colBlob}
+)
+
+func idx(table int) column       { return column{kind: colIndex, table: table} }
+func coded(c *codedIndex) column { return column{kind: colCoded, coded: c} }
+
+// schema lists the columns of every table that may appear in an assembly. Tables
+// must all be known to find the ones after them.
+var schema = [numTables][]column{
+	0x00: {u16, str, guid, guid, guid},                                            // Module
+	0x01: {coded(&resolutionScope), str, str},                                     // TypeRef
+	0x02: {u32, str, str, coded(&typeDefOrRef), idx(tabField), idx(tabMethodDef)}, // TypeDef
+	0x03: {idx(tabField)},                                                         // FieldPtr
+	0x04: {u16, str, blob},                                                        // Field
+	0x05: {idx(tabMethodDef)},                                                     // MethodPtr
+	0x06: {u32, u16, u16, str, blob, idx(tabParam)},                               // MethodDef
+	0x07: {idx(tabParam)},                                                         // ParamPtr
+	0x08: {u16, u16, str},                                                         // Param
+	0x09: {idx(tabTypeDef), coded(&typeDefOrRef)},                                 // InterfaceImpl
+	0x0A: {coded(&memberRefParent), str, blob},                                    // MemberRef
+	0x0B: {u16, coded(&hasConstant), blob},                                        // Constant
+	0x0C: {coded(&hasCustomAttribute), coded(&customAttributeType), blob},         // CustomAttribute
+	0x0D: {coded(&hasFieldMarshal), blob},                                         // FieldMarshal
+	0x0E: {u16, coded(&hasDeclSecurity), blob},                                    // DeclSecurity
+	0x0F: {u16, u32, idx(tabTypeDef)},                                             // ClassLayout
+	0x10: {u32, idx(tabField)},                                                    // FieldLayout
+	0x11: {blob},                                                                  // StandAloneSig
+	0x12: {idx(tabTypeDef), idx(tabEvent)},                                        // EventMap
+	0x13: {idx(tabEvent)},                                                         // EventPtr
+	0x14: {u16, str, coded(&typeDefOrRef)},                                        // Event
+	0x15: {idx(tabTypeDef), idx(tabProperty)},                                     // PropertyMap
+	0x16: {idx(tabProperty)},                                                      // PropertyPtr
+	0x17: {u16, str, blob},                                                        // Property
+	0x18: {u16, idx(tabMethodDef), coded(&hasSemantics)},                          // MethodSemantics
+	0x19: {idx(tabTypeDef), coded(&methodDefOrRef), coded(&methodDefOrRef)},       // MethodImpl
+	0x1A: {str},                                                                   // ModuleRef
+	0x1B: {blob},                                                                  // TypeSpec
+	0x1C: {u16, coded(&memberForwarded), str, idx(tabModuleRef)},                  // ImplMap
+	0x1D: {u32, idx(tabField)},                                                    // FieldRVA
+	0x1E: {u32, u32},                                                              // EncLog
+	0x1F: {u32},                                                                   // EncMap
+	0x20: {u32, u16, u16, u16, u16, u32, blob, str, str},                          // Assembly
+	0x21: {u32},                                                                   // AssemblyProcessor
+	0x22: {u32, u32, u32},                                                         // AssemblyOS
+	0x23: {u16, u16, u16, u16, u32, blob, str, str, blob},                         // AssemblyRef
+	0x24: {u32, idx(tabAssemblyRef)},                                              // AssemblyRefProcessor
+	0x25: {u32, u32, u32, idx(tabAssemblyRef)},                                    // AssemblyRefOS
+	0x26: {u32, str, blob},                                                        // File
+	0x27: {u32, u32, str, str, coded(&implementation)},                            // ExportedType
+	0x28: {u32, u32, str, coded(&implementation)},                                 // ManifestResource
+	0x29: {idx(tabTypeDef), idx(tabTypeDef)},                                      // NestedClass
+	0x2A: {u16, u16, coded(&typeOrMethodDef), str},                                // GenericParam
+	0x2B: {coded(&methodDefOrRef), blob},                                          // MethodSpec
+	0x2C: {idx(tabGenericParam), coded(&typeDefOrRef)},                            // GenericParamConstraint
+}
+
+// tables is a parsed #~ stream.
+type tables struct {
+	rows    [numTables]uint32
+	data    [numTables][]byte
+	rowSize [numTables]int
+	colOff  [numTables][]int
+	colSize [numTables][]int
+}
+
+// parseTables parses the #~ stream header (ECMA-335 §II.24.2.6) and locates every
+// table in it.
+func parseTables(b []byte) (*tables, error) {
+	c := &cursor{b: b}
+	c.skip(6) // reserved, major, minor
+	heapSizes := c.u8()
+	c.skip(1)
+	valid := c.u64()
+	c.skip(8) // sorted
+	if valid>>numTables != 0 {
+		return nil, fmt.Errorf("unsupported metadata tables %#x", valid>>numTables)
+	}
+
+	t := &tables{}
+	for i := 0; i < numTables; i++ {
+		if valid&(1<<i) != 0 {
+			t.rows[i] = c.u32()
+		}
+	}
+	if heapSizes&0x40 != 0 {
+		c.skip(4) // extra data
+	}
+	if c.err != nil {
+		return nil, fmt.Errorf("tables header: %w", c.err)
+	}
+
+	heapSize := func(bit uint8) int {
+		if heapSizes&bit != 0 {
+			return 4
+		}
+		return 2
+	}
+	for i := 0; i < numTables; i++ {
+		t.colOff[i] = make([]int, len(schema[i]))
+		t.colSize[i] = make([]int, len(schema[i]))
+		for j, col := range schema[i] {
+			var n int
+			switch col.kind {
+			case colU16:
+				n = 2
+			case colU32:
+				n = 4
+			case colString:
+				n = heapSize(0x01)
+			case colGUID:
+				n = heapSize(0x02)
+			case colBlob:
+				n = heapSize(0x04)
+			case colIndex:
+				n = 2
+				if t.rows[col.table] > 0xFFFF {
+					n = 4
+				}
+			case colCoded:
+				n = t.codedSize(col.coded)
+			}
+			t.colOff[i][j] = t.rowSize[i]
+			t.colSize[i][j] = n
+			t.rowSize[i] += n
+		}
+	}
+
+	for i := 0; i < numTables; i++ {
+		size := uint64(t.rows[i]) * uint64(t.rowSize[i])
+		if size > uint64(len(b)-c.off) {
+			return nil, errors.New("tables: truncated")
+		}
+		t.data[i] = c.bytes(int(size))
+	}
+	return t, nil
+}
+
+// codedSize returns the width of a coded index: two bytes if every table it refers
+// to has fewer than 2^(16-bits) rows, otherwise four.
+func (t *tables) codedSize(c *codedIndex) int {
+	var most uint32
+	for _, tab := range c.tables {
+		if tab != none {
+			most = max(most, t.rows[tab])
+		}
+	}
+	if most < 1<<(16-c.bits) {
+		return 2
+	}
+	return 4
+}
+
+// cell returns column col of the 1-based row of table tab. The caller checks that
+// the row exists.
+func (t *tables) cell(tab int, row uint32, col int) uint32 {
+	if !t.valid(tab, row) {
+		return 0
+	}
+	b := t.data[tab][int(row-1)*t.rowSize[tab]+t.colOff[tab][col]:]
+	if t.colSize[tab][col] == 2 {
+		return uint32(b[0]) | uint32(b[1])<<8
+	}
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+}
+
+// valid reports whether row exists in table tab.
+func (t *tables) valid(tab int, row uint32) bool {
+	return tab >= 0 && tab < numTables && row >= 1 && row <= t.rows[tab]
+}
+
+// decode splits a coded index into its table and row; an unknown tag yields none.
+func (t *tables) decode(c codedIndex, v uint32) (int, uint32) {
+	tag := v & (1<<c.bits - 1)
+	if int(tag) >= len(c.tables) {
+		return none, 0
+	}
+	return c.tables[tag], v >> c.bits
+}
+
+// ownerOf returns the TypeDef row whose method list contains MethodDef row method,
+// or 0.
+func (t *tables) ownerOf(method uint32) uint32 {
+	var owner uint32
+	for row := uint32(1); row <= t.rows[tabTypeDef]; row++ {
+		if t.cell(tabTypeDef, row, 5) > method {
+			break
+		}
+		owner = row
+	}
+	return owner
+}
+
+// enclosing returns the TypeDef row enclosing the nested type row, or 0.
+func (t *tables) enclosing(row uint32) uint32 {
+	for r := uint32(1); r <= t.rows[tabNestedClass]; r++ {
+		if t.cell(tabNestedClass, r, 0) == row {
+			return t.cell(tabNestedClass, r, 1)
+		}
+	}
+	return 0
+}
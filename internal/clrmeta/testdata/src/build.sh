@@ -0,0 +1,9 @@
+#!/bin/sh
+# Rebuilds the fixture assemblies in ../ from these sources. Requires the .NET SDK.
+set -eu
+cd "$(dirname "$0")"
+for p in MelonLoader CoolMod LegacyPlugin SelfContained RiskyMod; do
+	dotnet build -nologo -v q -c Release -o "bin/$p" "$p/$p.csproj"
+	cp "bin/$p/$p.dll" ..
+done
+rm -rf bin */bin */obj
@@ -0,0 +1,282 @@
+package credentials
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultHost is the host explicit tokens apply to when none is configured.
+const DefaultHost = "github.com"
+
+// Names reported in Credential.Source.
+const (
+	SourceFlag          = "--token"
+	SourceTokenFile     = "--token-file"
+	SourceTokenCmd      = "--token-cmd"
+	SourceGitHubToken   = "GITHUB_TOKEN"
+	SourceGHToken       = "GH_TOKEN"
+	SourceGHHosts       = "gh hosts.yml"
+	SourceGitCredential = "git credential"
+	SourceNetrc         = "netrc"
+)
+
+// Credential is a token together with the host it is valid for and where it came from.
+type Credential struct {
+	Token  string
+	Host   string
+	Source string
+}
+
+// Resolver looks up tokens. The zero value consults only ambient sources
+// (environment, gh, git and netrc) using the process environment.
+type Resolver struct {
+	// Token, TokenFile and TokenCmd are explicit settings, tried in that order.
+	// They apply only to Host.
+	Token     string
+	TokenFile string
+	TokenCmd  string
+
+	// Host scopes the explicit settings. When empty, DefaultHost is used.
+	Host string
+
+	// Getenv overrides os.Getenv; used by tests.
+	Getenv func(string) string
+}
+
+// Resolve returns the first credential for host, trying in order: the explicit token,
+// token file and token command (only when host matches Resolver.Host), GITHUB_TOKEN and
+// GH_TOKEN (only for github.com), the gh CLI's hosts.yml, `git credential fill`, and
+// ~/.netrc. A zero Credential with a nil error means no token was found.
+//
+// Misconfigured explicit settings (an unreadable token file or a failing token command)
+// are reported as errors; ambient sources that fail are skipped.
+func (r Resolver) Resolve(ctx context.Context, host string) (Credential, error) {
+	host = NormalizeHost(host)
+	if host == "" {
+		return Credential{}, errors.New("credentials: host is empty")
+	}
+
+	explicitHost := NormalizeHost(r.Host)
+	if explicitHost == "" {
+		explicitHost = DefaultHost
+	}
+
+	found := func(token, source string) (Credential, error) {
+		return Credential{Token: token, Host: host, Source: source}, nil
+	}
+
+	if host == explicitHost {
+		if t := strings.TrimSpace(r.Token); t != "" {
+			return found(t, SourceFlag)
+		}
+		if r.TokenFile != "" {
+			b, err := os.ReadFile(r.TokenFile)
+			if err != nil {
+				return Credential{}, fmt.Errorf("read token file: %w", err)
+			}
+			if t := strings.TrimSpace(string(b)); t != "" {
+				return found(t, SourceTokenFile)
+			}
+			return Credential{}, fmt.Errorf("token file %s is empty", r.TokenFile)
+		}
+		if r.TokenCmd != "" {
+			t, err := runTokenCmd(ctx, r.TokenCmd)
+			if err != nil {
+				return Credential{}, err
+			}
+			return found(t, SourceTokenCmd)
+		}
+	}
+
+	if host == DefaultHost {
+		if t := strings.TrimSpace(r.getenv("GITHUB_TOKEN")); t != "" {
+			return found(t, SourceGitHubToken)
+		}
+		if t := strings.TrimSpace(r.getenv("GH_TOKEN")); t != "" {
+			return found(t, SourceGHToken)
+		}
+	}
+
+	if t := r.fromGHHosts(host); t != "" {
+		return found(t, SourceGHHosts)
+	}
+	if t := r.fromGitCredential(ctx, host); t != "" {
+		return found(t, SourceGitCredential)
+	}
+	if t := r.fromNetrc(host); t != "" {
+		return found(t, SourceNetrc)
+	}
+
+	return Credential{}, nil
+}
+
+// NormalizeHost lower-cases host, strips any scheme and path, and maps the
+// GitHub API host onto github.com.
+func NormalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if _, rest, ok := strings.Cut(host, "://"); ok {
+		host = rest
+	}
+	host, _, _ = strings.Cut(host, "/")
+	if host == "api.github.com" {
+		return DefaultHost
+	}
+	return host
+}
+
+func (r Resolver) getenv(key string) string {
+	if r.Getenv != nil {
+		return r.Getenv(key)
+	}
+	return os.Getenv(key)
+}
+
+func (r Resolver) homeDir() string {
+	if h := r.getenv("HOME"); h != "" {
+		return h
+	}
+	h, _ := os.UserHomeDir()
+	return h
+}
+
+func runTokenCmd(ctx context.Context, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("token command failed: %w; stderr=%s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	t := strings.TrimSpace(string(out))
+	if t == "" {
+		return "", errors.New("token command printed no token")
+	}
+	return t, nil
+}
+
+// fromGHHosts reads oauth_token for host from the gh CLI configuration.
+// Tokens kept in the system keyring by newer gh versions are not visible here.
+func (r Resolver) fromGHHosts(host string) string {
+	dir := r.getenv("GH_CONFIG_DIR")
+	if dir == "" {
+		if xdg := r.getenv("XDG_CONFIG_HOME"); xdg != "" {
+			dir = filepath.Join(xdg, "gh")
+		} else {
+			dir = filepath.Join(r.homeDir(), ".config", "gh")
+		}
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "hosts.yml"))
+	if err != nil {
+		return ""
+	}
+
+	var hosts map[string]struct {
+		OAuthToken string `yaml:"oauth_token"`
+	}
+	if err := yaml.Unmarshal(b, &hosts); err != nil {
+		return ""
+	}
+	for h, v := range hosts {
+		if NormalizeHost(h) == host {
+			return strings.TrimSpace(v.OAuthToken)
+		}
+	}
+	return ""
+}
+
+// fromGitCredential asks the configured git credential helpers for host without
+// prompting, neither on the terminal nor in a dialog of Git Credential Manager.
+func (r Resolver) fromGitCredential(ctx context.Context, host string) string {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "credential", "fill")
+	cmd.Stdin = strings.NewReader("protocol=https\nhost=" + host + "\n\n")
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=", "GCM_INTERACTIVE=never")
+
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		if v, ok := strings.CutPrefix(sc.Text(), "password="); ok {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// fromNetrc returns the password of the netrc entry for host. The "default" entry
+// is ignored because it is not scoped to a host. $NETRC overrides the file location.
+func (r Resolver) fromNetrc(host string) string {
+	path := r.getenv("NETRC")
+	if path == "" {
+		path = filepath.Join(r.homeDir(), ".netrc")
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return parseNetrc(string(b), host)
+}
+
+func parseNetrc(data, host string) string {
+	// Macro definitions run until an empty line; drop them before tokenizing.
+	var kept []string
+	inMacdef := false
+	for _, line := range strings.Split(data, "\n") {
+		if inMacdef {
+			if strings.TrimSpace(line) == "" {
+				inMacdef = false
+			}
+			continue
+		}
+		if f := strings.Fields(line); len(f) > 0 && f[0] == "macdef" {
+			inMacdef = true
+			continue
+		}
+		kept = append(kept, line)
+	}
+	fields := strings.Fields(strings.Join(kept, "\n"))
+
+	current := "" // machine of the entry being read
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "machine":
+			current = ""
+			if i+1 < len(fields) {
+				i++
+				current = NormalizeHost(fields[i])
+			}
+		case "default":
+			current = ""
+		case "password":
+			if i+1 < len(fields) {
+				i++
+				if current == host {
+					return fields[i]
+				}
+			}
+		case "login", "account", "port":
+			i++
+		}
+	}
+	return ""
+}
@@ -0,0 +1,169 @@
+package credentials
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// isolate points HOME and PATH at empty temp dirs so ambient configuration on the
+// test machine cannot leak into results, and installs a fake git that answers
+// `git credential fill` for git.example.com only, and only when told not to prompt.
+func isolate(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	bin := t.TempDir()
+	git := `#!/bin/sh
+[ "$GIT_TERMINAL_PROMPT" = 0 ] && [ "$GCM_INTERACTIVE" = never ] || exit 1
+input=$(cat)
+case "$input" in
+  *host=git.example.com*) printf 'protocol=https\nhost=git.example.com\nusername=x\npassword=from-git\n' ;;
+  *) exit 1 ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(bin, "git"), []byte(git), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", home)
+	t.Setenv("PATH", bin+":/bin:/usr/bin")
+	for _, k := range []string{"GITHUB_TOKEN", "GH_TOKEN", "GH_CONFIG_DIR", "XDG_CONFIG_HOME", "NETRC"} {
+		t.Setenv(k, "")
+	}
+	return home
+}
+
+func resolve(t *testing.T, r Resolver, host string) Credential {
+	t.Helper()
+	c, err := r.Resolve(context.Background(), host)
+	if err != nil {
+		t.Fatalf("Resolve(%q): %v", host, err)
+	}
+	return c
+}
+
+func TestResolve_Order(t *testing.T) {
+	home := isolate(t)
+
+	if c := resolve(t, Resolver{}, "github.com"); c.Token != "" {
+		t.Fatalf("expected no token, got %+v", c)
+	}
+
+	netrc := "machine github.com login me password from-netrc\nmachine other.example password other\n"
+	if err := os.WriteFile(filepath.Join(home, ".netrc"), []byte(netrc), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if c := resolve(t, Resolver{}, "api.github.com"); c.Token != "from-netrc" || c.Source != SourceNetrc || c.Host != "github.com" {
+		t.Fatalf("got %+v; want netrc", c)
+	}
+
+	ghDir := filepath.Join(home, ".config", "gh")
+	if err := os.MkdirAll(ghDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	hosts := "github.com:\n    oauth_token: from-gh\n    user: me\n"
+	if err := os.WriteFile(filepath.Join(ghDir, "hosts.yml"), []byte(hosts), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if c := resolve(t, Resolver{}, "github.com"); c.Token != "from-gh" || c.Source != SourceGHHosts {
+		t.Fatalf("got %+v; want gh hosts.yml", c)
+	}
+
+	t.Setenv("GH_TOKEN", "from-gh-env")
+	if c := resolve(t, Resolver{}, "github.com"); c.Source != SourceGHToken {
+		t.Fatalf("got %+v; want GH_TOKEN", c)
+	}
+
+	t.Setenv("GITHUB_TOKEN", "from-github-env")
+	if c := resolve(t, Resolver{}, "github.com"); c.Source != SourceGitHubToken {
+		t.Fatalf("got %+v; want GITHUB_TOKEN", c)
+	}
+
+	if c := resolve(t, Resolver{TokenCmd: "echo from-cmd"}, "github.com"); c.Token != "from-cmd" || c.Source != SourceTokenCmd {
+		t.Fatalf("got %+v; want token command", c)
+	}
+
+	file := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(file, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if c := resolve(t, Resolver{TokenFile: file, TokenCmd: "echo from-cmd"}, "github.com"); c.Token != "from-file" || c.Source != SourceTokenFile {
+		t.Fatalf("got %+v; want token file", c)
+	}
+
+	if c := resolve(t, Resolver{Token: "from-flag", TokenFile: file}, "github.com"); c.Token != "from-flag" || c.Source != SourceFlag {
+		t.Fatalf("got %+v; want flag", c)
+	}
+}
+
+func TestResolve_HostScoping(t *testing.T) {
+	home := isolate(t)
+	t.Setenv("GITHUB_TOKEN", "gh-secret")
+	t.Setenv("GH_TOKEN", "gh-secret-2")
+	if err := os.WriteFile(filepath.Join(home, ".netrc"), []byte("default login x password leaked\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r := Resolver{Token: "flag-secret", TokenCmd: "echo cmd-secret"}
+	if c := resolve(t, r, "mirror.internal"); c.Token != "" {
+		t.Fatalf("token for github.com leaked to another host: %+v", c)
+	}
+
+	r.Host = "mirror.internal"
+	if c := resolve(t, r, "https://mirror.internal/path"); c.Token != "flag-secret" {
+		t.Fatalf("got %+v; want explicit token scoped to mirror.internal", c)
+	}
+	if c := resolve(t, r, "github.com"); c.Token != "gh-secret" || c.Source != SourceGitHubToken {
+		t.Fatalf("explicit token for mirror.internal should not apply to github.com: %+v", c)
+	}
+
+	if c := resolve(t, Resolver{}, "git.example.com"); c.Token != "from-git" || c.Source != SourceGitCredential {
+		t.Fatalf("got %+v; want git credential", c)
+	}
+}
+
+func TestResolve_ExplicitFailures(t *testing.T) {
+	isolate(t)
+	ctx := context.Background()
+
+	if _, err := (Resolver{TokenFile: filepath.Join(t.TempDir(), "missing")}).Resolve(ctx, "github.com"); err == nil {
+		t.Fatalf("expected missing token file to fail")
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty")
+	if err := os.WriteFile(empty, []byte("\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (Resolver{TokenFile: empty}).Resolve(ctx, "github.com"); err == nil || !strings.Contains(err.Error(), "empty") {
+		t.Fatalf("err=%v; want empty token file error", err)
+	}
+
+	if _, err := (Resolver{TokenCmd: "echo oops >&2; exit 3"}).Resolve(ctx, "github.com"); err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Fatalf("err=%v; want token command failure with stderr", err)
+	}
+	if _, err := (Resolver{TokenCmd: "true"}).Resolve(ctx, "github.com"); err == nil {
+		t.Fatalf("expected silent token command to fail")
+	}
+	if _, err := (Resolver{}).Resolve(ctx, " "); err == nil {
+		t.Fatalf("expected empty host to fail")
+	}
+}
+
+func TestParseNetrc(t *testing.T) {
+	data := `machine a.example login u password pa
+macdef init
+  machine b.example password evil
+
+machine b.example
+  login u
+  password pb
+default login d password pd
+`
+	cases := map[string]string{"a.example": "pa", "b.example": "pb", "c.example": ""}
+	for host, want := range cases {
+		if got := parseNetrc(data, host); got != want {
+			t.Fatalf("parseNetrc(%q)=%q; want %q", host, got, want)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+// Package credentials resolves API tokens for a specific host from the sources a
+// user is likely to have configured: explicit flags, token files and commands,
+// environment variables, the gh CLI's hosts.yml, git credential helpers and ~/.netrc.
+//
+// Every credential is scoped to a host, so a token configured for one server is
+// never returned for another.
+package credentials
@@ -0,0 +1,106 @@
+package ghrel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// APIBaseURL is the default GitHub REST API endpoint.
+const APIBaseURL = "https://api.github.com"
+
+// WorkflowRun models the fields of a GitHub Actions workflow run used to locate
+// CI build artifacts.
+type WorkflowRun struct {
+	ID         int64     `json:"id"`
+	RunNumber  int64     `json:"run_number"`
+	Name       string    `json:"name"`
+	HeadBranch string    `json:"head_branch"`
+	HeadSHA    string    `json:"head_sha"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Artifact models a GitHub Actions artifact attached to a workflow run.
+type Artifact struct {
+	ID                 int64  `json:"id"`
+	Name               string `json:"name"`
+	SizeInBytes        int64  `json:"size_in_bytes"`
+	ArchiveDownloadURL string `json:"archive_download_url"`
+	Expired            bool   `json:"expired"`
+}
+
+// ListSuccessfulRuns returns the most recent successful workflow runs for owner/repo,
+// newest first. If branch is non-empty, only runs for that branch are returned.
+func ListSuccessfulRuns(
+	ctx context.Context,
+	client *http.Client,
+	apiBaseURL, owner, repo, branch, githubToken string,
+	limit int,
+) ([]WorkflowRun, error) {
+	q := url.Values{}
+	q.Set("status", "success")
+	q.Set("per_page", fmt.Sprint(limit))
+	if branch != "" {
+		q.Set("branch", branch)
+	}
+	apiURL := fmt.Sprintf("%s/repos/%s/%s/actions/runs?%s", strings.TrimRight(apiBaseURL, "/"), owner, repo, q.Encode())
+
+	var body struct {
+		WorkflowRuns []WorkflowRun `json:"workflow_runs"`
+	}
+	if err := getJSON(ctx, client, apiURL, githubToken, "list workflow runs", &body); err != nil {
+		return nil, err
+	}
+	return body.WorkflowRuns, nil
+}
+
+// ListRunArtifacts returns the artifacts uploaded by workflow run runID.
+func ListRunArtifacts(
+	ctx context.Context,
+	client *http.Client,
+	apiBaseURL, owner, repo string,
+	runID int64,
+	githubToken string,
+) ([]Artifact, error) {
+	apiURL := fmt.Sprintf("%s/repos/%s/%s/actions/runs/%d/artifacts", strings.TrimRight(apiBaseURL, "/"), owner, repo, runID)
+
+	var body struct {
+		Artifacts []Artifact `json:"artifacts"`
+	}
+	if err := getJSON(ctx, client, apiURL, githubToken, "list run artifacts", &body); err != nil {
+		return nil, err
+	}
+	return body.Artifacts, nil
+}
+
+// getJSON performs an authenticated GitHub API GET and decodes the response into v.
+func getJSON(ctx context.Context, client *http.Client, apiURL, githubToken, op string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if githubToken != "" {
+		req.Header.Set("Authorization", "Bearer "+githubToken)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return NewStatusError(op, resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("%s: decode JSON: %w", op, err)
+	}
+	return nil
+}
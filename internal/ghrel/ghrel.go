@@ -4,28 +4,88 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
+
+	"automelonloaderinstallergo/internal/redact"
 )
 
+// ErrNotFound is wrapped by errors reporting a missing repository, release, tag
+// or asset. The releases package re-exports it as releases.ErrNotFound.
+var ErrNotFound = errors.New("not found")
+
+// StatusError reports a non-200 HTTP response.
+type StatusError struct {
+	// Op describes the operation that failed, e.g. "download asset".
+	Op string
+
+	StatusCode int
+	Status     string
+
+	// Body holds up to 64 KiB of the response body. It is redacted when formatted
+	// by Error but kept verbatim here.
+	Body string
+
+	// RateLimited is set when the server signalled that the request quota is exhausted.
+	RateLimited bool
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: status=%s body=%s", e.Op, e.Status, redact.String(e.Body))
+}
+
+// NewStatusError builds a StatusError from resp, consuming up to 64 KiB of its body.
+func NewStatusError(op string, resp *http.Response) *StatusError {
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	return &StatusError{
+		Op:         op,
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       string(b),
+		RateLimited: resp.StatusCode == http.StatusTooManyRequests ||
+			(resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0"),
+	}
+}
+
 // releaseByTagResponse models only the fields of the
 // GET /repos/{owner}/{repo}/releases/tags/{tag} response
-// required to identify and locate release assets by name.
+// required to describe a release and locate its assets by name.
 type releaseByTagResponse struct {
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	Body        string    `json:"body"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
+	HTMLURL     string    `json:"html_url"`
+
 	Assets []struct {
 		// Name is the filename of the release asset.
 		Name string `json:"name"`
 
 		// BrowserDownloadURL is the public URL for downloading the asset.
 		BrowserDownloadURL string `json:"browser_download_url"`
+
+		// Size is the asset size in bytes.
+		Size int64 `json:"size"`
+
+		// DownloadCount is how many times the asset has been downloaded.
+		DownloadCount int64 `json:"download_count"`
+
+		// Digest is "sha256:<hex>" for assets uploaded since GitHub began recording
+		// digests, and empty otherwise.
+		Digest string `json:"digest"`
 	} `json:"assets"`
 }
 
@@ -42,7 +102,41 @@ func GetReleaseByTag(
 	client *http.Client,
 	owner, repo, tag, githubToken string,
 ) (releaseByTagResponse, error) {
-	return getReleaseByTagFromBaseURL(ctx, client, "https://api.github.com", owner, repo, tag, githubToken)
+	return getReleaseByTagFromBaseURL(ctx, client, APIBaseURL, owner, repo, tag, githubToken)
+}
+
+// ReleaseSummary models the fields of a GET /repos/{owner}/{repo}/releases entry
+// used to date and classify tags.
+type ReleaseSummary struct {
+	TagName     string    `json:"tag_name"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// ListReleases returns the releases of owner/repo, newest first, reading at most
+// maxPages pages of 100 releases.
+func ListReleases(
+	ctx context.Context,
+	client *http.Client,
+	apiBaseURL, owner, repo, githubToken string,
+	maxPages int,
+) ([]ReleaseSummary, error) {
+	const perPage = 100
+	var out []ReleaseSummary
+	for page := 1; page <= maxPages; page++ {
+		apiURL := fmt.Sprintf("%s/repos/%s/%s/releases?per_page=%d&page=%d",
+			strings.TrimRight(apiBaseURL, "/"), owner, repo, perPage, page)
+		var batch []ReleaseSummary
+		if err := getJSON(ctx, client, apiURL, githubToken, "list releases", &batch); err != nil {
+			return nil, err
+		}
+		out = append(out, batch...)
+		if len(batch) < perPage {
+			break
+		}
+	}
+	return out, nil
 }
 
 // FindAssetDownloadURL returns the browser_download_url for an asset with the given name.
@@ -55,7 +149,7 @@ func FindAssetDownloadURL(rel releaseByTagResponse, assetName string) (string, e
 			return a.BrowserDownloadURL, nil
 		}
 	}
-	return "", fmt.Errorf("asset %q not found", assetName)
+	return "", fmt.Errorf("asset %q %w", assetName, ErrNotFound)
 }
 
 // DownloadToWriter streams the content at downloadURL into w.
@@ -82,8 +176,7 @@ func DownloadToWriter(
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		b, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
-		return fmt.Errorf("download asset: status=%s body=%s", resp.Status, string(b))
+		return NewStatusError("download asset", resp)
 	}
 
 	if _, err := io.Copy(w, resp.Body); err != nil {
@@ -150,7 +243,7 @@ func DownloadReleaseAssetByTag(
 	githubToken string,
 ) error {
 	client := NewGitHubClient()
-	return downloadReleaseAssetByTagWithClient(ctx, client, "https://api.github.com", owner, repo, tag, assetName, outPath, githubToken)
+	return downloadReleaseAssetByTagWithClient(ctx, client, APIBaseURL, owner, repo, tag, assetName, outPath, githubToken)
 }
 
 // downloadReleaseAssetByTagWithClient is an internal seam that performs the full operation
@@ -173,6 +266,32 @@ func downloadReleaseAssetByTagWithClient(
 		outPath = assetName
 	}
 
+	return WriteFileAtomically(outPath, func(f *os.File) error {
+		return writeReleaseAssetByTagWithClient(ctx, client, apiBaseURL, owner, repo, tag, assetName, f, githubToken)
+	})
+}
+
+// WriteReleaseAssetByTag streams a specific asset from a GitHub release tag to w.
+// Authentication and redirects behave as in DownloadReleaseAssetByTag.
+func WriteReleaseAssetByTag(
+	ctx context.Context,
+	owner, repo, tag, assetName string,
+	w io.Writer,
+	githubToken string,
+) error {
+	client := NewGitHubClient()
+	return writeReleaseAssetByTagWithClient(ctx, client, APIBaseURL, owner, repo, tag, assetName, w, githubToken)
+}
+
+// writeReleaseAssetByTagWithClient resolves the asset's download URL and streams it to w.
+func writeReleaseAssetByTagWithClient(
+	ctx context.Context,
+	client *http.Client,
+	apiBaseURL string,
+	owner, repo, tag, assetName string,
+	w io.Writer,
+	githubToken string,
+) error {
 	rel, err := getReleaseByTagFromBaseURL(ctx, client, apiBaseURL, owner, repo, tag, githubToken)
 	if err != nil {
 		return err
@@ -183,9 +302,7 @@ func downloadReleaseAssetByTagWithClient(
 		return fmt.Errorf("resolve asset URL: %w", err)
 	}
 
-	return WriteFileAtomically(outPath, func(f *os.File) error {
-		return DownloadToWriter(ctx, client, downloadURL, githubToken, f)
-	})
+	return DownloadToWriter(ctx, client, downloadURL, githubToken, w)
 }
 
 // getReleaseByTagFromBaseURL fetches release metadata for a specific tag from a configurable base URL.
@@ -215,8 +332,7 @@ func getReleaseByTagFromBaseURL(
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		b, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
-		return rel, fmt.Errorf("fetch release metadata: status=%s body=%s", resp.Status, string(b))
+		return rel, NewStatusError("fetch release metadata", resp)
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
@@ -233,14 +349,31 @@ func GitRemoteURL(owner, repo string) string {
 	return fmt.Sprintf("https://github.com/%s/%s.git", owner, repo)
 }
 
+// GitSSHRemoteURL returns the SSH Git remote URL for owner/repo,
+// e.g. "git@github.com:owner/repo.git".
+func GitSSHRemoteURL(owner, repo string) string {
+	owner = strings.TrimSpace(owner)
+	repo = strings.TrimSpace(repo)
+	return fmt.Sprintf("git@github.com:%s/%s.git", owner, repo)
+}
+
 // GetTagsViaGit retrieves all tag names from a remote Git repository by executing:
 //
 //	git ls-remote --tags <remoteURL>
 //
 // Annotated tag dereferences ("^{}") are stripped; the resulting list is de-duplicated
 // and returned in sorted order.
-func GetTagsViaGit(ctx context.Context, remoteURL string) ([]string, error) {
+//
+// If githubToken is provided and remoteURL is an HTTP(S) URL, the token is supplied to
+// git as an http.extraHeader scoped to the remote's origin. It is passed through the
+// environment (GIT_CONFIG_COUNT/KEY/VALUE), never on the command line. SSH remotes
+// ignore the token and authenticate through the user's SSH setup.
+func GetTagsViaGit(ctx context.Context, remoteURL, githubToken string) ([]string, error) {
 	cmd := exec.CommandContext(ctx, "git", "ls-remote", "--tags", remoteURL)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if githubToken != "" {
+		cmd.Env = append(cmd.Env, gitAuthEnv(remoteURL, githubToken)...)
+	}
 
 	out, err := cmd.Output()
 	if err != nil {
@@ -282,3 +415,26 @@ func GetTagsViaGit(ctx context.Context, remoteURL string) ([]string, error) {
 
 	return tags, nil
 }
+
+// gitAuthEnv returns environment entries that make git send an Authorization header
+// for requests to remoteURL's origin only. The entry is appended after any the
+// environment already passes in GIT_CONFIG_COUNT. It returns nil for non-HTTP(S)
+// remotes.
+func gitAuthEnv(remoteURL, githubToken string) []string {
+	u, err := url.Parse(remoteURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil
+	}
+
+	n := 0
+	if v, err := strconv.Atoi(os.Getenv("GIT_CONFIG_COUNT")); err == nil && v > 0 {
+		n = v
+	}
+	origin := u.Scheme + "://" + u.Host + "/"
+	basic := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + githubToken))
+	return []string{
+		fmt.Sprintf("GIT_CONFIG_COUNT=%d", n+1),
+		fmt.Sprintf("GIT_CONFIG_KEY_%d=http.%s.extraHeader", n, origin),
+		fmt.Sprintf("GIT_CONFIG_VALUE_%d=Authorization: Basic %s", n, basic),
+	}
+}
@@ -0,0 +1,164 @@
+package ghrel
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeGit installs a git script on PATH that records its arguments and environment
+// in dir/args and dir/env, then prints stdout and exits with code.
+func fakeGit(t *testing.T, stdout string, code int) string {
+	t.Helper()
+	dir := t.TempDir()
+	script := "#!/bin/sh\n" +
+		"printf '%s\\n' \"$@\" > " + filepath.Join(dir, "args") + "\n" +
+		"env > " + filepath.Join(dir, "env") + "\n" +
+		"cat <<'EOF'\n" + stdout + "EOF\n" +
+		"echo 'fatal: simulated' >&2\n" +
+		"exit " + strconv.Itoa(code) + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "git"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+":/bin:/usr/bin")
+	return dir
+}
+
+func TestGetTagsViaGit_Parses(t *testing.T) {
+	out := "aaa\trefs/tags/v0.6.5\n" +
+		"bbb\trefs/tags/v0.6.5^{}\n" +
+		"ccc\trefs/tags/v0.5.7\n" +
+		"ddd\trefs/heads/main\n" +
+		"malformed\n"
+	fakeGit(t, out, 0)
+
+	tags, err := GetTagsViaGit(context.Background(), GitRemoteURL("o", "r"), "")
+	if err != nil {
+		t.Fatalf("GetTagsViaGit: %v", err)
+	}
+	if want := []string{"v0.5.7", "v0.6.5"}; !reflect.DeepEqual(tags, want) {
+		t.Fatalf("tags=%v; want %v", tags, want)
+	}
+}
+
+func TestGetTagsViaGit_Failure(t *testing.T) {
+	fakeGit(t, "", 2)
+
+	_, err := GetTagsViaGit(context.Background(), GitRemoteURL("o", "r"), "")
+	if err == nil || !strings.Contains(err.Error(), "fatal: simulated") {
+		t.Fatalf("err=%v; want git stderr in error", err)
+	}
+}
+
+func TestGetTagsViaGit_TokenViaEnvironment(t *testing.T) {
+	const token = "ghp_secretvalue"
+	dir := fakeGit(t, "", 0)
+
+	if _, err := GetTagsViaGit(context.Background(), GitRemoteURL("o", "private"), token); err != nil {
+		t.Fatalf("GetTagsViaGit: %v", err)
+	}
+
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	if strings.Contains(string(args), token) || strings.Contains(string(args), base64.StdEncoding.EncodeToString([]byte("x-access-token:"+token))) {
+		t.Fatalf("token leaked onto the command line: %q", args)
+	}
+	if got := strings.Fields(string(args)); !reflect.DeepEqual(got, []string{"ls-remote", "--tags", "https://github.com/o/private.git"}) {
+		t.Fatalf("args=%v", got)
+	}
+
+	env, _ := os.ReadFile(filepath.Join(dir, "env"))
+	basic := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+	for _, want := range []string{
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=http.https://github.com/.extraHeader",
+		"GIT_CONFIG_VALUE_0=Authorization: Basic " + basic,
+	} {
+		if !strings.Contains(string(env), want+"\n") {
+			t.Fatalf("env missing %q", want)
+		}
+	}
+}
+
+func TestGetTagsViaGit_TokenKeepsGitConfigEnv(t *testing.T) {
+	dir := fakeGit(t, "", 0)
+	t.Setenv("GIT_CONFIG_COUNT", "2")
+	t.Setenv("GIT_CONFIG_KEY_0", "user.name")
+	t.Setenv("GIT_CONFIG_VALUE_0", "me")
+
+	if _, err := GetTagsViaGit(context.Background(), GitRemoteURL("o", "private"), "ghp_secretvalue"); err != nil {
+		t.Fatalf("GetTagsViaGit: %v", err)
+	}
+
+	env, _ := os.ReadFile(filepath.Join(dir, "env"))
+	for _, want := range []string{
+		"GIT_CONFIG_COUNT=3",
+		"GIT_CONFIG_KEY_0=user.name",
+		"GIT_CONFIG_VALUE_0=me",
+		"GIT_CONFIG_KEY_2=http.https://github.com/.extraHeader",
+	} {
+		if !strings.Contains(string(env), want+"\n") {
+			t.Fatalf("env missing %q", want)
+		}
+	}
+	if strings.Count(string(env), "GIT_CONFIG_COUNT=") != 1 {
+		t.Fatalf("GIT_CONFIG_COUNT set twice:\n%s", env)
+	}
+}
+
+func TestGetTagsViaGit_SSHIgnoresToken(t *testing.T) {
+	dir := fakeGit(t, "", 0)
+
+	remote := GitSSHRemoteURL("o", "r")
+	if remote != "git@github.com:o/r.git" {
+		t.Fatalf("GitSSHRemoteURL=%q", remote)
+	}
+	if _, err := GetTagsViaGit(context.Background(), remote, "ghp_secret"); err != nil {
+		t.Fatalf("GetTagsViaGit: %v", err)
+	}
+
+	env, _ := os.ReadFile(filepath.Join(dir, "env"))
+	if strings.Contains(string(env), "GIT_CONFIG_COUNT") {
+		t.Fatalf("token must not be configured for SSH remotes")
+	}
+}
+
+func TestListReleases_Paginates(t *testing.T) {
+	var pages []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pages = append(pages, r.URL.Query().Get("page"))
+		if r.URL.Path != "/repos/o/r/releases" {
+			http.NotFound(w, r)
+			return
+		}
+		n := 100
+		if r.URL.Query().Get("page") == "2" {
+			n = 1
+		}
+		var rels []string
+		for i := 0; i < n; i++ {
+			rels = append(rels, fmt.Sprintf(`{"tag_name":"v0.%d","published_at":"2024-01-02T03:04:05Z"}`, i))
+		}
+		fmt.Fprint(w, "["+strings.Join(rels, ",")+"]")
+	}))
+	defer srv.Close()
+
+	rels, err := ListReleases(context.Background(), srv.Client(), srv.URL, "o", "r", "", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rels) != 101 || !reflect.DeepEqual(pages, []string{"1", "2"}) {
+		t.Fatalf("got %d releases from pages %v", len(rels), pages)
+	}
+	if rels[0].TagName != "v0.0" || rels[0].PublishedAt.Year() != 2024 {
+		t.Fatalf("rels[0]=%+v", rels[0])
+	}
+}
@@ -1,15 +1,24 @@
 package logger
 
 import (
+	"io"
 	"os"
 
+	"automelonloaderinstallergo/internal/redact"
+
 	"github.com/charmbracelet/log"
 )
 
 var Log *log.Logger
 
 func init() {
-	Log = log.NewWithOptions(os.Stderr, log.Options{
+	Log = New(os.Stderr)
+}
+
+// New returns a logger writing to w in the format of Log. Everything logged passes
+// through the redaction layer before reaching w.
+func New(w io.Writer) *log.Logger {
+	return log.NewWithOptions(redact.NewWriter(w), log.Options{
 		ReportCaller:    false,
 		ReportTimestamp: true,
 		Prefix:          "automelonlaoderinstallergo",
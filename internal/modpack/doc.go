@@ -0,0 +1,10 @@
+// Package modpack describes a game's MelonLoader setup as a shareable modpack and
+// recreates it in another game directory.
+//
+// A modpack lists the MelonLoader version and every mod, plugin and user library
+// with where it was installed from, its version and its SHA-256 digest, and may
+// carry the game's UserData config files. It is written as a YAML file, as a
+// self-contained zip that also holds the files themselves, or as a compact share
+// code for chat. Importing installs each file from the zip, its GitHub release or
+// its Thunderstore package, and checks it against the recorded digest.
+package modpack
@@ -0,0 +1,311 @@
+package modpack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"automelonloaderinstallergo/internal/ghrel"
+	"automelonloaderinstallergo/internal/mods"
+	"automelonloaderinstallergo/internal/modscan"
+	"automelonloaderinstallergo/internal/releases"
+	"automelonloaderinstallergo/internal/thunderstore"
+)
+
+// Import statuses.
+const (
+	ImportUnchanged = "unchanged"
+	ImportPending   = "pending"
+	ImportInstalled = "installed"
+	ImportFailed    = "failed"
+)
+
+// Where a file is installed from, in ModResult.From.
+const (
+	FromBundle       = "bundle"
+	FromRelease      = "release"
+	FromThunderstore = "thunderstore"
+	FromLocal        = "local"
+)
+
+// Result reports what Import did.
+type Result struct {
+	Mods []ModResult `json:"mods"`
+	// Configs are the UserData files written, relative to UserData.
+	Configs  []string `json:"configs,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Failed returns the number of mods that could not be imported.
+func (r Result) Failed() int {
+	n := 0
+	for _, m := range r.Mods {
+		if m.Status == ImportFailed {
+			n++
+		}
+	}
+	return n
+}
+
+// ModResult is the import state of one file of a pack.
+type ModResult struct {
+	Mod  Mod    `json:"mod"`
+	From string `json:"from,omitempty"`
+	// Status is one of ImportUnchanged, ImportPending (on a dry run),
+	// ImportInstalled or ImportFailed, with the failure in Error.
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Scan is the safety scan of the installed file, with Importer.Scan.
+	Scan *modscan.Report `json:"scan,omitempty"`
+}
+
+func (r *ModResult) fail(err error) {
+	r.Status, r.Error = ImportFailed, err.Error()
+}
+
+// Importer recreates packs in a game.
+type Importer struct {
+	// Source and Token download release assets, as for mods update.
+	Source releases.Source
+	Token  string
+	// Packages looks up and downloads Thunderstore packages, which are installed at
+	// exactly the pack's versions with their dependencies. Packs with files from
+	// packages need it.
+	Packages *thunderstore.Client
+	// Force installs files that fail mods.Target.Check.
+	Force bool
+	// Scan, if set, scans the files before they are installed; see
+	// mods.AddOptions.Scan.
+	Scan *modscan.Scanner
+	// DryRun only reports what would be installed.
+	DryRun bool
+}
+
+// Import installs the files of p that g lacks or has in another version, sets
+// their enabled state, and writes p's configs under UserData, replacing existing
+// files. Each file is taken from the pack's zip if it has them, else from its
+// release or Thunderstore package, and must match the pack's digest. Other files
+// of g are kept. Failures of single files are reported in the Result.
+func (im Importer) Import(ctx context.Context, g mods.Game, p Pack) (Result, error) {
+	res := Result{Mods: make([]ModResult, len(p.Mods))}
+	res.Warnings = compare(p, g.Target())
+
+	installed, err := g.List()
+	if err != nil {
+		return res, err
+	}
+	var packages []thunderstore.Ref
+	for i, m := range p.Mods {
+		r := &res.Mods[i]
+		r.Mod = m
+		if have, ok := find(installed, m); ok {
+			if sum, _, err := mods.HashFile(have.Path); err == nil && sum == m.SHA256 {
+				r.Status = ImportUnchanged
+				continue
+			}
+		}
+		r.Status = ImportPending
+		_, isRelease := mods.ReleaseOf(m.Source)
+		pkg, isPackage := mods.PackageOf(m.Source)
+		switch {
+		case p.Bundled(m):
+			r.From = FromBundle
+		case isRelease && im.Source != nil:
+			r.From = FromRelease
+		case isPackage && im.Packages != nil:
+			r.From = FromThunderstore
+			packages = appendRef(packages, pkg)
+		case m.Source != "" && !isRelease && !isPackage && strings.EqualFold(filepath.Base(m.Source), m.File) && exists(m.Source):
+			r.From = FromLocal
+		case m.Source == "":
+			r.fail(fmt.Errorf("%s was not installed by amlinstall; share the modpack as a zip to include it", m.File))
+		default:
+			r.fail(fmt.Errorf("%s cannot be installed from %s here; share the modpack as a zip to include it", m.File, m.Source))
+		}
+	}
+	if im.DryRun {
+		for _, c := range p.Configs {
+			res.Configs = append(res.Configs, c.Path)
+		}
+		return res, nil
+	}
+
+	if len(packages) > 0 {
+		err := im.installPackages(ctx, g, packages)
+		if err == nil {
+			installed, err = g.List()
+		}
+		if err != nil {
+			for i := range res.Mods {
+				if res.Mods[i].From == FromThunderstore {
+					res.Mods[i].fail(err)
+				}
+			}
+		}
+	}
+	for i := range res.Mods {
+		r := &res.Mods[i]
+		if r.Status != ImportPending {
+			continue
+		}
+		if err := im.install(ctx, g, p, installed, r); err != nil {
+			r.fail(err)
+			continue
+		}
+		r.Status = ImportInstalled
+	}
+
+	for i := range res.Mods {
+		r := &res.Mods[i]
+		if r.Status == ImportFailed {
+			continue
+		}
+		var err error
+		if r.Mod.Enabled {
+			_, err = g.Enable(r.Mod.File, r.Mod.Kind)
+		} else {
+			_, err = g.Disable(r.Mod.File, r.Mod.Kind)
+		}
+		if err != nil {
+			r.fail(err)
+		}
+	}
+
+	for _, c := range p.Configs {
+		b, err := c.Data()
+		if err != nil {
+			return res, err
+		}
+		dst := filepath.Join(g.Dir, "UserData", filepath.FromSlash(c.Path))
+		err = ghrel.WriteFileAtomically(dst, func(f *os.File) error {
+			_, err := f.Write(b)
+			return err
+		})
+		if err != nil {
+			return res, fmt.Errorf("write UserData/%s: %w", c.Path, err)
+		}
+		res.Configs = append(res.Configs, c.Path)
+	}
+	return res, nil
+}
+
+// installPackages installs the Thunderstore packages refs with their dependencies.
+func (im Importer) installPackages(ctx context.Context, g mods.Game, refs []thunderstore.Ref) error {
+	var roots []thunderstore.Node
+	for _, r := range refs {
+		n, err := im.Packages.Node(ctx, r)
+		if err != nil {
+			return err
+		}
+		roots = append(roots, n)
+	}
+	_, err := g.InstallPackages(ctx, im.Packages, roots, mods.AddOptions{Force: im.Force, Scan: im.Scan})
+	return err
+}
+
+// install installs the file of r from r.From. installed lists the files of g after
+// any Thunderstore packages were installed.
+func (im Importer) install(ctx context.Context, g mods.Game, p Pack, installed []mods.Mod, r *ModResult) error {
+	m := r.Mod
+	opts := mods.AddOptions{Replace: true, Force: im.Force, SHA256: m.SHA256, Source: m.Source, Scan: im.Scan}
+	if rel, ok := mods.ReleaseOf(m.Source); ok {
+		opts.Tag = rel.Tag
+	} else if pkg, ok := mods.PackageOf(m.Source); ok {
+		opts.Tag = pkg.Version
+	}
+
+	switch r.From {
+	case FromBundle:
+		tmp, err := os.MkdirTemp("", "amlinstall-modpack-*")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+		file := filepath.Join(tmp, m.File)
+		err = ghrel.WriteFileAtomically(file, func(f *os.File) error {
+			return p.copyMod(f, m)
+		})
+		if err != nil {
+			return fmt.Errorf("extract %s: %w", m.File, err)
+		}
+		if !m.Reproducible() {
+			from := p.archive
+			if from == "" {
+				from = p.dir
+			}
+			opts.Source = "modpack:" + filepath.Base(from)
+		}
+		added, err := g.Add(file, m.Kind, opts)
+		r.Scan = added.Scan
+		return err
+	case FromRelease:
+		rel, _ := mods.ReleaseOf(m.Source)
+		added, err := g.AddRelease(ctx, im.Source, im.Token, rel, m.Kind, m.File, opts)
+		r.Scan = added.Scan
+		return err
+	case FromLocal:
+		added, err := g.Add(m.Source, m.Kind, opts)
+		r.Scan = added.Scan
+		return err
+	case FromThunderstore:
+		// installPackages has installed it; check that the package holds this file.
+		have, ok := find(installed, m)
+		if !ok {
+			pkg, _ := mods.PackageOf(m.Source)
+			return fmt.Errorf("%s is not in package %s", m.File, pkg)
+		}
+		sum, _, err := mods.HashFile(have.Path)
+		if err != nil {
+			return err
+		}
+		if sum != m.SHA256 {
+			return fmt.Errorf("%s: sha256 mismatch: got %s, want %s", m.File, sum, m.SHA256)
+		}
+		return nil
+	}
+	return errors.New("no source to install from")
+}
+
+// compare warns about differences between the game and loader p was exported
+// from and target.
+func compare(p Pack, target mods.Target) []string {
+	var warnings []string
+	if p.Game.Name != "" && target.Game.Name != "" && p.Game != target.Game {
+		warnings = append(warnings, fmt.Sprintf("the modpack is for %s, not %s", p.Game, target.Game))
+	}
+	switch {
+	case p.MelonLoader == "":
+	case target.Loader == "":
+		warnings = append(warnings, fmt.Sprintf("MelonLoader is not installed; the modpack uses MelonLoader %s", p.MelonLoader))
+	case target.Loader != p.MelonLoader:
+		warnings = append(warnings, fmt.Sprintf("the modpack uses MelonLoader %s, %s is installed", p.MelonLoader, target.Loader))
+	}
+	return warnings
+}
+
+// find returns the installed file of m, enabled or disabled.
+func find(installed []mods.Mod, m Mod) (mods.Mod, bool) {
+	for _, have := range installed {
+		if have.Kind == m.Kind && strings.EqualFold(have.Name, m.File) {
+			return have, true
+		}
+	}
+	return mods.Mod{}, false
+}
+
+func exists(name string) bool {
+	fi, err := os.Stat(name)
+	return err == nil && fi.Mode().IsRegular()
+}
+
+func appendRef(refs []thunderstore.Ref, r thunderstore.Ref) []thunderstore.Ref {
+	for _, x := range refs {
+		if x == r {
+			return refs
+		}
+	}
+	return append(refs, r)
+}
@@ -0,0 +1,517 @@
+package modpack
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"automelonloaderinstallergo/internal/mods"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaVersion is the Pack schema written by this version of the tool.
+const SchemaVersion = 1
+
+// FileName is the pack description at the root of a modpack zip.
+const FileName = "modpack.yaml"
+
+// ShareCodePrefix starts every share code, and names its format version.
+const ShareCodePrefix = "AML1-"
+
+// maxFile caps the size of a file read from a modpack zip.
+const maxFile = 512 << 20
+
+// maxShareCode caps the decompressed size of a share code, which holds no files.
+const maxShareCode = 1 << 20
+
+var sha256Re = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Pack is a reproducible description of a game's MelonLoader setup.
+type Pack struct {
+	Schema int    `yaml:"schema" json:"schema"`
+	Name   string `yaml:"name,omitempty" json:"name,omitempty"`
+	// Game is the game from <Game>_Data/app.info, and MelonLoader the installed
+	// loader version, of the game the pack was exported from.
+	Game        mods.GameRef `yaml:"game,omitempty" json:"game,omitempty"`
+	MelonLoader string       `yaml:"melonloader,omitempty" json:"melonloader,omitempty"`
+	Mods        []Mod        `yaml:"mods" json:"mods"`
+	Configs     []Config     `yaml:"configs,omitempty" json:"configs,omitempty"`
+
+	// archive is the zip the pack was read from, or dir the game it was exported
+	// from; either holds the files of Mods.
+	archive string
+	dir     string
+}
+
+// Mod is a file in one of a game's MelonLoader folders.
+type Mod struct {
+	Kind mods.Kind `yaml:"kind" json:"kind"`
+	// File is the file name, e.g. "CoolMod.dll".
+	File string `yaml:"file" json:"file"`
+	// Name and Version are from the melon's MelonInfo attribute, for reference.
+	Name    string `yaml:"name,omitempty" json:"name,omitempty"`
+	Version string `yaml:"version,omitempty" json:"version,omitempty"`
+	Enabled bool   `yaml:"enabled" json:"enabled"`
+	// Source is where the file was installed from, as recorded in the install
+	// manifest: "owner/repo@tag/asset" for a release asset,
+	// "thunderstore:Namespace-Name-1.2.3" for a package, or a local path. It is
+	// empty for files the tool did not install.
+	Source string `yaml:"source,omitempty" json:"source,omitempty"`
+	SHA256 string `yaml:"sha256" json:"sha256"`
+	Size   int64  `yaml:"size" json:"size"`
+}
+
+// Reproducible reports whether m can be installed on another machine without
+// the file itself: it came from a release asset or a Thunderstore package.
+func (m Mod) Reproducible() bool {
+	_, rel := mods.ReleaseOf(m.Source)
+	_, pkg := mods.PackageOf(m.Source)
+	return rel || pkg
+}
+
+// path returns the slash-separated path of m in a modpack zip or game directory.
+func (m Mod) path() string {
+	return m.Kind.Dir() + "/" + m.File
+}
+
+// Config is a file under the game's UserData folder.
+type Config struct {
+	// Path is relative to UserData and slash-separated.
+	Path   string `yaml:"path" json:"path"`
+	SHA256 string `yaml:"sha256" json:"sha256"`
+	// Content is the file's text, or its base64 when Encoding is "base64".
+	Content  string `yaml:"content" json:"content"`
+	Encoding string `yaml:"encoding,omitempty" json:"encoding,omitempty"`
+}
+
+// Data returns the file's contents.
+func (c Config) Data() ([]byte, error) {
+	if c.Encoding == "base64" {
+		return base64.StdEncoding.DecodeString(c.Content)
+	}
+	return []byte(c.Content), nil
+}
+
+func newConfig(p string, b []byte) Config {
+	c := Config{Path: p, SHA256: sum(b)}
+	if utf8.Valid(b) && !bytes.ContainsRune(b, 0) {
+		c.Content = string(b)
+	} else {
+		c.Content, c.Encoding = base64.StdEncoding.EncodeToString(b), "base64"
+	}
+	return c
+}
+
+// ExportOptions control Export.
+type ExportOptions struct {
+	Name string
+	// Configs includes the files under the game's UserData folder.
+	Configs bool
+}
+
+// Export describes the mods of g, and with opts.Configs its UserData files.
+func Export(g mods.Game, opts ExportOptions) (Pack, error) {
+	p := Pack{Schema: SchemaVersion, Name: opts.Name, Mods: []Mod{}, dir: g.Dir}
+	t := g.Target()
+	p.Game, p.MelonLoader = t.Game, t.Loader
+
+	man, err := mods.LoadManifest(g.Dir)
+	if err != nil {
+		return p, err
+	}
+	all, err := g.List()
+	if err != nil {
+		return p, err
+	}
+	for _, m := range all {
+		sum, size, err := mods.HashFile(m.Path)
+		if err != nil {
+			return p, err
+		}
+		pm := Mod{Kind: m.Kind, File: m.Name, Enabled: m.Enabled, SHA256: sum, Size: size}
+		if m.Info != nil {
+			pm.Name, pm.Version = m.Info.Name, m.Info.Version
+		}
+		if e, ok := man.Entry(m.Kind, m.Name); ok && e.SHA256 == sum {
+			pm.Source = e.Source
+		}
+		p.Mods = append(p.Mods, pm)
+	}
+
+	if opts.Configs {
+		root := filepath.Join(g.Dir, "UserData")
+		err := filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) && name == root {
+				return fs.SkipDir
+			}
+			if err != nil || !d.Type().IsRegular() {
+				return err
+			}
+			b, err := os.ReadFile(name)
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(root, name)
+			p.Configs = append(p.Configs, newConfig(filepath.ToSlash(rel), b))
+			return nil
+		})
+		if err != nil {
+			return p, fmt.Errorf("read UserData: %w", err)
+		}
+	}
+	p.sort()
+	return p, nil
+}
+
+// sort orders p for reproducible output.
+func (p *Pack) sort() {
+	sort.SliceStable(p.Mods, func(i, j int) bool {
+		if p.Mods[i].Kind != p.Mods[j].Kind {
+			return p.Mods[i].Kind < p.Mods[j].Kind
+		}
+		return strings.ToLower(p.Mods[i].File) < strings.ToLower(p.Mods[j].File)
+	})
+	sort.Slice(p.Configs, func(i, j int) bool { return p.Configs[i].Path < p.Configs[j].Path })
+}
+
+func (p *Pack) validate() error {
+	if p.Schema > SchemaVersion {
+		return fmt.Errorf("modpack schema %d is newer than supported (%d)", p.Schema, SchemaVersion)
+	}
+	for i := range p.Mods {
+		m := &p.Mods[i]
+		k, err := mods.ParseKind(string(m.Kind))
+		if err != nil {
+			return fmt.Errorf("mods[%d]: %w", i, err)
+		}
+		m.Kind = k
+		if m.File == "" || m.File == "." || m.File == ".." || strings.ContainsAny(m.File, `/\`) {
+			return fmt.Errorf("mods[%d]: file %q is not a file name", i, m.File)
+		}
+		if !sha256Re.MatchString(m.SHA256) {
+			return fmt.Errorf("mods[%d]: %s: sha256 %q is not a hex SHA-256 digest", i, m.File, m.SHA256)
+		}
+	}
+	for i, c := range p.Configs {
+		if !filepath.IsLocal(filepath.FromSlash(c.Path)) || strings.Contains(c.Path, `\`) {
+			return fmt.Errorf("configs[%d]: unsafe path %q", i, c.Path)
+		}
+		if c.Encoding != "" && c.Encoding != "base64" {
+			return fmt.Errorf("configs[%d]: %s: unknown encoding %q", i, c.Path, c.Encoding)
+		}
+		b, err := c.Data()
+		if err != nil {
+			return fmt.Errorf("configs[%d]: %s: %w", i, c.Path, err)
+		}
+		if got := sum(b); got != c.SHA256 {
+			return fmt.Errorf("configs[%d]: %s: sha256 mismatch: got %s, want %s", i, c.Path, got, c.SHA256)
+		}
+	}
+	return nil
+}
+
+// WriteYAML writes p as YAML, with its configs inline.
+func (p Pack) WriteYAML(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(p); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// zipTime is the modification time of every file in a modpack zip, so that the
+// same pack always yields the same bytes.
+var zipTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// WriteZip writes p as a self-contained zip: FileName, the mod files under their
+// folders and the configs under UserData/. p must come from Export or Read.
+func (p Pack) WriteZip(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	create := func(name string) (io.Writer, error) {
+		return zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: zipTime})
+	}
+
+	desc := p
+	desc.Configs = make([]Config, len(p.Configs))
+	for i, c := range p.Configs {
+		desc.Configs[i] = Config{Path: c.Path, SHA256: c.SHA256}
+	}
+	fw, err := create(FileName)
+	if err != nil {
+		return err
+	}
+	if err := desc.WriteYAML(fw); err != nil {
+		return err
+	}
+
+	for _, m := range p.Mods {
+		fw, err := create(m.path())
+		if err != nil {
+			return err
+		}
+		if err := p.copyMod(fw, m); err != nil {
+			return err
+		}
+	}
+	for _, c := range p.Configs {
+		b, err := c.Data()
+		if err != nil {
+			return err
+		}
+		fw, err := create("UserData/" + c.Path)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(b); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// copyMod writes the file of m from the game or zip p came from.
+func (p Pack) copyMod(w io.Writer, m Mod) error {
+	rc, err := p.openMod(m)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	n, err := io.Copy(w, io.LimitReader(rc, maxFile+1))
+	if err == nil && n > maxFile {
+		err = fmt.Errorf("%s is larger than %d MiB", m.File, maxFile>>20)
+	}
+	return err
+}
+
+// openMod opens the file of m in the game or zip p came from.
+func (p Pack) openMod(m Mod) (io.ReadCloser, error) {
+	switch {
+	case p.dir != "":
+		dir := m.Kind.Dir()
+		if !m.Enabled {
+			dir = m.Kind.DisabledDir()
+		}
+		return os.Open(filepath.Join(p.dir, dir, m.File))
+	case p.archive != "":
+		zr, err := zip.OpenReader(p.archive)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range zr.File {
+			if f.Name == m.path() {
+				rc, err := f.Open()
+				if err != nil {
+					zr.Close()
+					return nil, err
+				}
+				return readCloser{rc, zr}, nil
+			}
+		}
+		zr.Close()
+		return nil, fmt.Errorf("%s is not in %s", m.path(), filepath.Base(p.archive))
+	}
+	return nil, fmt.Errorf("the modpack does not include %s", m.File)
+}
+
+// Bundled reports whether p carries the file of m itself.
+func (p Pack) Bundled(m Mod) bool {
+	rc, err := p.openMod(m)
+	if err != nil {
+		return false
+	}
+	rc.Close()
+	return true
+}
+
+type readCloser struct {
+	io.ReadCloser
+	zr *zip.ReadCloser
+}
+
+func (r readCloser) Close() error {
+	r.ReadCloser.Close()
+	return r.zr.Close()
+}
+
+// Read reads the YAML or zip modpack at name.
+func Read(name string) (Pack, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return Pack{}, fmt.Errorf("read modpack: %w", err)
+	}
+	defer f.Close()
+	magic := make([]byte, 4)
+	n, err := io.ReadFull(f, magic)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return Pack{}, fmt.Errorf("read modpack: %w", err)
+	}
+	if !bytes.Equal(magic[:n], []byte("PK\x03\x04")) {
+		b, err := readAll(io.MultiReader(bytes.NewReader(magic[:n]), f), maxFile)
+		if err != nil {
+			return Pack{}, fmt.Errorf("read modpack: %w", err)
+		}
+		p, err := decode(b)
+		if err != nil {
+			return Pack{}, err
+		}
+		if err := p.check(); err != nil {
+			return Pack{}, err
+		}
+		return p, nil
+	}
+
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return Pack{}, fmt.Errorf("read modpack: %w", err)
+	}
+	defer zr.Close()
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	desc, err := readZipFile(files[FileName], FileName)
+	if err != nil {
+		return Pack{}, err
+	}
+	p, err := decode(desc)
+	if err != nil {
+		return Pack{}, err
+	}
+	// Configs are stored as files, not inline.
+	for i, c := range p.Configs {
+		zn := "UserData/" + c.Path
+		b, err := readZipFile(files[zn], zn)
+		if err != nil {
+			return Pack{}, err
+		}
+		inline := newConfig(c.Path, b)
+		p.Configs[i].Content, p.Configs[i].Encoding = inline.Content, inline.Encoding
+	}
+	p.archive = name
+	if err := p.check(); err != nil {
+		return Pack{}, err
+	}
+	return p, nil
+}
+
+func readZipFile(f *zip.File, name string) ([]byte, error) {
+	if f == nil {
+		return nil, fmt.Errorf("modpack zip has no %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", name, err)
+	}
+	defer rc.Close()
+	b, err := readAll(rc, maxFile)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", name, err)
+	}
+	return b, nil
+}
+
+// readAll reads r to the end, failing if it holds more than limit bytes.
+func readAll(r io.Reader, limit int64) ([]byte, error) {
+	b, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err == nil && int64(len(b)) > limit {
+		err = fmt.Errorf("larger than %d MiB", limit>>20)
+	}
+	return b, err
+}
+
+func decode(b []byte) (Pack, error) {
+	var p Pack
+	if err := yaml.Unmarshal(b, &p); err != nil {
+		return Pack{}, fmt.Errorf("parse modpack: %w", err)
+	}
+	if p.Schema == 0 {
+		return Pack{}, errors.New("parse modpack: no schema; not a modpack")
+	}
+	return p, nil
+}
+
+// check validates and sorts a pack read from a file.
+func (p *Pack) check() error {
+	if err := p.validate(); err != nil {
+		return fmt.Errorf("modpack: %w", err)
+	}
+	p.sort()
+	return nil
+}
+
+// ShareCode returns p without its configs as a compact string to paste into chat:
+// ShareCodePrefix followed by its compressed JSON in URL-safe base64.
+func (p Pack) ShareCode() (string, error) {
+	p.Configs = nil
+	b, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	fw, _ := flate.NewWriter(&buf, flate.BestCompression)
+	if _, err := fw.Write(b); err != nil {
+		return "", err
+	}
+	if err := fw.Close(); err != nil {
+		return "", err
+	}
+	return ShareCodePrefix + base64.RawURLEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// ParseShareCode decodes a share code from ShareCode. Whitespace, such as line
+// breaks added by chat clients, is ignored.
+func ParseShareCode(code string) (Pack, error) {
+	code = strings.Join(strings.Fields(code), "")
+	data, ok := strings.CutPrefix(code, ShareCodePrefix)
+	if !ok {
+		return Pack{}, fmt.Errorf("share code does not start with %s", ShareCodePrefix)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(data)
+	if err != nil {
+		return Pack{}, fmt.Errorf("share code: %w", err)
+	}
+	b, err := readAll(flate.NewReader(bytes.NewReader(raw)), maxShareCode)
+	if err != nil {
+		return Pack{}, fmt.Errorf("share code: %w", err)
+	}
+	var p Pack
+	if err := json.Unmarshal(b, &p); err != nil {
+		return Pack{}, fmt.Errorf("share code: %w", err)
+	}
+	if err := p.validate(); err != nil {
+		return Pack{}, fmt.Errorf("share code: %w", err)
+	}
+	p.sort()
+	return p, nil
+}
+
+// Load reads the modpack file at arg, or decodes arg as a share code when it is
+// not a file.
+func Load(arg string) (Pack, error) {
+	if _, err := os.Stat(arg); err != nil && strings.HasPrefix(strings.TrimSpace(arg), ShareCodePrefix) {
+		return ParseShareCode(arg)
+	}
+	return Read(arg)
+}
+
+func sum(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
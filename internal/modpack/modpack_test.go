@@ -0,0 +1,279 @@
+package modpack
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"automelonloaderinstallergo/internal/mods"
+)
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func fixture(t *testing.T, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("..", "clrmeta", "testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// newGame returns a BONELAB directory with MelonLoader 0.6.1 installed.
+func newGame(t *testing.T) mods.Game {
+	t.Helper()
+	g := mods.Game{Dir: t.TempDir()}
+	write(t, filepath.Join(g.Dir, "BONELAB_Data", "app.info"), "Stress Level Zero\nBONELAB")
+	write(t, filepath.Join(g.Dir, "MelonLoader", "net6", "MelonLoader.dll"), fixture(t, "MelonLoader.dll"))
+	return g
+}
+
+// fakeSource serves release assets that are clrmeta fixtures of the same name.
+type fakeSource struct{ t *testing.T }
+
+func (s fakeSource) ListTags(ctx context.Context, owner, repo, token string) ([]string, error) {
+	return []string{"v1.2.3"}, nil
+}
+
+func (s fakeSource) DownloadAsset(ctx context.Context, owner, repo, tag, asset, outPath, token string) error {
+	return os.WriteFile(outPath, []byte(fixture(s.t, asset)), 0o644)
+}
+
+// setup returns a game with a mod from a release, a disabled plugin added from a
+// local file, an untracked user library and two configs.
+func setup(t *testing.T) mods.Game {
+	t.Helper()
+	g := newGame(t)
+	rel := mods.Release{Repo: "jane/coolmod", Tag: "v1.2.3", Asset: "CoolMod.dll"}
+	if _, err := g.AddRelease(context.Background(), fakeSource{t}, "", rel, mods.KindMod, "CoolMod.dll", mods.AddOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(t.TempDir(), "LegacyPlugin.dll")
+	write(t, src, fixture(t, "LegacyPlugin.dll"))
+	if _, err := g.Add(src, mods.KindPlugin, mods.AddOptions{Force: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Disable("LegacyPlugin.dll", mods.KindPlugin); err != nil {
+		t.Fatal(err)
+	}
+	write(t, filepath.Join(g.Dir, "UserLibs", "Helper.dll"), "helper")
+	write(t, filepath.Join(g.Dir, "UserData", "CoolMod", "settings.cfg"), "volume = 3\n")
+	write(t, filepath.Join(g.Dir, "UserData", "cache.bin"), "\x00\x01\x02")
+	return g
+}
+
+func TestExport(t *testing.T) {
+	g := setup(t)
+	p, err := Export(g, ExportOptions{Name: "Team", Configs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Game != (mods.GameRef{Developer: "Stress Level Zero", Name: "BONELAB"}) || p.MelonLoader != "0.6.1" {
+		t.Errorf("game %+v, loader %q", p.Game, p.MelonLoader)
+	}
+	var got []string
+	for _, m := range p.Mods {
+		got = append(got, string(m.Kind)+":"+m.File+":"+m.Version)
+	}
+	if want := []string{"mod:CoolMod.dll:1.2.3", "plugin:LegacyPlugin.dll:2.0.1-beta", "userlib:Helper.dll:"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mods %v, want %v", got, want)
+	}
+	if m := p.Mods[0]; m.Source != "jane/coolmod@v1.2.3/CoolMod.dll" || !m.Enabled || !m.Reproducible() || len(m.SHA256) != 64 {
+		t.Errorf("release mod %+v", m)
+	}
+	if m := p.Mods[1]; m.Enabled || m.Reproducible() || !strings.HasSuffix(m.Source, "LegacyPlugin.dll") {
+		t.Errorf("local plugin %+v", m)
+	}
+	if m := p.Mods[2]; m.Source != "" {
+		t.Errorf("untracked library has source %q", m.Source)
+	}
+	if len(p.Configs) != 2 || p.Configs[0].Path != "CoolMod/settings.cfg" || p.Configs[0].Content != "volume = 3\n" ||
+		p.Configs[1].Path != "cache.bin" || p.Configs[1].Encoding != "base64" {
+		t.Errorf("configs %+v", p.Configs)
+	}
+
+	// YAML and zip modpacks and share codes read back the same.
+	dir := t.TempDir()
+	var yml bytes.Buffer
+	if err := p.WriteYAML(&yml); err != nil {
+		t.Fatal(err)
+	}
+	write(t, filepath.Join(dir, "pack.yaml"), yml.String())
+	var zip1, zip2 bytes.Buffer
+	if err := p.WriteZip(&zip1); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.WriteZip(&zip2); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(zip1.Bytes(), zip2.Bytes()) {
+		t.Error("zip modpack is not reproducible")
+	}
+	write(t, filepath.Join(dir, "pack.zip"), zip1.String())
+
+	for _, name := range []string{"pack.yaml", "pack.zip"} {
+		r, err := Load(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(r.Mods, p.Mods) || !reflect.DeepEqual(r.Configs, p.Configs) {
+			t.Errorf("%s read back as %+v", name, r)
+		}
+	}
+
+	code, err := p.ShareCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := Load(code[:20] + "\n" + code[20:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r.Mods, p.Mods) || r.Configs != nil || r.Name != "Team" {
+		t.Errorf("share code read back as %+v", r)
+	}
+}
+
+func TestImport(t *testing.T) {
+	src := setup(t)
+	p, err := Export(src, ExportOptions{Configs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	if err := p.WriteYAML(&buf); err != nil {
+		t.Fatal(err)
+	}
+	write(t, filepath.Join(dir, "pack.yaml"), buf.String())
+	buf.Reset()
+	if err := p.WriteZip(&buf); err != nil {
+		t.Fatal(err)
+	}
+	write(t, filepath.Join(dir, "pack.zip"), buf.String())
+
+	ctx := context.Background()
+	im := Importer{Source: fakeSource{t}, Force: true}
+	status := func(res Result) []string {
+		var out []string
+		for _, r := range res.Mods {
+			out = append(out, r.Mod.File+":"+r.From+":"+r.Status)
+		}
+		return out
+	}
+
+	// From YAML, the untracked library cannot be installed.
+	g := newGame(t)
+	yml, err := Read(filepath.Join(dir, "pack.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dry := im
+	dry.DryRun = true
+	res, err := dry.Import(ctx, g, yml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"CoolMod.dll:release:pending", "LegacyPlugin.dll:local:pending", "Helper.dll::failed"}
+	if got := status(res); !reflect.DeepEqual(got, want) {
+		t.Errorf("dry run %v, want %v", got, want)
+	}
+	if all, _ := g.List(); len(all) != 0 {
+		t.Errorf("dry run installed %d files", len(all))
+	}
+
+	res, err = im.Import(ctx, g, yml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"CoolMod.dll:release:installed", "LegacyPlugin.dll:local:installed", "Helper.dll::failed"}
+	if got := status(res); !reflect.DeepEqual(got, want) || res.Failed() != 1 {
+		t.Errorf("import %v, want %v", got, want)
+	}
+	if m, err := g.Find("LegacyPlugin.dll", mods.KindPlugin); err != nil || m.Enabled {
+		t.Errorf("plugin %+v, %v; want it disabled", m, err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(g.Dir, "UserData", "cache.bin")); string(b) != "\x00\x01\x02" {
+		t.Errorf("binary config %q", b)
+	}
+	man, err := mods.LoadManifest(g.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := man.Entry(mods.KindMod, "CoolMod.dll"); e.Source != "jane/coolmod@v1.2.3/CoolMod.dll" || e.Tag != "v1.2.3" {
+		t.Errorf("manifest entry %+v", e)
+	}
+
+	// A zip carries every file; a second import changes nothing.
+	g = newGame(t)
+	write(t, filepath.Join(g.Dir, "Mods", "CoolMod.dll"), "an older build")
+	zp, err := Read(filepath.Join(dir, "pack.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	im.Source = nil
+	if res, err = im.Import(ctx, g, zp); err != nil || res.Failed() != 0 {
+		t.Fatalf("zip import %v, %v", status(res), err)
+	}
+	if res, err = im.Import(ctx, g, zp); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"CoolMod.dll::unchanged", "LegacyPlugin.dll::unchanged", "Helper.dll::unchanged"}
+	if got := status(res); !reflect.DeepEqual(got, want) {
+		t.Errorf("second import %v, want %v", got, want)
+	}
+
+	// Another game and loader version are warned about.
+	write(t, filepath.Join(g.Dir, "BONELAB_Data", "app.info"), "Kinetic Games\nPhasmophobia")
+	os.RemoveAll(filepath.Join(g.Dir, "MelonLoader"))
+	res, _ = im.Import(ctx, g, zp)
+	if len(res.Warnings) != 2 || !strings.Contains(res.Warnings[0], "not Phasmophobia") || !strings.Contains(res.Warnings[1], "not installed") {
+		t.Errorf("warnings %q", res.Warnings)
+	}
+}
+
+func TestReadRejects(t *testing.T) {
+	dir := t.TempDir()
+	sha := strings.Repeat("a", 64)
+	for name, content := range map[string]string{
+		"newer.yaml":  "schema: 2\nmods: []\n",
+		"empty.yaml":  "mods: []\n",
+		"kind.yaml":   "schema: 1\nmods:\n  - {kind: texture, file: a.dll, sha256: " + sha + "}\n",
+		"path.yaml":   "schema: 1\nmods:\n  - {kind: mod, file: ../a.dll, sha256: " + sha + "}\n",
+		"sha.yaml":    "schema: 1\nmods:\n  - {kind: mod, file: a.dll, sha256: abc}\n",
+		"config.yaml": "schema: 1\nmods: []\nconfigs:\n  - {path: a.cfg, sha256: " + sha + ", content: x}\n",
+		"escape.yaml": "schema: 1\nmods: []\nconfigs:\n  - {path: ../a.cfg, sha256: " + sha + ", content: x}\n",
+	} {
+		write(t, filepath.Join(dir, name), content)
+		if _, err := Read(filepath.Join(dir, name)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+	if _, err := ParseShareCode(ShareCodePrefix + "!!!"); err == nil {
+		t.Error("bad share code: no error")
+	}
+
+	// A share code inflating past maxShareCode is refused, not truncated.
+	var buf bytes.Buffer
+	fw, _ := flate.NewWriter(&buf, flate.BestCompression)
+	fw.Write([]byte(`{"schema":1,"mods":[]}` + strings.Repeat(" ", maxShareCode)))
+	fw.Close()
+	code := ShareCodePrefix + base64.RawURLEncoding.EncodeToString(buf.Bytes())
+	if _, err := ParseShareCode(code); err == nil || !strings.Contains(err.Error(), "larger than 1 MiB") {
+		t.Errorf("oversized share code: %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package mods
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"automelonloaderinstallergo/internal/clrmeta"
+	"automelonloaderinstallergo/internal/version"
+)
+
+// ErrIncompatible reports a melon built for another game or MelonLoader version.
+var ErrIncompatible = errors.New("incompatible")
+
+// Target is what a game's melons run against. Fields are empty when unknown.
+type Target struct {
+	// Game is the developer and product name from <Game>_Data/app.info.
+	Game GameRef `json:"game"`
+	// Loader is the installed MelonLoader version, e.g. "0.6.1".
+	Loader string `json:"loader,omitempty"`
+}
+
+// loaderPaths are where MelonLoader installs its assembly: per runtime since 0.6,
+// directly under MelonLoader/ before.
+var loaderPaths = []string{
+	filepath.Join("MelonLoader", "net6", "MelonLoader.dll"),
+	filepath.Join("MelonLoader", "net35", "MelonLoader.dll"),
+	filepath.Join("MelonLoader", "MelonLoader.dll"),
+}
+
+// Target reads the game's identity and installed MelonLoader version. Missing or
+// unreadable files leave the corresponding field empty, which disables that check.
+func (g Game) Target() Target {
+	var t Target
+	datas, _ := filepath.Glob(filepath.Join(g.Dir, "*_Data", "app.info"))
+	for _, path := range datas {
+		if ref, err := readAppInfo(path); err == nil {
+			t.Game = ref
+			break
+		}
+	}
+	for _, p := range loaderPaths {
+		a, err := clrmeta.ReadFile(filepath.Join(g.Dir, p))
+		if err == nil && a.Version != "" {
+			t.Loader = strings.TrimSuffix(a.Version, ".0")
+			break
+		}
+	}
+	return t
+}
+
+// readAppInfo reads the company and product name Unity writes to the first two
+// lines of app.info.
+func readAppInfo(path string) (GameRef, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return GameRef{}, err
+	}
+	defer f.Close()
+	var lines []string
+	sc := bufio.NewScanner(f)
+	for len(lines) < 2 && sc.Scan() {
+		lines = append(lines, strings.TrimSpace(sc.Text()))
+	}
+	if err := sc.Err(); err != nil {
+		return GameRef{}, fmt.Errorf("read %s: %w", path, err)
+	}
+	if len(lines) < 2 || lines[0] == "" || lines[1] == "" {
+		return GameRef{}, fmt.Errorf("%s: want company and product name lines", path)
+	}
+	return GameRef{Developer: lines[0], Name: lines[1]}, nil
+}
+
+// Check returns why MelonLoader would refuse to load a melon with info into the
+// target, or nil. Like MelonLoader, it accepts melons declaring no game or a
+// universal MelonGame entry.
+func (t Target) Check(info *Info) []string {
+	if info == nil {
+		return nil
+	}
+	var problems []string
+	if t.Game.Name != "" && len(info.Games) > 0 && !t.supports(info.Games) {
+		var names []string
+		for _, g := range info.Games {
+			names = append(names, g.String())
+		}
+		problems = append(problems, fmt.Sprintf("built for %s, not %s", strings.Join(names, ", "), t.Game))
+	}
+	if t.Loader != "" && info.Loader != nil {
+		if msg := t.checkLoader(*info.Loader); msg != "" {
+			problems = append(problems, msg)
+		}
+	}
+	return problems
+}
+
+func (t Target) supports(games []GameRef) bool {
+	for _, g := range games {
+		if g.Universal() || (g.Developer == t.Game.Developer && g.Name == t.Game.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t Target) checkLoader(req LoaderReq) string {
+	want, err := version.Parse(req.Version)
+	if err != nil {
+		return ""
+	}
+	have, err := version.Parse(t.Loader)
+	if err != nil {
+		return ""
+	}
+	switch c := version.Compare(have, want); {
+	case req.Minimum && c < 0:
+		return fmt.Sprintf("needs MelonLoader %s or later, %s is installed", req.Version, t.Loader)
+	case !req.Minimum && c != 0:
+		return fmt.Sprintf("needs MelonLoader %s, %s is installed", req.Version, t.Loader)
+	}
+	return ""
+}
+
+// Universal reports whether g matches every game, as MelonLoader treats a
+// MelonGame entry with a missing developer or name.
+func (g GameRef) Universal() bool {
+	return g.Developer == "" || g.Name == "" || g.Developer == "UNKNOWN" || g.Name == "UNKNOWN"
+}
+
+// String returns e.g. "BONELAB (Stress Level Zero)".
+func (g GameRef) String() string {
+	if g.Universal() {
+		return "any game"
+	}
+	return fmt.Sprintf("%s (%s)", g.Name, g.Developer)
+}
@@ -0,0 +1,8 @@
+// Package mods manages the MelonLoader mods, plugins and user libraries of a game
+// installation: listing them, adding and removing files, and disabling files by
+// moving them under Mods/Disabled where MelonLoader does not load them.
+//
+// Every change is recorded in the game's install manifest
+// (.amlinstall/manifest.json), which tracks the files the tool installed and keeps
+// a history of changes.
+package mods
@@ -0,0 +1,188 @@
+package mods
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"automelonloaderinstallergo/internal/clrmeta"
+)
+
+// Conflict kinds.
+const (
+	// ConflictDuplicate is the same assembly, name, version and public key token,
+	// in more than one file.
+	ConflictDuplicate = "duplicate"
+	// ConflictVersion is an assembly name defined by files of different versions
+	// or public key tokens.
+	ConflictVersion = "version"
+	// ConflictLoader is a file holding a library MelonLoader provides itself, such
+	// as 0Harmony or Il2CppInterop.
+	ConflictLoader = "loader"
+)
+
+// loaderLibDirs are where MelonLoader keeps the libraries it provides to melons:
+// per runtime since 0.6, directly under MelonLoader/ before.
+var loaderLibDirs = []string{
+	filepath.Join("MelonLoader", "net6"),
+	filepath.Join("MelonLoader", "net35"),
+	"MelonLoader",
+}
+
+// Conflict is an assembly name defined by more than one file the game loads. The
+// runtime loads only one assembly of a name, so which copy a melon gets depends on
+// load order.
+type Conflict struct {
+	Kind     string `json:"kind"`
+	Assembly string `json:"assembly"`
+	// Copies are the files defining the assembly: MelonLoader's first, then the
+	// game's in List order.
+	Copies []Copy `json:"copies"`
+}
+
+// Copy is one file of a Conflict.
+type Copy struct {
+	// Path is relative to the game directory, with forward slashes.
+	Path string `json:"path"`
+	// Kind is empty for MelonLoader's own copies.
+	Kind           Kind   `json:"kind,omitempty"`
+	Name           string `json:"name"`
+	Version        string `json:"version"`
+	PublicKeyToken string `json:"public_key_token,omitempty"`
+}
+
+// Loader reports whether c is one of MelonLoader's own files.
+func (c Copy) Loader() bool { return c.Kind == "" }
+
+// Conflicts finds the assemblies defined by more than one enabled file in Mods,
+// Plugins and UserLibs, and those MelonLoader provides that such a file defines
+// again. Files that are not .NET assemblies or cannot be read are ignored, as are
+// disabled files, which MelonLoader does not load.
+func (g Game) Conflicts() ([]Conflict, error) {
+	all, err := g.List()
+	if err != nil {
+		return nil, err
+	}
+	byName := map[string][]Copy{}
+	var names []string
+	add := func(c Copy, a clrmeta.Assembly) {
+		c.Name, c.Version, c.PublicKeyToken = filepath.Base(c.Path), a.Version, a.PublicKeyToken
+		key := strings.ToLower(a.Name)
+		if _, ok := byName[key]; !ok {
+			names = append(names, a.Name)
+		}
+		byName[key] = append(byName[key], c)
+	}
+
+	for _, dir := range loaderLibDirs {
+		entries, err := os.ReadDir(filepath.Join(g.Dir, dir))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("list %s: %w", dir, err)
+		}
+		for _, e := range entries {
+			if !e.Type().IsRegular() || !strings.EqualFold(filepath.Ext(e.Name()), ".dll") {
+				continue
+			}
+			path := filepath.Join(dir, e.Name())
+			if a, err := clrmeta.ReadFile(filepath.Join(g.Dir, path)); err == nil {
+				add(Copy{Path: filepath.ToSlash(path)}, a)
+			}
+		}
+	}
+	for _, m := range all {
+		if !m.Enabled {
+			continue
+		}
+		if a, err := clrmeta.ReadFile(m.Path); err == nil {
+			add(Copy{Path: filepath.ToSlash(m.relPath(g.Dir)), Kind: m.Kind}, a)
+		}
+	}
+
+	var out []Conflict
+	for _, name := range names {
+		copies := byName[strings.ToLower(name)]
+		var loader, game int
+		for _, c := range copies {
+			if c.Loader() {
+				loader++
+			} else {
+				game++
+			}
+		}
+		c := Conflict{Assembly: name, Copies: copies}
+		switch {
+		case loader > 0 && game > 0:
+			c.Kind = ConflictLoader
+		case game < 2:
+			continue
+		default:
+			c.Kind = ConflictDuplicate
+			for _, cp := range copies[1:] {
+				if cp.Version != copies[0].Version || cp.PublicKeyToken != copies[0].PublicKeyToken {
+					c.Kind = ConflictVersion
+				}
+			}
+		}
+		out = append(out, c)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return strings.ToLower(out[i].Assembly) < strings.ToLower(out[j].Assembly)
+	})
+	return out, nil
+}
+
+// Keep resolves the conflict holding the file at path, relative to the game
+// directory as in Copy.Path, or with that file name if only one conflicting file
+// has it: every other copy in Mods, Plugins and UserLibs is disabled.
+// MelonLoader's copies are never changed, so for a ConflictLoader only one of them
+// can be kept.
+func (g Game) Keep(path string) ([]Mod, error) {
+	conflicts, err := g.Conflicts()
+	if err != nil {
+		return nil, err
+	}
+	want := filepath.ToSlash(filepath.Clean(path))
+	var matches []Copy
+	var in []Conflict
+	for _, c := range conflicts {
+		for _, cp := range c.Copies {
+			if strings.EqualFold(cp.Path, want) || strings.EqualFold(cp.Name, want) {
+				matches = append(matches, cp)
+				in = append(in, c)
+			}
+		}
+	}
+	switch {
+	case len(matches) == 0:
+		return nil, fmt.Errorf("%q %w among the conflicting files of %s", path, ErrNotFound, g.Dir)
+	case len(matches) > 1:
+		var where []string
+		for _, m := range matches {
+			where = append(where, m.Path)
+		}
+		return nil, fmt.Errorf("%q is ambiguous (%s); pass the path", path, strings.Join(where, ", "))
+	}
+	keep, c := matches[0], in[0]
+	if c.Kind == ConflictLoader && !keep.Loader() {
+		return nil, fmt.Errorf("MelonLoader always loads its own %s; keep %s instead", c.Assembly, c.Copies[0].Path)
+	}
+
+	var disabled []Mod
+	for _, cp := range c.Copies {
+		if cp.Loader() || cp.Path == keep.Path {
+			continue
+		}
+		m, err := g.Disable(cp.Name, cp.Kind)
+		if err != nil {
+			return disabled, err
+		}
+		disabled = append(disabled, m)
+	}
+	return disabled, nil
+}
@@ -0,0 +1,146 @@
+package mods
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"automelonloaderinstallergo/internal/ghrel"
+)
+
+// ManifestSchemaVersion is the Manifest schema written by this version of the tool.
+const ManifestSchemaVersion = 1
+
+// ManifestPath is the install manifest's path relative to the game directory.
+var ManifestPath = filepath.Join(".amlinstall", "manifest.json")
+
+// Manifest records the files installed into a game and every change made to them.
+type Manifest struct {
+	Schema int     `json:"schema"`
+	Files  []Entry `json:"files"`
+	// PackageFiles are the files Thunderstore packages installed besides mods,
+	// plugins and user libraries, such as configs under UserData.
+	PackageFiles []PackageFile `json:"package_files,omitempty"`
+	History      []Event       `json:"history"`
+}
+
+// Entry describes a file installed by the tool.
+type Entry struct {
+	Kind        Kind      `json:"kind"`
+	Name        string    `json:"name"`
+	Enabled     bool      `json:"enabled"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	InstalledAt time.Time `json:"installed_at"`
+	// Source is where the file came from, e.g. a local path or
+	// "owner/repo@tag/asset" for a release asset.
+	Source string `json:"source,omitempty"`
+	// Tag is the release tag the file was installed from, if any.
+	Tag string `json:"tag,omitempty"`
+}
+
+// PackageFile is a file installed from a Thunderstore package that is not a
+// mod, plugin or user library.
+type PackageFile struct {
+	// Path is relative to the game directory, with forward slashes.
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	// Source names the package, as in Entry.Source.
+	Source string `json:"source"`
+}
+
+// Actions recorded in Event.Action.
+const (
+	ActionAdd     = "add"
+	ActionRemove  = "remove"
+	ActionEnable  = "enable"
+	ActionDisable = "disable"
+	ActionUpdate  = "update"
+)
+
+// Event is one change in a Manifest's history. Kind is empty for a PackageFile,
+// which Name gives the Path of.
+type Event struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	Kind   Kind      `json:"kind"`
+	Name   string    `json:"name"`
+}
+
+// LoadManifest reads the install manifest of the game in dir. A missing manifest
+// yields an empty one.
+func LoadManifest(dir string) (Manifest, error) {
+	m := Manifest{Schema: ManifestSchemaVersion}
+	b, err := os.ReadFile(filepath.Join(dir, ManifestPath))
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return m, fmt.Errorf("read manifest: %w", err)
+	}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return m, fmt.Errorf("parse manifest %s: %w", filepath.Join(dir, ManifestPath), err)
+	}
+	if m.Schema > ManifestSchemaVersion {
+		return m, fmt.Errorf("manifest schema %d is newer than supported (%d)", m.Schema, ManifestSchemaVersion)
+	}
+	return m, nil
+}
+
+// save writes the manifest of the game in dir atomically.
+func (m Manifest) save(dir string) error {
+	m.Schema = ManifestSchemaVersion
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode manifest: %w", err)
+	}
+	return ghrel.WriteFileAtomically(filepath.Join(dir, ManifestPath), func(f *os.File) error {
+		_, err := f.Write(append(b, '\n'))
+		return err
+	})
+}
+
+// Entry returns the entry for the file name of kind, if the manifest tracks it.
+func (m Manifest) Entry(kind Kind, name string) (Entry, bool) {
+	if i := m.index(kind, name); i >= 0 {
+		return m.Files[i], true
+	}
+	return Entry{}, false
+}
+
+func (m Manifest) index(kind Kind, name string) int {
+	for i, e := range m.Files {
+		if e.Kind == kind && strings.EqualFold(e.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// record applies a change to the tracked files and appends it to the history.
+// For ActionAdd and ActionUpdate, e is the new entry; otherwise only its Kind and Name are used.
+func (m *Manifest) record(action string, e Entry, now time.Time) {
+	i := m.index(e.Kind, e.Name)
+	switch action {
+	case ActionAdd, ActionUpdate:
+		if i >= 0 {
+			m.Files[i] = e
+		} else {
+			m.Files = append(m.Files, e)
+		}
+	case ActionRemove:
+		if i >= 0 {
+			m.Files = append(m.Files[:i], m.Files[i+1:]...)
+		}
+	case ActionEnable, ActionDisable:
+		if i >= 0 {
+			m.Files[i].Enabled = action == ActionEnable
+		}
+	}
+	m.History = append(m.History, Event{Time: now, Action: action, Kind: e.Kind, Name: e.Name})
+}
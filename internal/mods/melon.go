@@ -0,0 +1,192 @@
+package mods
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"automelonloaderinstallergo/internal/clrmeta"
+)
+
+// Info is the MelonLoader metadata declared by a mod or plugin assembly.
+type Info struct {
+	// Name, Version, Author and DownloadLink come from MelonInfoAttribute.
+	Name         string `json:"name"`
+	Version      string `json:"version"`
+	Author       string `json:"author,omitempty"`
+	DownloadLink string `json:"download_link,omitempty"`
+	// Type is the melon's entry type, e.g. "CoolMod.Main".
+	Type string `json:"type,omitempty"`
+	// Games lists the games the melon declares support for with MelonGameAttribute;
+	// it is empty when it declares none.
+	Games []GameRef `json:"games,omitempty"`
+	// Color is the MelonColorAttribute as "#rrggbb", or a ConsoleColor name for the
+	// older form of the attribute.
+	Color string `json:"color,omitempty"`
+	// Loader is the MelonLoader version required with VerifyLoaderVersionAttribute,
+	// or nil.
+	Loader *LoaderReq `json:"loader,omitempty"`
+}
+
+// LoaderReq is a VerifyLoaderVersionAttribute.
+type LoaderReq struct {
+	Version string `json:"version"`
+	// Minimum accepts later versions too; otherwise the version must match exactly.
+	Minimum bool `json:"minimum,omitempty"`
+}
+
+// GameRef is one MelonGameAttribute. Empty fields match any developer or game.
+type GameRef struct {
+	Developer string `json:"developer,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+// Attribute type names, with the names used before MelonLoader 0.3 as fallbacks.
+var (
+	infoAttrs   = []string{"MelonLoader.MelonInfoAttribute", "MelonLoader.MelonModInfoAttribute", "MelonLoader.MelonPluginInfoAttribute"}
+	gameAttrs   = []string{"MelonLoader.MelonGameAttribute", "MelonLoader.MelonModGameAttribute", "MelonLoader.MelonPluginGameAttribute"}
+	colorAttrs  = []string{"MelonLoader.MelonColorAttribute"}
+	loaderAttrs = []string{"MelonLoader.VerifyLoaderVersionAttribute"}
+)
+
+// consoleColors names System.ConsoleColor values.
+var consoleColors = []string{
+	"Black", "DarkBlue", "DarkGreen", "DarkCyan", "DarkRed", "DarkMagenta", "DarkYellow", "Gray",
+	"DarkGray", "Blue", "Green", "Cyan", "Red", "Magenta", "Yellow", "White",
+}
+
+// ReadInfo reads the MelonLoader metadata of the assembly at path. It returns nil
+// without an error for files that are not .NET assemblies, such as native
+// libraries, and for assemblies without a MelonInfo attribute, such as libraries.
+func ReadInfo(path string) (*Info, error) {
+	a, err := clrmeta.ReadFile(path)
+	if errors.Is(err, clrmeta.ErrNotAssembly) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return infoFromAssembly(a)
+}
+
+func infoFromAssembly(a clrmeta.Assembly) (*Info, error) {
+	infos := attrs(a, infoAttrs)
+	if len(infos) == 0 {
+		return nil, nil
+	}
+	at := infos[0]
+	if at.Err != nil {
+		return nil, fmt.Errorf("MelonInfo: %w", at.Err)
+	}
+
+	info := &Info{}
+	args := at.Args
+	info.Type = argString(args, 0)
+	info.Name = argString(args, 1)
+	if len(args) > 2 {
+		if _, ok := args[2].(string); ok || args[2] == nil {
+			// (type, name, version, author[, downloadLink])
+			info.Version = argString(args, 2)
+			info.Author = argString(args, 3)
+			info.DownloadLink = argString(args, 4)
+		} else {
+			// (type, name, major, minor, patch[, identifier], author[, downloadLink]),
+			// formatted as MelonLoader does: the identifier is appended as is.
+			info.Version = fmt.Sprintf("%v.%v.%v", args[2], argAt(args, 3), argAt(args, 4))
+			rest := args[min(5, len(args)):]
+			if len(rest) == 3 {
+				info.Version += argString(rest, 0)
+				rest = rest[1:]
+			}
+			info.Author = argString(rest, 0)
+			info.DownloadLink = argString(rest, 1)
+		}
+	}
+	if info.Name == "" {
+		info.Name = a.Name
+	}
+
+	for _, g := range attrs(a, gameAttrs) {
+		if g.Err == nil {
+			info.Games = append(info.Games, GameRef{Developer: argString(g.Args, 0), Name: argString(g.Args, 1)})
+		}
+	}
+
+	if cs := attrs(a, colorAttrs); len(cs) > 0 && cs[0].Err == nil {
+		info.Color = colorString(cs[0].Args)
+	}
+
+	if ls := attrs(a, loaderAttrs); len(ls) > 0 && ls[0].Err == nil {
+		info.Loader = loaderReq(ls[0].Args)
+	}
+	return info, nil
+}
+
+// loaderReq reads VerifyLoaderVersion's (version[, isMinimum]) and
+// (major, minor, patch[, revision][, isMinimum]) forms.
+func loaderReq(args []any) *LoaderReq {
+	req := &LoaderReq{}
+	var segs []string
+	for _, v := range args {
+		switch v := v.(type) {
+		case string:
+			req.Version = v
+		case int64:
+			segs = append(segs, fmt.Sprint(v))
+		case bool:
+			req.Minimum = v
+		}
+	}
+	if req.Version == "" {
+		req.Version = strings.Join(segs, ".")
+	}
+	if req.Version == "" {
+		return nil
+	}
+	return req
+}
+
+// attrs returns the attributes of the first of names that a has.
+func attrs(a clrmeta.Assembly, names []string) []clrmeta.Attribute {
+	for _, n := range names {
+		if got := a.Attrs(n); len(got) > 0 {
+			return got
+		}
+	}
+	return nil
+}
+
+func argAt(args []any, i int) any {
+	if i < len(args) {
+		return args[i]
+	}
+	return nil
+}
+
+// argString returns args[i] if it is a string, or "".
+func argString(args []any, i int) string {
+	s, _ := argAt(args, i).(string)
+	return s
+}
+
+// colorString formats MelonColor's (alpha, red, green, blue) or ConsoleColor forms.
+func colorString(args []any) string {
+	switch len(args) {
+	case 1:
+		if n, ok := args[0].(int64); ok && n >= 0 && int(n) < len(consoleColors) {
+			return consoleColors[n]
+		}
+	case 4:
+		var rgb strings.Builder
+		rgb.WriteByte('#')
+		for _, v := range args[1:] {
+			n, ok := v.(int64)
+			if !ok {
+				return ""
+			}
+			fmt.Fprintf(&rgb, "%02x", max(0, min(255, n)))
+		}
+		return rgb.String()
+	}
+	return ""
+}
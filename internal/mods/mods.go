@@ -0,0 +1,464 @@
+package mods
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"automelonloaderinstallergo/internal/ghrel"
+	"automelonloaderinstallergo/internal/modscan"
+)
+
+// ErrNotFound reports that no file matches a name.
+var ErrNotFound = errors.New("not found")
+
+// Kind is the MelonLoader folder a file belongs to.
+type Kind string
+
+// File kinds.
+const (
+	KindMod     Kind = "mod"
+	KindPlugin  Kind = "plugin"
+	KindUserLib Kind = "userlib"
+)
+
+// Kinds lists every kind in display order.
+var Kinds = []Kind{KindMod, KindPlugin, KindUserLib}
+
+// ParseKind parses a kind name, accepting the folder names as well.
+func ParseKind(s string) (Kind, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "mod", "mods":
+		return KindMod, nil
+	case "plugin", "plugins":
+		return KindPlugin, nil
+	case "userlib", "userlibs":
+		return KindUserLib, nil
+	}
+	return "", fmt.Errorf("unknown kind %q (want mod, plugin or userlib)", s)
+}
+
+// Dir returns the game subdirectory holding enabled files of kind k.
+func (k Kind) Dir() string {
+	switch k {
+	case KindPlugin:
+		return "Plugins"
+	case KindUserLib:
+		return "UserLibs"
+	default:
+		return "Mods"
+	}
+}
+
+// DisabledDir returns the game subdirectory holding disabled files of kind k:
+// Mods/Disabled for mods, and Mods/Disabled/Plugins or Mods/Disabled/UserLibs.
+func (k Kind) DisabledDir() string {
+	if k == KindMod {
+		return filepath.Join("Mods", "Disabled")
+	}
+	return filepath.Join("Mods", "Disabled", k.Dir())
+}
+
+// loads reports whether MelonLoader would load a file of kind k named name. Mods
+// and plugins are .dll assemblies; UserLibs may also hold native libraries.
+func (k Kind) loads(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+	if k == KindUserLib {
+		return true
+	}
+	return strings.EqualFold(filepath.Ext(name), ".dll")
+}
+
+// Mod is a file in one of a game's MelonLoader folders.
+type Mod struct {
+	Kind    Kind   `json:"kind"`
+	Name    string `json:"name"` // file name, e.g. "CoolMod.dll"
+	Path    string `json:"path"`
+	Enabled bool   `json:"enabled"`
+	Size    int64  `json:"size"`
+	// Tracked is set for files installed by the tool and recorded in the manifest.
+	Tracked bool `json:"tracked"`
+	// Info is the melon's MelonInfo metadata; it is nil for other files. InfoErr
+	// reports an assembly whose metadata could not be read.
+	Info    *Info  `json:"info,omitempty"`
+	InfoErr string `json:"info_error,omitempty"`
+	// Warnings say why MelonLoader would not load the melon into this game; see
+	// Target.Check.
+	Warnings []string `json:"warnings,omitempty"`
+	// Scan is the safety scan of a file added with AddOptions.Scan.
+	Scan *modscan.Report `json:"scan,omitempty"`
+}
+
+// DisplayName returns the melon's declared name, or its file name.
+func (m Mod) DisplayName() string {
+	if m.Info != nil && m.Info.Name != "" {
+		return m.Info.Name
+	}
+	return m.Name
+}
+
+// Game is a MelonLoader game installation.
+type Game struct {
+	Name string `json:"name,omitempty"`
+	Dir  string `json:"dir"`
+}
+
+// now is replaced in tests.
+var now = time.Now
+
+// List returns the mods, plugins and user libraries of g, enabled and disabled,
+// sorted by kind and name.
+func (g Game) List() ([]Mod, error) {
+	man, err := LoadManifest(g.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	target := g.Target()
+	var out []Mod
+	for _, k := range Kinds {
+		for _, enabled := range []bool{true, false} {
+			dir := k.Dir()
+			if !enabled {
+				dir = k.DisabledDir()
+			}
+			entries, err := os.ReadDir(filepath.Join(g.Dir, dir))
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("list %s: %w", dir, err)
+			}
+			for _, e := range entries {
+				if !e.Type().IsRegular() || !k.loads(e.Name()) {
+					continue
+				}
+				m := Mod{Kind: k, Name: e.Name(), Path: filepath.Join(g.Dir, dir, e.Name()), Enabled: enabled}
+				if fi, err := e.Info(); err == nil {
+					m.Size = fi.Size()
+				}
+				_, m.Tracked = man.Entry(k, m.Name)
+				m.readInfo(target)
+				out = append(out, m)
+			}
+		}
+	}
+
+	kindOrder := func(k Kind) int {
+		for i, kk := range Kinds {
+			if kk == k {
+				return i
+			}
+		}
+		return len(Kinds)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Kind != out[j].Kind {
+			return kindOrder(out[i].Kind) < kindOrder(out[j].Kind)
+		}
+		return strings.ToLower(out[i].Name) < strings.ToLower(out[j].Name)
+	})
+	return out, nil
+}
+
+// Find returns the file matching name, compared case-insensitively with the file
+// name with or without its extension, or with the melon's declared name. An empty
+// kind searches every kind.
+func (g Game) Find(name string, kind Kind) (Mod, error) {
+	all, err := g.List()
+	if err != nil {
+		return Mod{}, err
+	}
+	var matches []Mod
+	for _, m := range all {
+		if kind != "" && m.Kind != kind {
+			continue
+		}
+		base := strings.TrimSuffix(m.Name, filepath.Ext(m.Name))
+		if strings.EqualFold(m.Name, name) || strings.EqualFold(base, name) ||
+			(m.Info != nil && strings.EqualFold(m.Info.Name, name)) {
+			matches = append(matches, m)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Mod{}, fmt.Errorf("%q %w in %s", name, ErrNotFound, g.Dir)
+	case 1:
+		return matches[0], nil
+	default:
+		var where []string
+		for _, m := range matches {
+			where = append(where, m.relPath(g.Dir))
+		}
+		return Mod{}, fmt.Errorf("%q is ambiguous (%s); pass the kind or full file name", name, strings.Join(where, ", "))
+	}
+}
+
+// AddOptions control Add.
+type AddOptions struct {
+	// Replace overwrites an existing file of the same name.
+	Replace bool
+	// Force installs a melon that fails Target.Check; the returned Mod carries the
+	// warnings.
+	Force bool
+
+	// Source and Tag are recorded in the manifest entry; Source defaults to the
+	// absolute path of the file added.
+	Source, Tag string
+	// SHA256, if set, is the hex digest the file must have.
+	SHA256 string
+	// Scan, if set, scans the file first; a file a deny rule matches is refused
+	// with modscan.ErrDenied, even with Force.
+	Scan *modscan.Scanner
+}
+
+// Add copies the file at src into the folder for kind. An existing file of the
+// same name, enabled or disabled, is only replaced with opts.Replace; the copy
+// keeps the existing file's enabled state. A melon built for another game or
+// MelonLoader version is refused with ErrIncompatible unless opts.Force is set.
+func (g Game) Add(src string, kind Kind, opts AddOptions) (Mod, error) {
+	return g.add(src, kind, opts, ActionAdd)
+}
+
+// add implements Add, recording action in the manifest.
+func (g Game) add(src string, kind Kind, opts AddOptions, action string) (Mod, error) {
+	name := filepath.Base(src)
+	if !kind.loads(name) {
+		return Mod{}, fmt.Errorf("%s is not a .dll; MelonLoader would not load it from %s", name, kind.Dir())
+	}
+	if opts.SHA256 != "" {
+		got, _, err := HashFile(src)
+		if err != nil {
+			return Mod{}, err
+		}
+		if !strings.EqualFold(got, opts.SHA256) {
+			return Mod{}, fmt.Errorf("%s: sha256 mismatch: got %s, want %s", name, got, opts.SHA256)
+		}
+	}
+	incoming := Mod{Kind: kind, Name: name, Path: src}
+	if opts.Scan != nil {
+		rep, err := opts.Scan.ScanFile(src)
+		if err != nil {
+			return Mod{}, err
+		}
+		if err := rep.Err(name); err != nil {
+			return Mod{}, err
+		}
+		incoming.Scan = &rep
+	}
+	incoming.readInfo(g.Target())
+	if len(incoming.Warnings) > 0 && !opts.Force {
+		return Mod{}, fmt.Errorf("%s is %w with %s: %s; use force to install it anyway",
+			name, ErrIncompatible, g.label(), strings.Join(incoming.Warnings, "; "))
+	}
+
+	all, err := g.List()
+	if err != nil {
+		return Mod{}, err
+	}
+	enabled := true
+	for _, existing := range all {
+		if existing.Kind != kind || !strings.EqualFold(existing.Name, name) {
+			continue
+		}
+		if !opts.Replace {
+			return Mod{}, fmt.Errorf("%s already exists; use replace to overwrite it", existing.relPath(g.Dir))
+		}
+		enabled = existing.Enabled
+		name = existing.Name // keep the installed file's case
+	}
+
+	dir := kind.Dir()
+	if !enabled {
+		dir = kind.DisabledDir()
+	}
+	dst := filepath.Join(g.Dir, dir, name)
+
+	in, err := os.Open(src)
+	if err != nil {
+		return Mod{}, fmt.Errorf("open %s: %w", src, err)
+	}
+	defer in.Close()
+
+	h := sha256.New()
+	var size int64
+	err = ghrel.WriteFileAtomically(dst, func(f *os.File) error {
+		size, err = io.Copy(io.MultiWriter(f, h), in)
+		return err
+	})
+	if err != nil {
+		return Mod{}, fmt.Errorf("copy %s: %w", name, err)
+	}
+
+	source := opts.Source
+	if source == "" {
+		source, _ = filepath.Abs(src)
+	}
+	entry := Entry{
+		Kind:        kind,
+		Name:        name,
+		Enabled:     enabled,
+		Size:        size,
+		SHA256:      hex.EncodeToString(h.Sum(nil)),
+		InstalledAt: now().UTC(),
+		Source:      source,
+		Tag:         opts.Tag,
+	}
+	if err := g.record(action, entry); err != nil {
+		return Mod{}, err
+	}
+	m := incoming
+	m.Name, m.Path, m.Enabled, m.Size, m.Tracked = name, dst, enabled, size, true
+	return m, nil
+}
+
+// HashFile returns the hex SHA-256 digest and size of the file at path.
+func HashFile(path string) (sum string, size int64, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if size, err = io.Copy(h, f); err != nil {
+		return "", 0, fmt.Errorf("hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// Remove deletes the file matching name (see Find). When it is the last mod,
+// plugin or user library installed from a Thunderstore package, the package's
+// other files are removed too (see RemovePackageFiles).
+func (g Game) Remove(name string, kind Kind) (Mod, error) {
+	return g.remove(name, kind, true)
+}
+
+// remove deletes the file matching name, and with prune the other files of its
+// package once none of the package's tracked files are left.
+func (g Game) remove(name string, kind Kind, prune bool) (Mod, error) {
+	m, err := g.Find(name, kind)
+	if err != nil {
+		return Mod{}, err
+	}
+	man, err := LoadManifest(g.Dir)
+	if err != nil {
+		return Mod{}, err
+	}
+	e, _ := man.Entry(m.Kind, m.Name)
+	if err := os.Remove(m.Path); err != nil {
+		return Mod{}, fmt.Errorf("remove %s: %w", m.relPath(g.Dir), err)
+	}
+	if err := g.record(ActionRemove, Entry{Kind: m.Kind, Name: m.Name}); err != nil {
+		return Mod{}, err
+	}
+	m.Tracked = false
+
+	ref, ok := PackageOf(e.Source)
+	if !prune || !ok {
+		return m, nil
+	}
+	man, err = LoadManifest(g.Dir)
+	if err != nil {
+		return m, err
+	}
+	for _, e := range man.Files {
+		if r, ok := PackageOf(e.Source); ok && r.Key() == ref.Key() {
+			return m, nil
+		}
+	}
+	_, err = g.RemovePackageFiles(ref)
+	return m, err
+}
+
+// Enable moves the file matching name (see Find) back into its folder.
+func (g Game) Enable(name string, kind Kind) (Mod, error) {
+	return g.setEnabled(name, kind, true)
+}
+
+// Disable moves the file matching name (see Find) under Mods/Disabled.
+func (g Game) Disable(name string, kind Kind) (Mod, error) {
+	return g.setEnabled(name, kind, false)
+}
+
+func (g Game) setEnabled(name string, kind Kind, enabled bool) (Mod, error) {
+	m, err := g.Find(name, kind)
+	if err != nil {
+		return Mod{}, err
+	}
+	if m.Enabled == enabled {
+		return m, nil
+	}
+
+	dir := m.Kind.Dir()
+	action := ActionEnable
+	if !enabled {
+		dir = m.Kind.DisabledDir()
+		action = ActionDisable
+	}
+	dst := filepath.Join(g.Dir, dir, m.Name)
+	if _, err := os.Lstat(dst); err == nil {
+		return Mod{}, fmt.Errorf("cannot move %s: %s already exists", m.relPath(g.Dir), filepath.Join(dir, m.Name))
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return Mod{}, fmt.Errorf("mkdir: %w", err)
+	}
+	if err := os.Rename(m.Path, dst); err != nil {
+		return Mod{}, fmt.Errorf("move %s: %w", m.relPath(g.Dir), err)
+	}
+	if err := g.record(action, Entry{Kind: m.Kind, Name: m.Name}); err != nil {
+		return Mod{}, err
+	}
+	m.Path, m.Enabled = dst, enabled
+	return m, nil
+}
+
+// readInfo sets m.Info, or m.InfoErr, and the warnings of checking it against t.
+// User libraries are not melons and have no info.
+func (m *Mod) readInfo(t Target) {
+	if m.Kind == KindUserLib {
+		return
+	}
+	info, err := ReadInfo(m.Path)
+	if err != nil {
+		m.InfoErr = err.Error()
+		return
+	}
+	m.Info = info
+	m.Warnings = t.Check(info)
+}
+
+// label names g for messages: its configured name, or its directory.
+func (g Game) label() string {
+	if g.Name != "" {
+		return g.Name
+	}
+	return g.Dir
+}
+
+// record appends a change to the game's manifest.
+func (g Game) record(action string, e Entry) error {
+	man, err := LoadManifest(g.Dir)
+	if err != nil {
+		return err
+	}
+	man.record(action, e, now().UTC())
+	return man.save(g.Dir)
+}
+
+// relPath returns m's path relative to the game directory dir, for messages.
+func (m Mod) relPath(dir string) string {
+	if rel, err := filepath.Rel(dir, m.Path); err == nil {
+		return rel
+	}
+	return m.Path
+}
@@ -0,0 +1,740 @@
+package mods
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"automelonloaderinstallergo/internal/modscan"
+	"automelonloaderinstallergo/internal/releases"
+	"automelonloaderinstallergo/internal/thunderstore"
+)
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func names(ms []Mod) []string {
+	var out []string
+	for _, m := range ms {
+		s := string(m.Kind) + ":" + m.Name
+		if !m.Enabled {
+			s += " (disabled)"
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func TestList(t *testing.T) {
+	g := Game{Dir: t.TempDir()}
+	write(t, filepath.Join(g.Dir, "Mods", "b.dll"), "b")
+	write(t, filepath.Join(g.Dir, "Mods", "A.dll"), "a")
+	write(t, filepath.Join(g.Dir, "Mods", "readme.txt"), "not loaded")
+	write(t, filepath.Join(g.Dir, "Mods", "Disabled", "old.dll"), "old")
+	write(t, filepath.Join(g.Dir, "Mods", "Disabled", "Plugins", "p2.dll"), "p2")
+	write(t, filepath.Join(g.Dir, "Plugins", "p1.dll"), "p1")
+	write(t, filepath.Join(g.Dir, "UserLibs", "native.so"), "lib")
+
+	got, err := g.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	want := "mod:A.dll mod:b.dll mod:old.dll (disabled) plugin:p1.dll plugin:p2.dll (disabled) userlib:native.so"
+	if s := strings.Join(names(got), " "); s != want {
+		t.Fatalf("got  %s\nwant %s", s, want)
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+
+	g := Game{Dir: t.TempDir()}
+	src := filepath.Join(t.TempDir(), "CoolMod.dll")
+	write(t, src, "v1")
+
+	m, err := g.Add(src, KindMod, AddOptions{})
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if m.Path != filepath.Join(g.Dir, "Mods", "CoolMod.dll") || !m.Enabled || !m.Tracked {
+		t.Fatalf("Add = %+v", m)
+	}
+	if _, err := g.Add(src, KindMod, AddOptions{}); err == nil {
+		t.Fatal("expected an error adding an existing mod without replace")
+	}
+
+	if m, err = g.Disable("coolmod", ""); err != nil {
+		t.Fatalf("Disable: %v", err)
+	}
+	if m.Path != filepath.Join(g.Dir, "Mods", "Disabled", "CoolMod.dll") || m.Enabled {
+		t.Fatalf("Disable = %+v", m)
+	}
+
+	// Replacing a disabled mod keeps it disabled.
+	write(t, src, "v2")
+	if m, err = g.Add(src, KindMod, AddOptions{Replace: true}); err != nil || m.Enabled {
+		t.Fatalf("Add(replace) = %+v, %v", m, err)
+	}
+	if b, _ := os.ReadFile(m.Path); string(b) != "v2" {
+		t.Fatalf("replaced content = %q", b)
+	}
+
+	if m, err = g.Enable("CoolMod.dll", KindMod); err != nil || !m.Enabled {
+		t.Fatalf("Enable = %+v, %v", m, err)
+	}
+
+	man, err := LoadManifest(g.Dir)
+	if err != nil {
+		t.Fatalf("LoadManifest: %v", err)
+	}
+	e, ok := man.Entry(KindMod, "coolmod.dll")
+	if !ok || !e.Enabled || e.Size != 2 || len(e.SHA256) != 64 || !e.InstalledAt.Equal(now()) {
+		t.Fatalf("entry = %+v, %v", e, ok)
+	}
+
+	if _, err := g.Remove("CoolMod", ""); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := os.Stat(m.Path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("file still present: %v", err)
+	}
+
+	man, _ = LoadManifest(g.Dir)
+	if len(man.Files) != 0 {
+		t.Fatalf("files = %+v", man.Files)
+	}
+	var actions []string
+	for _, ev := range man.History {
+		actions = append(actions, ev.Action)
+	}
+	if s := strings.Join(actions, ","); s != "add,disable,add,enable,remove" {
+		t.Fatalf("history = %s", s)
+	}
+}
+
+func TestFind(t *testing.T) {
+	g := Game{Dir: t.TempDir()}
+	write(t, filepath.Join(g.Dir, "Mods", "Shared.dll"), "m")
+	write(t, filepath.Join(g.Dir, "Plugins", "Shared.dll"), "p")
+
+	if _, err := g.Find("shared", ""); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Fatalf("expected an ambiguity error, got %v", err)
+	}
+	if m, err := g.Find("shared", KindPlugin); err != nil || m.Kind != KindPlugin {
+		t.Fatalf("Find(plugin) = %+v, %v", m, err)
+	}
+	if _, err := g.Find("missing", ""); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestAddRejectsNonAssembly(t *testing.T) {
+	g := Game{Dir: t.TempDir()}
+	src := filepath.Join(t.TempDir(), "notes.txt")
+	write(t, src, "x")
+	if _, err := g.Add(src, KindMod, AddOptions{}); err == nil {
+		t.Fatal("expected an error adding a non-.dll mod")
+	}
+	if _, err := g.Add(src, KindUserLib, AddOptions{}); err != nil {
+		t.Fatalf("UserLibs accept any file: %v", err)
+	}
+}
+
+func TestAddScan(t *testing.T) {
+	g := Game{Dir: t.TempDir()}
+	src := filepath.Join(t.TempDir(), "RiskyMod.dll")
+	copyFixture(t, "RiskyMod.dll", src)
+
+	deny := &modscan.Scanner{Deny: []string{"pinvoke:*"}}
+	if _, err := g.Add(src, KindMod, AddOptions{Scan: deny, Force: true}); !errors.Is(err, modscan.ErrDenied) {
+		t.Fatalf("denied mod: %v", err)
+	}
+	if all, _ := g.List(); len(all) != 0 {
+		t.Fatalf("denied mod was installed: %v", names(all))
+	}
+	m, err := g.Add(src, KindMod, AddOptions{Scan: &modscan.Scanner{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Scan == nil || len(m.Scan.Findings) != 5 {
+		t.Errorf("scan %+v", m.Scan)
+	}
+}
+
+func TestLoadManifestRejectsNewerSchema(t *testing.T) {
+	dir := t.TempDir()
+	write(t, filepath.Join(dir, ManifestPath), `{"schema": 99}`)
+	if _, err := LoadManifest(dir); err == nil {
+		t.Fatal("expected an error for a newer schema")
+	}
+}
+
+// copyFixture copies an assembly from the clrmeta test fixtures to dst.
+func copyFixture(t *testing.T, name, dst string) {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("..", "clrmeta", "testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, dst, string(b))
+}
+
+func TestReadInfo(t *testing.T) {
+	g := Game{Dir: t.TempDir()}
+	copyFixture(t, "CoolMod.dll", filepath.Join(g.Dir, "Mods", "CoolMod.dll"))
+	copyFixture(t, "LegacyPlugin.dll", filepath.Join(g.Dir, "Plugins", "LegacyPlugin.dll"))
+	write(t, filepath.Join(g.Dir, "Mods", "Native.dll"), "not an assembly")
+
+	ms, err := g.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := map[string]Mod{}
+	for _, m := range ms {
+		byName[m.Name] = m
+	}
+
+	cool := byName["CoolMod.dll"]
+	if cool.Info == nil {
+		t.Fatalf("CoolMod.dll: no info (error %q)", cool.InfoErr)
+	}
+	if got, want := *cool.Info, (Info{
+		Name:         "Cool Mod",
+		Version:      "1.2.3",
+		Author:       "Jane Doe",
+		DownloadLink: "https://example.com/coolmod",
+		Type:         cool.Info.Type,
+		Games:        []GameRef{{"Stress Level Zero", "BONELAB"}, {"Kinetic Games", "Phasmophobia"}},
+		Color:        "#c86432",
+		Loader:       &LoaderReq{Version: "0.6.0", Minimum: true},
+	}); !reflect.DeepEqual(got, want) {
+		t.Errorf("CoolMod.dll info = %+v, want %+v", got, want)
+	}
+	if cool.DisplayName() != "Cool Mod" {
+		t.Errorf("DisplayName = %q", cool.DisplayName())
+	}
+
+	legacy := byName["LegacyPlugin.dll"]
+	if legacy.Info == nil {
+		t.Fatalf("LegacyPlugin.dll: no info (error %q)", legacy.InfoErr)
+	}
+	if legacy.Info.Version != "2.0.1-beta" || legacy.Info.Author != "Someone" || legacy.Info.Color != "Cyan" {
+		t.Errorf("LegacyPlugin.dll info = %+v", *legacy.Info)
+	}
+	if legacy.Info.Loader == nil || *legacy.Info.Loader != (LoaderReq{Version: "0.5.7"}) {
+		t.Errorf("LegacyPlugin.dll loader = %+v, want exactly 0.5.7", legacy.Info.Loader)
+	}
+	if len(legacy.Info.Games) != 1 || legacy.Info.Games[0] != (GameRef{}) {
+		t.Errorf("LegacyPlugin.dll games = %+v, want one universal entry", legacy.Info.Games)
+	}
+
+	native := byName["Native.dll"]
+	if native.Info != nil || native.InfoErr != "" {
+		t.Errorf("Native.dll: info %+v, error %q; want neither", native.Info, native.InfoErr)
+	}
+
+	m, err := g.Find("cool mod", "")
+	if err != nil || m.Name != "CoolMod.dll" {
+		t.Errorf(`Find("cool mod") = %v, %v`, m.Name, err)
+	}
+}
+
+func TestCompatibility(t *testing.T) {
+	g := Game{Dir: t.TempDir()}
+	write(t, filepath.Join(g.Dir, "BONELAB_Data", "app.info"), "Stress Level Zero\nBONELAB")
+	copyFixture(t, "MelonLoader.dll", filepath.Join(g.Dir, "MelonLoader", "net6", "MelonLoader.dll"))
+	if got, want := g.Target(), (Target{Game: GameRef{"Stress Level Zero", "BONELAB"}, Loader: "0.6.1"}); got != want {
+		t.Fatalf("Target = %+v, want %+v", got, want)
+	}
+
+	src := filepath.Join(t.TempDir(), "LegacyPlugin.dll")
+	copyFixture(t, "LegacyPlugin.dll", src)
+	_, err := g.Add(src, KindPlugin, AddOptions{})
+	if !errors.Is(err, ErrIncompatible) || !strings.Contains(err.Error(), "needs MelonLoader 0.5.7, 0.6.1 is installed") {
+		t.Fatalf("Add incompatible plugin: %v, want ErrIncompatible", err)
+	}
+	m, err := g.Add(src, KindPlugin, AddOptions{Force: true})
+	if err != nil || len(m.Warnings) != 1 {
+		t.Fatalf("Add with Force = %+v, %v; want one warning", m.Warnings, err)
+	}
+
+	src = filepath.Join(t.TempDir(), "CoolMod.dll")
+	copyFixture(t, "CoolMod.dll", src)
+	if m, err := g.Add(src, KindMod, AddOptions{}); err != nil || len(m.Warnings) != 0 {
+		t.Fatalf("Add compatible mod = %+v, %v", m.Warnings, err)
+	}
+
+	// The same mod in another game.
+	write(t, filepath.Join(g.Dir, "BONELAB_Data", "app.info"), "Someone Else\nOther Game\n")
+	ms, err := g.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "built for BONELAB (Stress Level Zero), Phasmophobia (Kinetic Games), not Other Game (Someone Else)"
+	if len(ms) != 2 || ms[0].Name != "CoolMod.dll" || len(ms[0].Warnings) != 1 || ms[0].Warnings[0] != want {
+		t.Errorf("List warnings = %q, want [%q]", ms[0].Warnings, want)
+	}
+}
+
+func TestCheckLoader(t *testing.T) {
+	for _, tc := range []struct {
+		loader string
+		req    LoaderReq
+		ok     bool
+	}{
+		{"0.6.1", LoaderReq{Version: "0.6.0", Minimum: true}, true},
+		{"0.5.7", LoaderReq{Version: "0.6.0", Minimum: true}, false},
+		{"0.6.1", LoaderReq{Version: "0.6.1"}, true},
+		{"0.6.1.0", LoaderReq{Version: "0.6.1"}, true},
+		{"0.6.2", LoaderReq{Version: "0.6.1"}, false},
+		{"0.6.1", LoaderReq{Version: "not a version"}, true},
+	} {
+		got := Target{Loader: tc.loader}.Check(&Info{Loader: &tc.req})
+		if (len(got) == 0) != tc.ok {
+			t.Errorf("loader %s, requirement %+v: warnings %q, want ok=%v", tc.loader, tc.req, got, tc.ok)
+		}
+	}
+}
+
+// fakeSource serves releases whose assets are zips holding CoolMod.dll.
+type fakeSource struct {
+	t    *testing.T
+	tags []string
+}
+
+func (s fakeSource) ListTags(ctx context.Context, owner, repo, token string) ([]string, error) {
+	return s.tags, nil
+}
+
+func (s fakeSource) ListAssets(ctx context.Context, owner, repo, tag, token string) ([]releases.Asset, error) {
+	return []releases.Asset{{Name: "CoolMod-" + tag + ".zip"}, {Name: "README.md"}}, nil
+}
+
+func (s fakeSource) DownloadAsset(ctx context.Context, owner, repo, tag, asset, outPath, token string) error {
+	dll, err := os.ReadFile(filepath.Join("..", "clrmeta", "testdata", "CoolMod.dll"))
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for name, b := range map[string][]byte{"CoolMod/CoolMod.dll": dll, "CoolMod/README.md": []byte(tag)} {
+		w, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		w.Write(b)
+	}
+	return zw.Close()
+}
+
+func TestUpdate(t *testing.T) {
+	g := Game{Dir: t.TempDir()}
+	src := filepath.Join(t.TempDir(), "CoolMod.dll")
+	copyFixture(t, "CoolMod.dll", src)
+	if _, err := g.Add(src, KindMod, AddOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Disable("CoolMod", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SetOrigin(Origin{Name: "Cool Mod", Repo: "jane/coolmod", Asset: "CoolMod-*.zip"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	u := Updater{Source: fakeSource{t: t, tags: []string{"v1.2.3", "v1.3.0", "v2.0.0-beta.1"}}}
+	ups, err := u.Check(ctx, g, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ups) != 1 || ups[0].Installed != "1.2.3" || ups[0].Tag != "v1.3.0" || ups[0].Status != UpdateAvailable {
+		t.Fatalf("Check = %+v, want 1.2.3 -> v1.3.0 available", ups)
+	}
+
+	if err := u.Apply(ctx, g, &ups[0]); err != nil {
+		t.Fatal(err)
+	}
+	if m := ups[0].Mod; ups[0].Status != UpdateInstalled || m.Enabled || m.Path != filepath.Join(g.Dir, "Mods", "Disabled", "CoolMod.dll") {
+		t.Errorf("Apply = %+v, want the disabled file replaced", ups[0])
+	}
+	man, err := LoadManifest(g.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, _ := man.Entry(KindMod, "CoolMod.dll")
+	if e.Tag != "v1.3.0" || e.Source != "jane/coolmod@v1.3.0/CoolMod-v1.3.0.zip" || e.Enabled {
+		t.Errorf("manifest entry = %+v", e)
+	}
+	if last := man.History[len(man.History)-1]; last.Action != ActionUpdate {
+		t.Errorf("last history action = %q, want %q", last.Action, ActionUpdate)
+	}
+
+	if ups, err = u.Check(ctx, g, []string{"coolmod"}, ""); err != nil || ups[0].Status != UpdateCurrent {
+		t.Errorf("Check after update = %+v, %v; want up to date", ups, err)
+	}
+
+	if err := g.SetOrigin(Origin{Name: "Cool Mod", Repo: "jane/coolmod", Asset: "CoolMod-*.zip", Prerelease: true}); err != nil {
+		t.Fatal(err)
+	}
+	if ups, err = u.Check(ctx, g, nil, ""); err != nil || ups[0].Tag != "v2.0.0-beta.1" {
+		t.Errorf("Check with prereleases = %+v, %v", ups, err)
+	}
+
+	write(t, filepath.Join(g.Dir, "Mods", "Other.dll"), "other")
+	if _, err := u.Check(ctx, g, []string{"Other"}, ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Check of a mod without origin: %v, want ErrNotFound", err)
+	}
+}
+
+func TestLoadModsFileRejectsBadOrigin(t *testing.T) {
+	dir := t.TempDir()
+	write(t, filepath.Join(dir, OriginsPath), "mods:\n  - name: CoolMod\n    repo: coolmod\n    asset: CoolMod.dll\n")
+	if _, err := LoadModsFile(dir); err == nil || !strings.Contains(err.Error(), "owner/repo") {
+		t.Fatalf("LoadModsFile = %v, want an owner/repo error", err)
+	}
+}
+
+// writeZip writes a zip archive of files, whose values name clrmeta fixtures when
+// they start with "fixture:".
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for name, body := range files {
+		if fixture, ok := strings.CutPrefix(body, "fixture:"); ok {
+			b, err := os.ReadFile(filepath.Join("..", "clrmeta", "testdata", fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body = string(b)
+		}
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInstallPackage(t *testing.T) {
+	g := Game{Dir: t.TempDir()}
+	write(t, filepath.Join(g.Dir, "BONELAB_Data", "app.info"), "Stress Level Zero\nBONELAB")
+	copyFixture(t, "MelonLoader.dll", filepath.Join(g.Dir, "MelonLoader", "net6", "MelonLoader.dll"))
+	write(t, filepath.Join(g.Dir, "UserData", "CoolMod.cfg"), "mine")
+
+	archive := filepath.Join(t.TempDir(), "Jane-CoolMod-1.2.3.zip")
+	writeZip(t, archive, map[string]string{
+		"manifest.json":              `{"name":"CoolMod","version_number":"1.2.3","dependencies":[]}`,
+		"icon.png":                   "png",
+		"README.md":                  "readme",
+		"Mods/CoolMod.dll":           "fixture:CoolMod.dll",
+		"UserLibs/CoolLib.dll":       "fixture:MelonLoader.dll",
+		"UserData/CoolMod.cfg":       "default",
+		"UserData/CoolMod/theme.txt": "dark",
+		"MelonLoader/Bundled.dll":    "loader",
+	})
+	ref := thunderstore.Ref{Namespace: "Jane", Name: "CoolMod", Version: "1.2.3"}
+	res, err := g.InstallPackage(archive, ref, AddOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := names(res.Mods), []string{"userlib:CoolLib.dll", "mod:CoolMod.dll"}; !sameSet(got, want) {
+		t.Errorf("mods = %v, want %v", got, want)
+	}
+	if want := []string{filepath.Join("UserData", "CoolMod", "theme.txt")}; !reflect.DeepEqual(res.Files, want) {
+		t.Errorf("files = %v, want %v", res.Files, want)
+	}
+	if b, _ := os.ReadFile(filepath.Join(g.Dir, "UserData", "CoolMod.cfg")); string(b) != "mine" {
+		t.Errorf("existing UserData file overwritten with %q", b)
+	}
+	if _, err := os.Stat(filepath.Join(g.Dir, "MelonLoader", "Bundled.dll")); !os.IsNotExist(err) {
+		t.Errorf("MelonLoader folder of the package installed: %v", err)
+	}
+
+	pkgs, err := g.Packages()
+	if err != nil || pkgs["jane-coolmod"] != ref {
+		t.Errorf("Packages = %v, %v; want %v", pkgs, err, ref)
+	}
+
+	ms, err := g.SetPackageEnabled(thunderstore.Ref{Namespace: "jane", Name: "coolmod"}, false)
+	if err != nil || len(ms) != 2 || ms[0].Enabled || ms[1].Enabled {
+		t.Errorf("SetPackageEnabled = %v, %v; want both files disabled", names(ms), err)
+	}
+
+	// Profile configs replace existing files, and only UserData is imported.
+	profile := filepath.Join(t.TempDir(), "profile.r2z")
+	writeZip(t, profile, map[string]string{
+		"export.r2x":           "profileName: Default\nmods: []\n",
+		"UserData/CoolMod.cfg": "profile",
+		"BepInEx/config/x.cfg": "x",
+	})
+	files, err := g.ImportUserData(profile)
+	if err != nil || !reflect.DeepEqual(files, []string{filepath.Join("UserData", "CoolMod.cfg")}) {
+		t.Errorf("ImportUserData = %v, %v", files, err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(g.Dir, "UserData", "CoolMod.cfg")); string(b) != "profile" {
+		t.Errorf("UserData file = %q, want the profile's", b)
+	}
+
+	// A package with an incompatible melon installs nothing.
+	bad := filepath.Join(t.TempDir(), "Someone-Legacy-2.0.1.zip")
+	writeZip(t, bad, map[string]string{
+		"manifest.json":            `{"name":"Legacy","version_number":"2.0.1","dependencies":[]}`,
+		"UserData/Legacy.cfg":      "x",
+		"Plugins/LegacyPlugin.dll": "fixture:LegacyPlugin.dll",
+	})
+	ref = thunderstore.Ref{Namespace: "Someone", Name: "Legacy", Version: "2.0.1"}
+	if _, err := g.InstallPackage(bad, ref, AddOptions{}); !errors.Is(err, ErrIncompatible) {
+		t.Fatalf("InstallPackage incompatible = %v, want ErrIncompatible", err)
+	}
+	if _, err := os.Stat(filepath.Join(g.Dir, "UserData", "Legacy.cfg")); !os.IsNotExist(err) {
+		t.Errorf("incompatible package partly installed: %v", err)
+	}
+
+	// So does a package with a denied melon, even with Force.
+	risky := filepath.Join(t.TempDir(), "Someone-Risky-1.0.0.zip")
+	writeZip(t, risky, map[string]string{
+		"manifest.json":      `{"name":"Risky","version_number":"1.0.0","dependencies":[]}`,
+		"UserData/Risky.cfg": "x",
+		"Mods/CoolMod.dll":   "fixture:CoolMod.dll",
+		"Mods/RiskyMod.dll":  "fixture:RiskyMod.dll",
+	})
+	ref = thunderstore.Ref{Namespace: "Someone", Name: "Risky", Version: "1.0.0"}
+	deny := &modscan.Scanner{Deny: []string{"pinvoke:*"}}
+	if _, err := g.InstallPackage(risky, ref, AddOptions{Force: true, Scan: deny}); !errors.Is(err, modscan.ErrDenied) {
+		t.Fatalf("InstallPackage denied = %v, want ErrDenied", err)
+	}
+	if _, err := os.Stat(filepath.Join(g.Dir, "UserData", "Risky.cfg")); !os.IsNotExist(err) {
+		t.Errorf("denied package partly installed: %v", err)
+	}
+	res, err = g.InstallPackage(risky, ref, AddOptions{Force: true, Scan: &modscan.Scanner{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range res.Mods {
+		if m.Scan == nil || (m.Name == "RiskyMod.dll") != (len(m.Scan.Findings) > 0) {
+			t.Errorf("%s scan = %+v", m.Name, m.Scan)
+		}
+	}
+
+	evil := filepath.Join(t.TempDir(), "evil.zip")
+	writeZip(t, evil, map[string]string{"../escape.dll": "x"})
+	if _, err := g.InstallPackage(evil, ref, AddOptions{}); err == nil || !strings.Contains(err.Error(), "unsafe path") {
+		t.Errorf("InstallPackage with ../ path = %v", err)
+	}
+}
+
+func TestPackageFiles(t *testing.T) {
+	g := Game{Dir: t.TempDir()}
+	write(t, filepath.Join(g.Dir, "UserData", "Mine.cfg"), "mine")
+	v1 := filepath.Join(t.TempDir(), "Jane-CoolMod-1.0.0.zip")
+	writeZip(t, v1, map[string]string{
+		"Mods/CoolMod.dll":          "fixture:CoolMod.dll",
+		"UserLibs/CoolLib.dll":      "fixture:MelonLoader.dll",
+		"Mods/CoolMod/data.json":    "v1",
+		"Mods/CoolMod/old.json":     "old",
+		"UserData/CoolMod.cfg":      "default",
+		"UserData/CoolMod/edit.cfg": "default",
+		"UserData/Mine.cfg":         "theirs",
+	})
+	ref := thunderstore.Ref{Namespace: "Jane", Name: "CoolMod", Version: "1.0.0"}
+	if _, err := g.InstallPackage(v1, ref, AddOptions{Force: true}); err != nil {
+		t.Fatal(err)
+	}
+	man, _ := LoadManifest(g.Dir)
+	var paths []string
+	for _, f := range man.PackageFiles {
+		paths = append(paths, f.Path)
+	}
+	// UserData/Mine.cfg existed, so it is not the package's.
+	if want := []string{"Mods/CoolMod/data.json", "Mods/CoolMod/old.json", "UserData/CoolMod.cfg", "UserData/CoolMod/edit.cfg"}; !sameSet(paths, want) {
+		t.Fatalf("package files = %v, want %v", paths, want)
+	}
+	write(t, filepath.Join(g.Dir, "UserData", "CoolMod", "edit.cfg"), "edited")
+
+	// Upgrading removes what the new version no longer has, except changed files.
+	v2 := filepath.Join(t.TempDir(), "Jane-CoolMod-2.0.0.zip")
+	writeZip(t, v2, map[string]string{
+		"Mods/CoolMod.dll":       "fixture:CoolMod.dll",
+		"Mods/CoolMod/data.json": "v2",
+		"UserData/CoolMod.cfg":   "default",
+	})
+	ref.Version = "2.0.0"
+	if _, err := g.InstallPackage(v2, ref, AddOptions{Force: true}); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]bool{
+		"UserLibs/CoolLib.dll":      false,
+		"Mods/CoolMod/old.json":     false,
+		"Mods/CoolMod/data.json":    true,
+		"UserData/CoolMod.cfg":      true,
+		"UserData/CoolMod/edit.cfg": true,
+		"UserData/Mine.cfg":         true,
+	} {
+		if _, err := os.Stat(filepath.Join(g.Dir, path)); (err == nil) != want {
+			t.Errorf("after upgrade, %s exists = %v, want %v", path, err == nil, want)
+		}
+	}
+	if b, _ := os.ReadFile(filepath.Join(g.Dir, "Mods", "CoolMod", "data.json")); string(b) != "v2" {
+		t.Errorf("data.json = %q, want v2", b)
+	}
+
+	// Removing the package's last mod removes its other files.
+	if _, err := g.Remove("CoolMod.dll", KindMod); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]bool{
+		"Mods/CoolMod/data.json":    false,
+		"UserData/CoolMod.cfg":      false,
+		"UserData/CoolMod/edit.cfg": true,
+		"UserData/Mine.cfg":         true,
+	} {
+		if _, err := os.Stat(filepath.Join(g.Dir, path)); (err == nil) != want {
+			t.Errorf("after remove, %s exists = %v, want %v", path, err == nil, want)
+		}
+	}
+	if man, _ := LoadManifest(g.Dir); len(man.Files) != 0 || len(man.PackageFiles) != 0 {
+		t.Errorf("manifest = %+v", man)
+	}
+
+	disabled := filepath.Join(t.TempDir(), "Jane-Sneaky-1.0.0.zip")
+	writeZip(t, disabled, map[string]string{"Mods/Disabled/Sneaky.dll": "fixture:CoolMod.dll"})
+	if _, err := g.InstallPackage(disabled, ref, AddOptions{Force: true}); err == nil || !strings.Contains(err.Error(), "disabled folder") {
+		t.Errorf("package with Mods/Disabled files = %v", err)
+	}
+}
+
+func TestInstallPackages(t *testing.T) {
+	g := Game{Dir: t.TempDir()}
+	write(t, filepath.Join(g.Dir, "BONELAB_Data", "app.info"), "Stress Level Zero\nBONELAB")
+	copyFixture(t, "MelonLoader.dll", filepath.Join(g.Dir, "MelonLoader", "net6", "MelonLoader.dll"))
+
+	archive := filepath.Join(t.TempDir(), "Jane-CoolMod-1.2.3.zip")
+	writeZip(t, archive, map[string]string{
+		"manifest.json":    `{"name":"CoolMod","version_number":"1.2.3","dependencies":["LavaGang-MelonLoader-0.7.0"]}`,
+		"Mods/CoolMod.dll": "fixture:CoolMod.dll",
+	})
+	ml := thunderstore.Ref{Namespace: "LavaGang", Name: "MelonLoader", Version: "0.7.0"}
+	roots := []thunderstore.Node{{
+		Ref:  thunderstore.Ref{Namespace: "Jane", Name: "CoolMod", Version: "1.2.3"},
+		Deps: []thunderstore.Ref{ml},
+		Path: archive,
+	}}
+	// Local archives and MelonLoader are never looked up.
+	client := thunderstore.NewClient("http://127.0.0.1:0")
+
+	plan, err := g.PlanPackages(context.Background(), client, roots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Plan.Install) != 1 || len(plan.Installed) != 0 || len(plan.Warnings) != 1 || !strings.Contains(plan.Warnings[0], ml.String()) {
+		t.Fatalf("PlanPackages = %+v", plan)
+	}
+	if all, _ := g.List(); len(all) != 0 {
+		t.Fatalf("PlanPackages installed %v", names(all))
+	}
+
+	res, err := g.InstallPackages(context.Background(), client, roots, AddOptions{Force: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Installed) != 1 || !reflect.DeepEqual(names(res.Installed[0].Mods), []string{"mod:CoolMod.dll"}) {
+		t.Fatalf("InstallPackages = %+v", res)
+	}
+	pkgs, _ := g.Packages()
+	if pkgs["jane-coolmod"] != roots[0].Ref {
+		t.Errorf("Packages = %v", pkgs)
+	}
+}
+
+func sameSet(a, b []string) bool {
+	a, b = append([]string(nil), a...), append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	return reflect.DeepEqual(a, b)
+}
+
+func TestConflicts(t *testing.T) {
+	g := Game{Dir: t.TempDir()}
+	// MelonLoader's copies for both runtimes do not conflict with each other.
+	copyFixture(t, "MelonLoader.dll", filepath.Join(g.Dir, "MelonLoader", "net6", "MelonLoader.dll"))
+	copyFixture(t, "MelonLoader.dll", filepath.Join(g.Dir, "MelonLoader", "net35", "MelonLoader.dll"))
+	copyFixture(t, "MelonLoader.dll", filepath.Join(g.Dir, "UserLibs", "MelonLoader.dll"))
+	copyFixture(t, "CoolMod.dll", filepath.Join(g.Dir, "Mods", "CoolMod.dll"))
+	copyFixture(t, "CoolMod.dll", filepath.Join(g.Dir, "Plugins", "CoolModCopy.dll"))
+	copyFixture(t, "CoolMod.dll", filepath.Join(g.Dir, "Mods", "Disabled", "CoolModOld.dll"))
+	copyFixture(t, "LegacyPlugin.dll", filepath.Join(g.Dir, "Plugins", "LegacyPlugin.dll"))
+	write(t, filepath.Join(g.Dir, "UserLibs", "CoolMod.so"), "native")
+
+	cs, err := g.Conflicts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range cs {
+		var paths []string
+		for _, cp := range c.Copies {
+			paths = append(paths, cp.Path)
+		}
+		got = append(got, c.Kind+" "+c.Assembly+": "+strings.Join(paths, " "))
+	}
+	want := []string{
+		"duplicate CoolMod: Mods/CoolMod.dll Plugins/CoolModCopy.dll",
+		"loader MelonLoader: MelonLoader/net6/MelonLoader.dll MelonLoader/net35/MelonLoader.dll UserLibs/MelonLoader.dll",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("conflicts\n%q\nwant\n%q", got, want)
+	}
+	if cp := cs[0].Copies[0]; cp.Version != "1.2.3.0" || cp.PublicKeyToken != "" || cp.Kind != KindMod || cp.Name != "CoolMod.dll" {
+		t.Errorf("copy %+v", cp)
+	}
+
+	if _, err := g.Keep("MelonLoader.dll"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("keep by ambiguous name: %v", err)
+	}
+	if _, err := g.Keep("UserLibs/MelonLoader.dll"); err == nil || !strings.Contains(err.Error(), "always loads its own") {
+		t.Errorf("keep a copy of MelonLoader's library: %v", err)
+	}
+	if _, err := g.Keep("LegacyPlugin.dll"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("keep a file without conflicts: %v", err)
+	}
+	disabled, err := g.Keep("CoolModCopy.dll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := strings.Join(names(disabled), " "); s != "mod:CoolMod.dll (disabled)" {
+		t.Errorf("disabled %s", s)
+	}
+	if _, err := g.Keep("MelonLoader/net6/MelonLoader.dll"); err != nil {
+		t.Fatal(err)
+	}
+	if cs, err := g.Conflicts(); err != nil || len(cs) != 0 {
+		t.Errorf("conflicts after keeping %+v, %v", cs, err)
+	}
+}
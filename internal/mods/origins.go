@@ -0,0 +1,139 @@
+package mods
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"automelonloaderinstallergo/internal/ghrel"
+	"automelonloaderinstallergo/internal/version"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OriginsPath is the per-game mods file's path relative to the game directory.
+var OriginsPath = filepath.Join(".amlinstall", "mods.yaml")
+
+// ModsFile is the per-game mods file, which declares where mods are updated from.
+type ModsFile struct {
+	Mods []Origin `yaml:"mods" json:"mods"`
+}
+
+// Origin declares the GitHub releases a mod is published in.
+type Origin struct {
+	// Name identifies the installed file as Find matches it: its file name, with or
+	// without the extension, or its declared name.
+	Name string `yaml:"name" json:"name"`
+	Kind Kind   `yaml:"kind,omitempty" json:"kind,omitempty"`
+	// Repo is the repository as "owner/repo".
+	Repo string `yaml:"repo" json:"repo"`
+	// Asset is the release asset's name or a glob matching exactly one asset, e.g.
+	// "CoolMod-*.zip". A .zip asset must contain the mod's file.
+	Asset string `yaml:"asset" json:"asset"`
+	// Prerelease allows updating to prereleases.
+	Prerelease bool `yaml:"prerelease,omitempty" json:"prerelease,omitempty"`
+	// Constraint limits the versions updated to, e.g. "<2"; see version.ParseConstraint.
+	Constraint string `yaml:"constraint,omitempty" json:"constraint,omitempty"`
+}
+
+// OwnerRepo splits o.Repo.
+func (o Origin) OwnerRepo() (owner, repo string) {
+	owner, repo, _ = strings.Cut(o.Repo, "/")
+	return owner, repo
+}
+
+func (o *Origin) validate() error {
+	if o.Name == "" {
+		return errors.New("name is empty")
+	}
+	if owner, repo := o.OwnerRepo(); owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return fmt.Errorf("%s: repo %q is not owner/repo", o.Name, o.Repo)
+	}
+	if o.Asset == "" {
+		return fmt.Errorf("%s: asset is empty", o.Name)
+	}
+	if o.Kind != "" {
+		k, err := ParseKind(string(o.Kind))
+		if err != nil {
+			return fmt.Errorf("%s: %w", o.Name, err)
+		}
+		o.Kind = k
+	}
+	if o.Constraint != "" {
+		if _, err := version.ParseConstraint(o.Constraint); err != nil {
+			return fmt.Errorf("%s: %w", o.Name, err)
+		}
+	}
+	return nil
+}
+
+// matches reports whether o declares the origin of m.
+func (o Origin) matches(m Mod) bool {
+	if o.Kind != "" && o.Kind != m.Kind {
+		return false
+	}
+	base := strings.TrimSuffix(m.Name, filepath.Ext(m.Name))
+	return strings.EqualFold(o.Name, m.Name) || strings.EqualFold(o.Name, base) ||
+		(m.Info != nil && strings.EqualFold(o.Name, m.Info.Name))
+}
+
+// LoadModsFile reads the mods file of the game in dir. A missing file yields an
+// empty one.
+func LoadModsFile(dir string) (ModsFile, error) {
+	var f ModsFile
+	path := filepath.Join(dir, OriginsPath)
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return f, fmt.Errorf("read mods file: %w", err)
+	}
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return f, fmt.Errorf("parse mods file %s: %w", path, err)
+	}
+	for i := range f.Mods {
+		if err := f.Mods[i].validate(); err != nil {
+			return f, fmt.Errorf("mods file %s: %w", path, err)
+		}
+	}
+	return f, nil
+}
+
+// Save writes the mods file of the game in dir atomically. Comments in an edited
+// file are not kept.
+func (f ModsFile) Save(dir string) error {
+	b, err := yaml.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("encode mods file: %w", err)
+	}
+	return ghrel.WriteFileAtomically(filepath.Join(dir, OriginsPath), func(w *os.File) error {
+		_, err := w.Write(b)
+		return err
+	})
+}
+
+// SetOrigin adds o to the game's mods file, replacing an origin of the same name
+// and kind.
+func (g Game) SetOrigin(o Origin) error {
+	if err := o.validate(); err != nil {
+		return err
+	}
+	f, err := LoadModsFile(g.Dir)
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i, old := range f.Mods {
+		if strings.EqualFold(old.Name, o.Name) && old.Kind == o.Kind {
+			f.Mods[i], replaced = o, true
+		}
+	}
+	if !replaced {
+		f.Mods = append(f.Mods, o)
+	}
+	return f.Save(g.Dir)
+}
@@ -0,0 +1,463 @@
+package mods
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"automelonloaderinstallergo/internal/ghrel"
+	"automelonloaderinstallergo/internal/modscan"
+	"automelonloaderinstallergo/internal/thunderstore"
+)
+
+// packageSource prefixes the manifest Source of files installed from a
+// Thunderstore package, e.g. "thunderstore:Author-CoolMod-1.2.3".
+const packageSource = "thunderstore:"
+
+// PackageInstall reports the files a Thunderstore package installed.
+type PackageInstall struct {
+	Package thunderstore.Ref `json:"package"`
+	// Mods are the mods, plugins and user libraries, which the manifest tracks.
+	Mods []Mod `json:"mods"`
+	// Files are other files written, relative to the game directory, which the
+	// manifest records as PackageFiles. Files under UserData are only written if
+	// missing, so settings are kept.
+	Files []string `json:"files,omitempty"`
+}
+
+// PackagePlan is an install of Thunderstore packages with their dependencies.
+type PackagePlan struct {
+	Plan thunderstore.Plan `json:"plan"`
+	// Installed lists the packages installed, in Plan.Install order; it is empty
+	// for a plan that was not installed.
+	Installed []PackageInstall `json:"installed,omitempty"`
+	// Warnings are MelonLoader versions required by the packages that the
+	// installed MelonLoader does not satisfy.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// PackageOf returns the Thunderstore package a manifest Source names, if any.
+func PackageOf(source string) (thunderstore.Ref, bool) {
+	s, ok := strings.CutPrefix(source, packageSource)
+	if !ok {
+		return thunderstore.Ref{}, false
+	}
+	r, err := thunderstore.ParseRef(s)
+	return r, err == nil
+}
+
+// Packages returns the installed Thunderstore packages by thunderstore.Ref.Key,
+// as recorded in the manifest.
+func (g Game) Packages() (map[string]thunderstore.Ref, error) {
+	man, err := LoadManifest(g.Dir)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]thunderstore.Ref)
+	for _, e := range man.Files {
+		if r, ok := PackageOf(e.Source); ok {
+			out[r.Key()] = r
+		}
+	}
+	for _, f := range man.PackageFiles {
+		if r, ok := PackageOf(f.Source); ok {
+			out[r.Key()] = r
+		}
+	}
+	return out, nil
+}
+
+// PlanPackages resolves the dependencies of roots against the packages installed
+// in g, looking up packages with client, without installing anything.
+// Dependencies on MelonLoader are checked against the installed loader.
+func (g Game) PlanPackages(ctx context.Context, client *thunderstore.Client, roots []thunderstore.Node) (PackagePlan, error) {
+	installed, err := g.Packages()
+	if err != nil {
+		return PackagePlan{}, err
+	}
+	r := thunderstore.Resolver{
+		Fetch:     client.Package,
+		Installed: installed,
+		Skip:      thunderstore.Ref.IsMelonLoader,
+	}
+	plan, err := r.Resolve(ctx, roots)
+	if err != nil {
+		return PackagePlan{}, err
+	}
+
+	res := PackagePlan{Plan: plan}
+	target := g.Target()
+	for _, ref := range plan.Skipped {
+		for _, msg := range target.Check(&Info{Loader: &LoaderReq{Version: ref.Version, Minimum: true}}) {
+			res.Warnings = append(res.Warnings, "dependency "+ref.String()+": "+msg)
+		}
+	}
+	return res, nil
+}
+
+// InstallPackages resolves roots as PlanPackages does, then downloads the packages
+// of the plan that are not local archives and installs them with InstallPackage,
+// dependencies first.
+func (g Game) InstallPackages(ctx context.Context, client *thunderstore.Client, roots []thunderstore.Node, opts AddOptions) (PackagePlan, error) {
+	res, err := g.PlanPackages(ctx, client, roots)
+	if err != nil {
+		return res, err
+	}
+	tmp, err := os.MkdirTemp("", "amlinstall-packages-*")
+	if err != nil {
+		return res, err
+	}
+	defer os.RemoveAll(tmp)
+
+	for _, n := range res.Plan.Install {
+		archive := n.Path
+		if archive == "" {
+			archive = filepath.Join(tmp, n.Ref.String()+".zip")
+			if err := client.Download(ctx, *n.Version, archive); err != nil {
+				return res, err
+			}
+		}
+		pi, err := g.InstallPackage(archive, n.Ref, opts)
+		if err != nil {
+			return res, err
+		}
+		res.Installed = append(res.Installed, pi)
+	}
+	return res, nil
+}
+
+// packageFile is a file of a package archive and where it goes.
+type packageFile struct {
+	f    *zip.File
+	kind Kind   // set for tracked files, which are staged and added
+	dst  string // game-relative path of other files
+	keep bool   // do not overwrite an existing file
+}
+
+// InstallPackage installs the Thunderstore package archive at archive into the
+// game. Files under Mods/, Plugins/ and UserLibs/ in the archive go to those
+// folders, UserData/ to UserData/, and .dll files anywhere else to Mods/. The
+// package's metadata files and any MelonLoader/ folder are not installed, and
+// files under Mods/Disabled/ refuse the package.
+//
+// Every file is recorded in the manifest. Files an earlier install of the package
+// left that this version does not have are removed, as by RemovePackageFiles.
+//
+// Every melon is checked with Target.Check before anything is written, and the
+// package is refused with ErrIncompatible unless opts.Force is set. With
+// opts.Scan, every melon is also scanned first and a denied one refuses the
+// package. opts.Source and opts.Tag are set from ref.
+func (g Game) InstallPackage(archive string, ref thunderstore.Ref, opts AddOptions) (PackageInstall, error) {
+	res := PackageInstall{Package: ref}
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return res, fmt.Errorf("open package %s: %w", ref, err)
+	}
+	defer zr.Close()
+
+	var files []packageFile
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		pf, ok, err := placeFile(f)
+		if err != nil {
+			return res, fmt.Errorf("package %s: %w", ref, err)
+		}
+		if ok {
+			files = append(files, pf)
+		}
+	}
+
+	tmp, err := os.MkdirTemp("", "amlinstall-package-*")
+	if err != nil {
+		return res, err
+	}
+	defer os.RemoveAll(tmp)
+
+	// Stage and check every melon first, so an incompatible or denied package is
+	// not installed halfway.
+	target := g.Target()
+	staged := make([]string, len(files))
+	scans := make([]*modscan.Report, len(files))
+	for i, pf := range files {
+		if pf.kind == "" {
+			continue
+		}
+		staged[i] = filepath.Join(tmp, fmt.Sprint(i), path.Base(pf.f.Name))
+		if err := extractTo(pf.f, staged[i]); err != nil {
+			return res, fmt.Errorf("package %s: %w", ref, err)
+		}
+		m := Mod{Kind: pf.kind, Name: path.Base(pf.f.Name), Path: staged[i]}
+		if opts.Scan != nil {
+			rep, err := opts.Scan.ScanFile(staged[i])
+			if err != nil {
+				return res, fmt.Errorf("package %s: %w", ref, err)
+			}
+			if err := rep.Err(m.Name); err != nil {
+				return res, fmt.Errorf("package %s: %w", ref, err)
+			}
+			scans[i] = &rep
+		}
+		m.readInfo(target)
+		if len(m.Warnings) > 0 && !opts.Force {
+			return res, fmt.Errorf("%s in %s is %w with %s: %s; use force to install it anyway",
+				m.Name, ref, ErrIncompatible, g.label(), strings.Join(m.Warnings, "; "))
+		}
+	}
+
+	opts.Replace = true
+	opts.Source, opts.Tag = packageSource+ref.String(), ref.Version
+	opts.Scan = nil // scanned above
+	var written []PackageFile
+	placed := make(map[string]bool) // every other file of the package, written or kept
+	for i, pf := range files {
+		if pf.kind != "" {
+			m, err := g.add(staged[i], pf.kind, opts, ActionAdd)
+			if err != nil {
+				return res, fmt.Errorf("package %s: %w", ref, err)
+			}
+			m.Scan = scans[i]
+			res.Mods = append(res.Mods, m)
+			continue
+		}
+		rel := filepath.ToSlash(pf.dst)
+		placed[strings.ToLower(rel)] = true
+		dst := filepath.Join(g.Dir, pf.dst)
+		if pf.keep {
+			if _, err := os.Lstat(dst); err == nil {
+				continue
+			}
+		}
+		if err := extractTo(pf.f, dst); err != nil {
+			return res, fmt.Errorf("package %s: %w", ref, err)
+		}
+		sum, _, err := HashFile(dst)
+		if err != nil {
+			return res, fmt.Errorf("package %s: %w", ref, err)
+		}
+		written = append(written, PackageFile{Path: rel, SHA256: sum, Source: opts.Source})
+		res.Files = append(res.Files, pf.dst)
+	}
+	if err := g.replacePackage(ref, res.Mods, written, placed); err != nil {
+		return res, fmt.Errorf("package %s: %w", ref, err)
+	}
+	return res, nil
+}
+
+// replacePackage records the other files written by an install of the package
+// ref, and removes the files of an earlier install of it that are neither among
+// installed nor placed, keyed by lower-case path.
+func (g Game) replacePackage(ref thunderstore.Ref, installed []Mod, written []PackageFile, placed map[string]bool) error {
+	man, err := LoadManifest(g.Dir)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]bool)
+	for _, m := range installed {
+		current[string(m.Kind)+"/"+strings.ToLower(m.Name)] = true
+	}
+	for _, e := range man.Files {
+		if r, ok := PackageOf(e.Source); !ok || r.Key() != ref.Key() || current[string(e.Kind)+"/"+strings.ToLower(e.Name)] {
+			continue
+		}
+		if _, err := g.remove(e.Name, e.Kind, false); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+
+	if man, err = LoadManifest(g.Dir); err != nil {
+		return err
+	}
+	t := now().UTC()
+	kept := man.PackageFiles[:0]
+	for _, f := range man.PackageFiles {
+		p := strings.ToLower(f.Path)
+		switch r, ok := PackageOf(f.Source); {
+		case !ok || r.Key() != ref.Key():
+			kept = append(kept, f)
+		case !placed[p]:
+			if err := g.removePackageFile(f); err != nil {
+				return err
+			}
+			man.History = append(man.History, Event{Time: t, Action: ActionRemove, Name: f.Path})
+		case !slices.ContainsFunc(written, func(w PackageFile) bool { return strings.ToLower(w.Path) == p }):
+			kept = append(kept, f) // a kept UserData file this package wrote before
+		}
+	}
+	man.PackageFiles = kept
+	for _, f := range written {
+		man.PackageFiles = append(man.PackageFiles, f)
+		man.History = append(man.History, Event{Time: t, Action: ActionAdd, Name: f.Path})
+	}
+	return man.save(g.Dir)
+}
+
+// RemovePackageFiles removes the other files the Thunderstore package ref
+// installed, whatever its installed version, and returns their paths. Files
+// changed since, such as edited configs, are kept on disk.
+func (g Game) RemovePackageFiles(ref thunderstore.Ref) ([]string, error) {
+	man, err := LoadManifest(g.Dir)
+	if err != nil {
+		return nil, err
+	}
+	t := now().UTC()
+	var removed []string
+	kept := man.PackageFiles[:0]
+	for _, f := range man.PackageFiles {
+		if r, ok := PackageOf(f.Source); !ok || r.Key() != ref.Key() {
+			kept = append(kept, f)
+			continue
+		}
+		if err := g.removePackageFile(f); err != nil {
+			return removed, err
+		}
+		man.History = append(man.History, Event{Time: t, Action: ActionRemove, Name: f.Path})
+		removed = append(removed, f.Path)
+	}
+	if removed == nil {
+		return nil, nil
+	}
+	man.PackageFiles = kept
+	return removed, man.save(g.Dir)
+}
+
+// removePackageFile deletes the file of f unless it is missing or was changed
+// since it was installed.
+func (g Game) removePackageFile(f PackageFile) error {
+	path := filepath.Join(g.Dir, filepath.FromSlash(f.Path))
+	sum, _, err := HashFile(path)
+	if errors.Is(err, os.ErrNotExist) || err == nil && sum != f.SHA256 {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("remove %s: %w", f.Path, err)
+	}
+	return nil
+}
+
+// placeFile decides where a file of a package archive goes; ok is false for files
+// that are not installed.
+func placeFile(f *zip.File) (pf packageFile, ok bool, err error) {
+	name := strings.ReplaceAll(f.Name, `\`, "/")
+	if !filepath.IsLocal(filepath.FromSlash(name)) {
+		return pf, false, fmt.Errorf("unsafe path %q", f.Name)
+	}
+	pf.f = f
+	top, rest, nested := strings.Cut(name, "/")
+	base := path.Base(name)
+
+	if nested {
+		// Every kind's disabled folder is under Mods/Disabled, and List would show
+		// the files there as disabled mods.
+		if d := filepath.ToSlash(KindMod.DisabledDir()) + "/"; strings.HasPrefix(strings.ToLower(name), strings.ToLower(d)) {
+			return pf, false, fmt.Errorf("%s is under the disabled folder %s", f.Name, KindMod.DisabledDir())
+		}
+		for _, k := range Kinds {
+			if strings.EqualFold(top, k.Dir()) {
+				if !strings.Contains(rest, "/") && k.loads(rest) {
+					pf.kind = k
+				} else {
+					pf.dst = filepath.FromSlash(path.Join(k.Dir(), rest))
+				}
+				return pf, true, nil
+			}
+		}
+		switch {
+		case strings.EqualFold(top, "UserData"):
+			pf.dst, pf.keep = filepath.FromSlash(path.Join("UserData", rest)), true
+			return pf, true, nil
+		case strings.EqualFold(top, "MelonLoader"):
+			return pf, false, nil
+		}
+	}
+	if KindMod.loads(base) {
+		pf.kind = KindMod
+		return pf, true, nil
+	}
+	// Package metadata (manifest.json, icon.png, README.md) and stray files.
+	return pf, false, nil
+}
+
+// extractTo writes the archive file f to dst atomically, capped at maxExtract.
+func extractTo(f *zip.File, dst string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("extract %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	err = ghrel.WriteFileAtomically(dst, func(w *os.File) error {
+		n, err := io.Copy(w, io.LimitReader(rc, maxExtract+1))
+		if err == nil && n > maxExtract {
+			err = fmt.Errorf("larger than %d MiB", maxExtract>>20)
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("extract %s: %w", f.Name, err)
+	}
+	return nil
+}
+
+// SetPackageEnabled enables or disables the tracked files installed from the
+// Thunderstore package ref, whatever its installed version.
+func (g Game) SetPackageEnabled(ref thunderstore.Ref, enabled bool) ([]Mod, error) {
+	man, err := LoadManifest(g.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var changed []Mod
+	for _, e := range man.Files {
+		if r, ok := PackageOf(e.Source); !ok || r.Key() != ref.Key() {
+			continue
+		}
+		m, err := g.setEnabled(e.Name, e.Kind, enabled)
+		if err != nil {
+			return changed, err
+		}
+		changed = append(changed, m)
+	}
+	return changed, nil
+}
+
+// ImportUserData copies the files under UserData/ in the zip archive at archive,
+// such as a profile export's config files, into the game's UserData folder. Unlike
+// InstallPackage, it replaces existing files. It returns the game-relative paths
+// written.
+func (g Game) ImportUserData(archive string) ([]string, error) {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", archive, err)
+	}
+	defer zr.Close()
+
+	var written []string
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		pf, ok, err := placeFile(f)
+		if err != nil {
+			return written, err
+		}
+		// placeFile keeps exactly the files under UserData.
+		if !ok || !pf.keep {
+			continue
+		}
+		if err := extractTo(f, filepath.Join(g.Dir, pf.dst)); err != nil {
+			return written, err
+		}
+		written = append(written, pf.dst)
+	}
+	return written, nil
+}
@@ -0,0 +1,319 @@
+package mods
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"automelonloaderinstallergo/internal/modscan"
+	"automelonloaderinstallergo/internal/releases"
+	"automelonloaderinstallergo/internal/version"
+)
+
+// Update statuses.
+const (
+	UpdateCurrent   = "up-to-date"
+	UpdateAvailable = "available"
+	UpdateInstalled = "updated"
+	UpdateFailed    = "failed"
+)
+
+// maxExtract caps the size of a file extracted from a release archive.
+const maxExtract = 512 << 20
+
+// Update is the update state of a mod with a declared Origin.
+type Update struct {
+	Origin Origin `json:"origin"`
+	// Mod is the installed file; it is the zero Mod if none matches the origin.
+	Mod Mod `json:"mod"`
+	// Installed is the installed release tag, or the version the mod declares when
+	// it was not installed from a release.
+	Installed string `json:"installed,omitempty"`
+	// Tag and Asset are the newest release the origin allows and its asset.
+	Tag   string `json:"tag,omitempty"`
+	Asset string `json:"asset,omitempty"`
+	// Status is one of UpdateCurrent, UpdateAvailable, UpdateInstalled or
+	// UpdateFailed, with the failure in Error.
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (u *Update) fail(err error) {
+	u.Status, u.Error = UpdateFailed, err.Error()
+}
+
+// Updater updates mods from the releases declared in a game's mods file.
+type Updater struct {
+	Source releases.Source
+	// Token is the GitHub token passed to Source.
+	Token string
+	// Force installs updates that fail Target.Check.
+	Force bool
+	// Scan, if set, scans updates before they are installed; see AddOptions.Scan.
+	Scan *modscan.Scanner
+}
+
+// Check looks up the newest release of every origin in the game's mods file, or of
+// the origins of the mods named in names (see Find, which also takes kind). Failures
+// of single mods are reported in their Update; a name without an origin is an error.
+func (u Updater) Check(ctx context.Context, g Game, names []string, kind Kind) ([]Update, error) {
+	file, err := LoadModsFile(g.Dir)
+	if err != nil {
+		return nil, err
+	}
+	man, err := LoadManifest(g.Dir)
+	if err != nil {
+		return nil, err
+	}
+	all, err := g.List()
+	if err != nil {
+		return nil, err
+	}
+
+	origins := file.Mods
+	if len(names) > 0 {
+		origins = nil
+		for _, name := range names {
+			m, err := g.Find(name, kind)
+			if err != nil {
+				return nil, err
+			}
+			o, ok := originOf(file, m)
+			if !ok {
+				return nil, fmt.Errorf("origin of %s %w in %s", m.Name, ErrNotFound, OriginsPath)
+			}
+			origins = append(origins, o)
+		}
+	}
+
+	updates := make([]Update, 0, len(origins))
+	for _, o := range origins {
+		up := Update{Origin: o}
+		var found []Mod
+		for _, m := range all {
+			if o.matches(m) {
+				found = append(found, m)
+			}
+		}
+		switch len(found) {
+		case 0:
+			up.fail(fmt.Errorf("%q is not installed", o.Name))
+		case 1:
+			up.Mod = found[0]
+			up.Installed = installedVersion(man, up.Mod)
+			if err := u.check(ctx, &up); err != nil {
+				up.fail(err)
+			}
+		default:
+			up.fail(fmt.Errorf("%q matches %d files; set its kind", o.Name, len(found)))
+		}
+		updates = append(updates, up)
+	}
+	return updates, nil
+}
+
+// originOf returns the origin declared for m.
+func originOf(f ModsFile, m Mod) (Origin, bool) {
+	for _, o := range f.Mods {
+		if o.matches(m) {
+			return o, true
+		}
+	}
+	return Origin{}, false
+}
+
+// installedVersion returns the tag m was installed from, or the version it declares.
+func installedVersion(man Manifest, m Mod) string {
+	if e, ok := man.Entry(m.Kind, m.Name); ok && e.Tag != "" {
+		return e.Tag
+	}
+	if m.Info != nil {
+		return m.Info.Version
+	}
+	return ""
+}
+
+// check sets up.Tag to the newest release allowed by the origin, and up.Status.
+func (u Updater) check(ctx context.Context, up *Update) error {
+	o := up.Origin
+	owner, repo := o.OwnerRepo()
+	q := releases.TagQuery{NoPrerelease: !o.Prerelease}
+	if o.Constraint != "" {
+		c, err := version.ParseConstraint(o.Constraint)
+		if err != nil {
+			return err
+		}
+		q.Constraint = &c
+	}
+	tags, err := u.Source.ListTags(ctx, owner, repo, u.Token)
+	if err != nil {
+		return fmt.Errorf("list releases of %s: %w", o.Repo, err)
+	}
+	// Newest version first; nightly CI builds, which sort last, are never used.
+	for _, info := range releases.QueryTags(tags, q) {
+		if !info.Nightly {
+			up.Tag = info.Tag
+			break
+		}
+	}
+	if up.Tag == "" {
+		return fmt.Errorf("no release of %s matches", o.Repo)
+	}
+
+	up.Status = UpdateCurrent
+	if newer(up.Tag, up.Installed) {
+		up.Status = UpdateAvailable
+	}
+	return nil
+}
+
+// newer reports whether tag is newer than the installed version, which may be a
+// tag or a declared version. Versions that do not parse are compared as text.
+func newer(tag, installed string) bool {
+	if installed == "" {
+		return true
+	}
+	tv, err1 := version.Parse(tag)
+	iv, err2 := version.Parse(installed)
+	if err1 != nil || err2 != nil {
+		return version.NormalizeTag(tag) != version.NormalizeTag(installed)
+	}
+	return version.Compare(tv, iv) > 0
+}
+
+// Apply downloads and installs an available update in place of the installed
+// file, which keeps its name and enabled state. It sets up.Status, and up.Mod to
+// the new file; the error is also recorded in up.
+func (u Updater) Apply(ctx context.Context, g Game, up *Update) error {
+	if up.Status != UpdateAvailable {
+		return nil
+	}
+	m, err := u.apply(ctx, g, up)
+	if err != nil {
+		err = fmt.Errorf("update %s: %w", up.Mod.Name, err)
+		up.fail(err)
+		return err
+	}
+	up.Mod, up.Status = m, UpdateInstalled
+	return nil
+}
+
+func (u Updater) apply(ctx context.Context, g Game, up *Update) (Mod, error) {
+	o := up.Origin
+	owner, repo := o.OwnerRepo()
+	asset, err := releases.ResolveAsset(ctx, u.Source, owner, repo, up.Tag, o.Asset, u.Token)
+	if err != nil {
+		return Mod{}, err
+	}
+	up.Asset = asset
+
+	rel := Release{Repo: o.Repo, Tag: up.Tag, Asset: asset}
+	opts := AddOptions{Replace: true, Force: u.Force, Scan: u.Scan}
+	return g.addRelease(ctx, u.Source, u.Token, rel, up.Mod.Kind, up.Mod.Name, opts, ActionUpdate)
+}
+
+// Release names a release asset a file is installed from.
+type Release struct {
+	Repo  string // owner/repo
+	Tag   string
+	Asset string
+}
+
+// String returns the manifest Source of a file installed from r,
+// "owner/repo@tag/asset".
+func (r Release) String() string {
+	return r.Repo + "@" + r.Tag + "/" + r.Asset
+}
+
+// ReleaseOf returns the release a manifest Source names, if any.
+func ReleaseOf(source string) (Release, bool) {
+	repo, rest, ok := strings.Cut(source, "@")
+	if !ok || strings.Count(repo, "/") != 1 {
+		return Release{}, false
+	}
+	i := strings.LastIndex(rest, "/")
+	if i <= 0 || i == len(rest)-1 {
+		return Release{}, false
+	}
+	return Release{Repo: repo, Tag: rest[:i], Asset: rest[i+1:]}, true
+}
+
+// AddRelease downloads the release asset rel from src and adds the file named
+// name from it, like Add. A .zip asset must contain the file (see extractFile);
+// another asset is the file itself. opts.Source and opts.Tag are set from rel.
+func (g Game) AddRelease(ctx context.Context, src releases.Source, token string, rel Release, kind Kind, name string, opts AddOptions) (Mod, error) {
+	return g.addRelease(ctx, src, token, rel, kind, name, opts, ActionAdd)
+}
+
+func (g Game) addRelease(ctx context.Context, src releases.Source, token string, rel Release, kind Kind, name string, opts AddOptions, action string) (Mod, error) {
+	owner, repo, _ := strings.Cut(rel.Repo, "/")
+	tmp, err := os.MkdirTemp("", "amlinstall-mod-*")
+	if err != nil {
+		return Mod{}, err
+	}
+	defer os.RemoveAll(tmp)
+
+	// Sources write downloads atomically and verify them where they know a size or
+	// checksum, just as for MelonLoader itself.
+	dl := filepath.Join(tmp, "download")
+	if err := src.DownloadAsset(ctx, owner, repo, rel.Tag, rel.Asset, dl, token); err != nil {
+		return Mod{}, err
+	}
+
+	// Stage the file under its installed name, so add replaces it.
+	file := filepath.Join(tmp, "stage", name)
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return Mod{}, err
+	}
+	if strings.EqualFold(path.Ext(rel.Asset), ".zip") {
+		err = extractFile(dl, name, file)
+	} else {
+		err = os.Rename(dl, file)
+	}
+	if err != nil {
+		return Mod{}, err
+	}
+
+	opts.Source, opts.Tag = rel.String(), rel.Tag
+	return g.add(file, kind, opts, action)
+}
+
+// extractFile extracts the file named name from the zip archive at archive into
+// dst. Its directory within the archive does not matter; an archive holding a
+// single .dll of another name yields that one.
+func extractFile(archive, name, dst string) error {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+	defer zr.Close()
+
+	var match, dlls []*zip.File
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		base := path.Base(f.Name)
+		if strings.EqualFold(base, name) {
+			match = append(match, f)
+		}
+		if strings.EqualFold(path.Ext(base), ".dll") {
+			dlls = append(dlls, f)
+		}
+	}
+	if len(match) == 0 && len(dlls) == 1 {
+		match = dlls
+	}
+	switch {
+	case len(match) == 0:
+		return fmt.Errorf("archive has no %s", name)
+	case len(match) > 1:
+		return fmt.Errorf("archive has %d files named %s", len(match), name)
+	}
+
+	return extractTo(match[0], dst)
+}
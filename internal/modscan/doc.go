@@ -0,0 +1,19 @@
+// Package modscan checks mod assemblies for references that deserve a second look
+// before the mod is installed, such as starting processes, network access, calls
+// into the Windows kernel and code loaded from memory.
+//
+// The scan is static: it matches the references recorded in an assembly's
+// metadata (see clrmeta.References) against rules, and runs nothing. A reference
+// is written as one of
+//
+//	System.Net.Http.HttpClient                  a type
+//	System.Diagnostics.Process::Start(string)   a method, or a field without "(...)"
+//	assembly:System.Net.Http                    a referenced assembly
+//	pinvoke:kernel32.dll!VirtualAlloc           a P/Invoke
+//	resource:payload.bin                        an embedded resource
+//	native-resource:payload.bin                 an embedded executable or library
+//
+// and rules are patterns in which "*" matches any text, compared
+// case-insensitively. Mods are arbitrary code: a clean report does not make a mod
+// safe, and code can reach the same features through reflection.
+package modscan
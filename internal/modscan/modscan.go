@@ -0,0 +1,236 @@
+package modscan
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"automelonloaderinstallergo/internal/clrmeta"
+)
+
+// ErrDenied reports a file with a reference a deny rule matches.
+var ErrDenied = errors.New("denied")
+
+// Rule is a pattern of risky references and what they let a mod do.
+type Rule struct {
+	Pattern string `json:"pattern"`
+	Why     string `json:"why"`
+}
+
+// DefaultRules are the references reported by every scan.
+var DefaultRules = []Rule{
+	{"System.Diagnostics.Process::Start*", "starts other programs"},
+	{"System.Net.Http.*", "makes HTTP requests"},
+	{"System.Net.WebClient*", "makes HTTP requests"},
+	{"System.Net.WebRequest*", "makes HTTP requests"},
+	{"System.Net.HttpWebRequest*", "makes HTTP requests"},
+	{"System.Net.Sockets.*", "opens network connections"},
+	{"System.Reflection.Assembly::Load(byte[]*", "loads code from memory"},
+	{"pinvoke:kernel32*", "calls the Windows kernel directly"},
+	{"pinvoke:ntdll*", "calls the Windows kernel directly"},
+	{"native-resource:*", "embeds a native program or library"},
+	{"Microsoft.Win32.Registry*", "accesses the Windows registry"},
+}
+
+// denyWhy describes references only a deny rule matches.
+const denyWhy = "matches a deny rule"
+
+// Scanner matches assemblies against DefaultRules and the user's rules.
+type Scanner struct {
+	// Allow are patterns of references that are never reported.
+	Allow []string
+	// Deny are patterns of references that keep a mod from being installed.
+	Deny []string
+}
+
+// Report is the result of scanning one file.
+type Report struct {
+	// Native is set for files that are not .NET assemblies, such as native
+	// libraries, which cannot be scanned.
+	Native   bool      `json:"native,omitempty"`
+	Findings []Finding `json:"findings"`
+}
+
+// Finding lists the references of a file that one rule matches.
+type Finding struct {
+	Rule string `json:"rule"`
+	Why  string `json:"why"`
+	// Denied is set when Rule is a deny rule.
+	Denied bool     `json:"denied,omitempty"`
+	Refs   []string `json:"refs"`
+}
+
+func (f Finding) String() string {
+	return f.Why + ": " + strings.Join(f.Refs, ", ")
+}
+
+// Denied returns the findings of deny rules.
+func (r Report) Denied() []Finding {
+	var out []Finding
+	for _, f := range r.Findings {
+		if f.Denied {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Err returns an error wrapping ErrDenied that names the deny rules matching the
+// file name, or nil.
+func (r Report) Err(name string) error {
+	denied := r.Denied()
+	if len(denied) == 0 {
+		return nil
+	}
+	var why []string
+	for _, f := range denied {
+		why = append(why, fmt.Sprintf("%q (%s)", f.Rule, f))
+	}
+	return fmt.Errorf("%s is %w by scan rule %s; add a scan.allow rule to install it anyway",
+		name, ErrDenied, strings.Join(why, ", "))
+}
+
+// ScanFile scans the file at path. Files that are not .NET assemblies yield a
+// Native report.
+func (s Scanner) ScanFile(path string) (Report, error) {
+	a, err := clrmeta.ReadFile(path)
+	if errors.Is(err, clrmeta.ErrNotAssembly) {
+		return Report{Native: true, Findings: []Finding{}}, nil
+	}
+	if err != nil {
+		return Report{}, err
+	}
+	return s.Scan(a), nil
+}
+
+// Scan matches the references of a. Each reference is reported once: not at all
+// if an allow rule matches it, else under the first deny rule, else under the
+// first of DefaultRules that matches it. Findings are in rule order.
+func (s Scanner) Scan(a clrmeta.Assembly) Report {
+	type hit struct {
+		refs []string
+		seen map[string]bool
+	}
+	deny := make([]hit, len(s.Deny))
+	def := make([]hit, len(DefaultRules))
+	add := func(h *hit, ref string) {
+		if h.seen == nil {
+			h.seen = map[string]bool{}
+		}
+		if !h.seen[ref] {
+			h.seen[ref] = true
+			h.refs = append(h.refs, ref)
+		}
+	}
+
+	for _, ref := range refStrings(a.References) {
+		if matchAny(s.Allow, ref) >= 0 {
+			continue
+		}
+		if i := matchAny(s.Deny, ref); i >= 0 {
+			add(&deny[i], ref)
+			continue
+		}
+		for i, r := range DefaultRules {
+			if match(r.Pattern, ref) {
+				add(&def[i], ref)
+				break
+			}
+		}
+	}
+
+	rep := Report{Findings: []Finding{}}
+	for i, h := range deny {
+		if len(h.refs) == 0 {
+			continue
+		}
+		f := Finding{Rule: s.Deny[i], Why: denyWhy, Denied: true, Refs: members(h.refs)}
+		// A deny rule for what a default rule reports keeps its description.
+		for _, r := range DefaultRules {
+			if match(r.Pattern, f.Refs[0]) {
+				f.Why = r.Why
+				break
+			}
+		}
+		rep.Findings = append(rep.Findings, f)
+	}
+	for i, h := range def {
+		if len(h.refs) > 0 {
+			r := DefaultRules[i]
+			rep.Findings = append(rep.Findings, Finding{Rule: r.Pattern, Why: r.Why, Refs: members(h.refs)})
+		}
+	}
+	return rep
+}
+
+// refStrings writes the references of r in the forms the package doc lists.
+func refStrings(r clrmeta.References) []string {
+	var out []string
+	for _, a := range r.Assemblies {
+		out = append(out, "assembly:"+a)
+	}
+	out = append(out, r.Types...)
+	out = append(out, r.Members...)
+	for _, p := range r.PInvokes {
+		out = append(out, "pinvoke:"+p.Module+"!"+p.Entry)
+	}
+	for _, res := range r.Resources {
+		out = append(out, "resource:"+res.Name)
+		if res.Native {
+			out = append(out, "native-resource:"+res.Name)
+		}
+	}
+	return out
+}
+
+// members drops the types of refs that also list a member of the type, which says
+// more about what the file does.
+func members(refs []string) []string {
+	var out []string
+	for _, ref := range refs {
+		used := false
+		for _, other := range refs {
+			if strings.HasPrefix(other, ref+"::") {
+				used = true
+				break
+			}
+		}
+		if !used {
+			out = append(out, ref)
+		}
+	}
+	return out
+}
+
+// matchAny returns the index of the first pattern matching s, or -1.
+func matchAny(patterns []string, s string) int {
+	for i, p := range patterns {
+		if match(p, s) {
+			return i
+		}
+	}
+	return -1
+}
+
+// match reports whether s matches pattern case-insensitively, where "*" matches
+// any text and every other character itself.
+func match(pattern, s string) bool {
+	pattern, s = strings.ToLower(strings.TrimSpace(pattern)), strings.ToLower(s)
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, p := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, p)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(p):]
+	}
+	return strings.HasSuffix(s, last)
+}
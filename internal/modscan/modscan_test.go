@@ -0,0 +1,105 @@
+package modscan
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func fixture(name string) string {
+	return filepath.Join("..", "clrmeta", "testdata", name)
+}
+
+func rules(r Report) []string {
+	var out []string
+	for _, f := range r.Findings {
+		s := f.Rule + " " + strings.Join(f.Refs, ", ")
+		if f.Denied {
+			s = "deny " + s
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func TestScan(t *testing.T) {
+	r, err := Scanner{}.ScanFile(fixture("RiskyMod.dll"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"System.Diagnostics.Process::Start* System.Diagnostics.Process::Start(string)",
+		"System.Net.Http.* System.Net.Http.HttpClient::.ctor(), System.Net.Http.HttpClient::GetStringAsync(string)",
+		"System.Reflection.Assembly::Load(byte[]* System.Reflection.Assembly::Load(byte[])",
+		"pinvoke:kernel32* pinvoke:kernel32.dll!VirtualAlloc",
+		"native-resource:* native-resource:payload.bin",
+	}
+	if got := rules(r); !reflect.DeepEqual(got, want) {
+		t.Errorf("findings\n%q\nwant\n%q", got, want)
+	}
+	if r.Err("RiskyMod.dll") != nil {
+		t.Error("denied without deny rules")
+	}
+
+	clean, err := Scanner{}.ScanFile(fixture("CoolMod.dll"))
+	if err != nil || len(clean.Findings) != 0 || clean.Native {
+		t.Errorf("CoolMod.dll: %+v, %v", clean, err)
+	}
+
+	native := filepath.Join(t.TempDir(), "native.dll")
+	if err := os.WriteFile(native, []byte("\x7fELF"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if r, err := (Scanner{}).ScanFile(native); err != nil || !r.Native {
+		t.Errorf("native library: %+v, %v", r, err)
+	}
+}
+
+func TestScanRules(t *testing.T) {
+	s := Scanner{
+		Allow: []string{"System.Net.Http.*", "native-resource:PAYLOAD.BIN"},
+		Deny:  []string{"System.Diagnostics.Process::*", "resource:*.txt"},
+	}
+	r, err := s.ScanFile(fixture("RiskyMod.dll"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"deny System.Diagnostics.Process::* System.Diagnostics.Process::Start(string)",
+		"deny resource:*.txt resource:readme.txt",
+		"System.Reflection.Assembly::Load(byte[]* System.Reflection.Assembly::Load(byte[])",
+		"pinvoke:kernel32* pinvoke:kernel32.dll!VirtualAlloc",
+	}
+	if got := rules(r); !reflect.DeepEqual(got, want) {
+		t.Errorf("findings\n%q\nwant\n%q", got, want)
+	}
+	if r.Findings[0].Why != "starts other programs" || r.Findings[1].Why != denyWhy {
+		t.Errorf("why %q, %q", r.Findings[0].Why, r.Findings[1].Why)
+	}
+	err = r.Err("RiskyMod.dll")
+	if !errors.Is(err, ErrDenied) || !strings.Contains(err.Error(), `"resource:*.txt" (matches a deny rule: resource:readme.txt)`) {
+		t.Errorf("error %v", err)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	for _, c := range []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"System.Reflection.Assembly::Load(byte[]*", "System.Reflection.Assembly::Load(byte[])", true},
+		{"System.Reflection.Assembly::Load(byte[]*", "System.Reflection.Assembly::Load(string)", false},
+		{"pinvoke:KERNEL32*", "pinvoke:kernel32.dll!VirtualAlloc", true},
+		{"pinvoke:*!Virtual*", "pinvoke:kernel32.dll!VirtualAlloc", true},
+		{"a*b*b", "ab", false},
+		{"exact", "exact", true},
+		{"exact", "exactly", false},
+	} {
+		if got := match(c.pattern, c.s); got != c.want {
+			t.Errorf("match(%q, %q) = %v", c.pattern, c.s, got)
+		}
+	}
+}
@@ -0,0 +1,5 @@
+// Package oci implements a minimal OCI distribution (registry v2) client.
+// It supports listing tags, fetching image manifests and downloading blobs with
+// digest verification, authenticating with basic credentials or bearer tokens
+// obtained from the registry's token service.
+package oci
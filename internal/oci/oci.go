@@ -0,0 +1,359 @@
+package oci
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"automelonloaderinstallergo/internal/ghrel"
+)
+
+// Media types accepted when fetching manifests.
+const (
+	MediaTypeImageManifest  = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// AnnotationTitle names the file a layer represents, as set by tools such as oras.
+const AnnotationTitle = "org.opencontainers.image.title"
+
+// Descriptor references a blob by digest.
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Title returns the layer's file name annotation, if any.
+func (d Descriptor) Title() string {
+	return d.Annotations[AnnotationTitle]
+}
+
+// Manifest models the fields of an image manifest used to locate artifact files.
+type Manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	Config        Descriptor        `json:"config"`
+	Layers        []Descriptor      `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// Client talks to a single registry.
+type Client struct {
+	http     *http.Client
+	baseURL  string
+	username string
+	password string
+
+	mu     sync.Mutex
+	tokens map[string]string // scope -> bearer token
+}
+
+// NewClient returns a client for the registry at baseURL (e.g. "https://registry.example:5000").
+// username and password are optional and are used for basic auth or to obtain bearer tokens.
+func NewClient(baseURL, username, password string) *Client {
+	return &Client{
+		http:     &http.Client{Timeout: 60 * time.Second},
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		username: username,
+		password: password,
+		tokens:   make(map[string]string),
+	}
+}
+
+// Tags lists the tags of repository name, following the registry's
+// Link rel="next" header across pages.
+func (c *Client) Tags(ctx context.Context, name string) ([]string, error) {
+	var tags []string
+	seen := make(map[string]bool)
+	for path := "/v2/" + name + "/tags/list"; path != ""; {
+		if seen[path] {
+			return nil, fmt.Errorf("list tags: pagination loop at %s", path)
+		}
+		seen[path] = true
+
+		page, next, err := c.tagsPage(ctx, name, path)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, page...)
+		path = next
+	}
+	return tags, nil
+}
+
+// tagsPage fetches one page of tags and returns the path of the next page, if any.
+func (c *Client) tagsPage(ctx context.Context, name, path string) ([]string, string, error) {
+	resp, err := c.get(ctx, name, path, "")
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", ghrel.NewStatusError("list tags", resp)
+	}
+
+	var body struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, "", fmt.Errorf("decode tags JSON: %w", err)
+	}
+	next, err := nextLink(resp.Header.Get("Link"))
+	if err != nil {
+		return nil, "", err
+	}
+	return body.Tags, next, nil
+}
+
+// nextLink returns the path and query of the rel="next" target in a Link
+// header, or "" when there is none. Registries send it relative to their
+// root, but an absolute URL is reduced to its request URI.
+func nextLink(header string) (string, error) {
+	for _, link := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+		if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		next := false
+		for _, p := range strings.Split(params, ";") {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if strings.EqualFold(k, "rel") && strings.Trim(v, `"`) == "next" {
+				next = true
+			}
+		}
+		if !next {
+			continue
+		}
+		u, err := url.Parse(strings.Trim(target, "<>"))
+		if err != nil {
+			return "", fmt.Errorf("parse Link header: %w", err)
+		}
+		return u.RequestURI(), nil
+	}
+	return "", nil
+}
+
+// Manifest fetches the image manifest for reference (a tag or digest).
+func (c *Client) Manifest(ctx context.Context, name, reference string) (Manifest, error) {
+	var m Manifest
+
+	accept := MediaTypeImageManifest + ", " + MediaTypeDockerManifest
+	resp, err := c.get(ctx, name, "/v2/"+name+"/manifests/"+url.PathEscape(reference), accept)
+	if err != nil {
+		return m, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return m, ghrel.NewStatusError("fetch manifest", resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+		return m, fmt.Errorf("decode manifest JSON: %w", err)
+	}
+	if m.SchemaVersion != 2 {
+		return m, fmt.Errorf("unsupported manifest schemaVersion %d", m.SchemaVersion)
+	}
+	if m.MediaType != "" && m.MediaType != MediaTypeImageManifest && m.MediaType != MediaTypeDockerManifest {
+		return m, fmt.Errorf("unsupported manifest media type %q", m.MediaType)
+	}
+	return m, nil
+}
+
+// FetchBlob streams the blob described by desc into w, verifying its size and digest.
+// On mismatch an error is returned after the data has been written, so callers
+// should write to a temporary destination.
+func (c *Client) FetchBlob(ctx context.Context, name string, desc Descriptor, w io.Writer) error {
+	algo, want, ok := strings.Cut(desc.Digest, ":")
+	if !ok || algo != "sha256" {
+		return fmt.Errorf("unsupported digest %q", desc.Digest)
+	}
+
+	resp, err := c.get(ctx, name, "/v2/"+name+"/blobs/"+desc.Digest, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ghrel.NewStatusError("fetch blob", resp)
+	}
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, h), resp.Body)
+	if err != nil {
+		return fmt.Errorf("stream blob: %w", err)
+	}
+	if desc.Size > 0 && n != desc.Size {
+		return fmt.Errorf("blob %s: size mismatch: got %d bytes, want %d", desc.Digest, n, desc.Size)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("blob %s: digest mismatch: got sha256:%s", desc.Digest, got)
+	}
+	return nil
+}
+
+// get issues a GET for path, answering at most one authentication challenge.
+func (c *Client) get(ctx context.Context, name, path, accept string) (*http.Response, error) {
+	scope := "repository:" + name + ":pull"
+
+	do := func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		c.authorize(req, scope)
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("registry request: %w", err)
+		}
+		return resp, nil
+	}
+
+	resp, err := do()
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	_ = resp.Body.Close()
+
+	scheme, params := parseChallenge(challenge)
+	switch scheme {
+	case "bearer":
+		tokenScope := scope
+		if params["scope"] != "" {
+			tokenScope = params["scope"]
+		}
+		token, err := c.fetchToken(ctx, params["realm"], params["service"], tokenScope)
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		c.tokens[scope] = token
+		c.mu.Unlock()
+	case "basic":
+		// Basic credentials, when configured, were already sent with the first request.
+		if c.username == "" {
+			return nil, fmt.Errorf("registry requires basic auth but no credentials are configured")
+		}
+		return nil, fmt.Errorf("registry rejected the configured credentials")
+	default:
+		return nil, fmt.Errorf("registry: unsupported auth challenge %q", challenge)
+	}
+
+	return do()
+}
+
+// authorize attaches a cached bearer token for scope, or basic credentials.
+func (c *Client) authorize(req *http.Request, scope string) {
+	c.mu.Lock()
+	token := c.tokens[scope]
+	c.mu.Unlock()
+
+	switch {
+	case token != "":
+		req.Header.Set("Authorization", "Bearer "+token)
+	case c.username != "":
+		req.SetBasicAuth(c.username, c.password)
+	}
+}
+
+// fetchToken exchanges credentials for a bearer token at the registry token service.
+func (c *Client) fetchToken(ctx context.Context, realm, service, scope string) (string, error) {
+	if realm == "" {
+		return "", fmt.Errorf("registry: bearer challenge without realm")
+	}
+	u, err := url.Parse(realm)
+	if err != nil {
+		return "", fmt.Errorf("registry: parse token realm: %w", err)
+	}
+	q := u.Query()
+	if service != "" {
+		q.Set("service", service)
+	}
+	q.Set("scope", scope)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetch registry token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", ghrel.NewStatusError("fetch registry token", resp)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decode registry token JSON: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return "", fmt.Errorf("registry token response contains no token")
+}
+
+// parseChallenge parses a WWW-Authenticate header such as
+//
+//	Bearer realm="https://auth.example/token",service="registry",scope="repository:x:pull"
+//
+// returning the lower-cased scheme and its parameters.
+func parseChallenge(h string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(h), " ")
+	params := make(map[string]string)
+
+	for rest != "" {
+		rest = strings.TrimLeft(rest, " ,")
+		key, after, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		var val string
+		if strings.HasPrefix(after, `"`) {
+			end := strings.IndexByte(after[1:], '"')
+			if end < 0 {
+				val, rest = after[1:], ""
+			} else {
+				val, rest = after[1:1+end], after[2+end:]
+			}
+		} else {
+			val, rest, _ = strings.Cut(after, ",")
+		}
+		params[key] = val
+	}
+
+	return strings.ToLower(scheme), params
+}
@@ -0,0 +1,4 @@
+// Package output writes command results as human-readable text or as structured
+// JSON, YAML or newline-delimited JSON for scripts, and reports failures as objects
+// carrying a stable error code so callers need not parse messages.
+package output
@@ -0,0 +1,215 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"automelonloaderinstallergo/internal/redact"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format selects how a Printer writes results.
+type Format string
+
+// Supported formats.
+const (
+	Text   Format = "text"
+	JSON   Format = "json"
+	YAML   Format = "yaml"
+	NDJSON Format = "ndjson"
+)
+
+// Formats lists every supported format.
+var Formats = []Format{Text, JSON, YAML, NDJSON}
+
+// ParseFormat parses a format name case-insensitively. An empty name means Text.
+func ParseFormat(s string) (Format, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return Text, nil
+	}
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = string(f)
+	}
+	return "", Usage(fmt.Errorf("unknown output format %q (want %s)", s, strings.Join(names, ", ")))
+}
+
+// Error codes reported in structured error objects.
+const (
+	CodeUsage        = "usage"
+	CodeNotFound     = "not_found"
+	CodeIncompatible = "incompatible"
+	CodeDenied       = "denied"
+	CodeRateLimited  = "rate_limited"
+	CodeUnavailable  = "unavailable"
+	CodeUnsupported  = "unsupported"
+	CodeTimeout      = "timeout"
+	CodeCanceled     = "canceled"
+	CodeError        = "error"
+)
+
+// usageError marks an invalid invocation: unknown commands, flags or arguments.
+type usageError struct{ err error }
+
+func (e *usageError) Error() string { return e.err.Error() }
+func (e *usageError) Unwrap() error { return e.err }
+
+// Usage marks err as an invalid invocation, reported with CodeUsage.
+func Usage(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &usageError{err: err}
+}
+
+// codes maps the sentinel errors of other packages to their error codes; see
+// RegisterCode.
+var codes []struct {
+	err  error
+	code string
+}
+
+// RegisterCode makes Code report code for errors matching target, such as a
+// package's ErrNotFound. Targets are tried in the order they were registered. It
+// is meant to be called during initialization.
+func RegisterCode(target error, code string) {
+	codes = append(codes, struct {
+		err  error
+		code string
+	}{target, code})
+}
+
+// Code returns the error code for err. Failures without a more specific category
+// are reported as CodeError.
+func Code(err error) string {
+	var ue *usageError
+	if errors.As(err, &ue) {
+		return CodeUsage
+	}
+	for _, c := range codes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	switch {
+	case errors.Is(err, errors.ErrUnsupported):
+		return CodeUnsupported
+	case errors.Is(err, context.DeadlineExceeded):
+		return CodeTimeout
+	case errors.Is(err, context.Canceled):
+		return CodeCanceled
+	default:
+		return CodeError
+	}
+}
+
+// ErrorInfo is the structured form of a failed command.
+type ErrorInfo struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Printer writes results in one Format.
+type Printer struct {
+	w      io.Writer
+	format Format
+}
+
+// NewPrinter returns a Printer writing to w in format f.
+func NewPrinter(w io.Writer, f Format) *Printer {
+	return &Printer{w: w, format: f}
+}
+
+// Format returns the printer's format.
+func (p *Printer) Format() Format { return p.format }
+
+// Structured reports whether the printer emits machine-readable output.
+func (p *Printer) Structured() bool { return p.format != Text }
+
+// Print writes v. In Text format it calls text instead. Slices are written as a
+// JSON array or YAML sequence, and as one line per element in NDJSON.
+func (p *Printer) Print(v any, text func(w io.Writer) error) error {
+	switch p.format {
+	case JSON:
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case NDJSON:
+		enc := json.NewEncoder(p.w)
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice {
+			return enc.Encode(v)
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if err := enc.Encode(rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	case YAML:
+		out, err := toYAML(v)
+		if err != nil {
+			return err
+		}
+		_, err = p.w.Write(out)
+		return err
+	default:
+		return text(p.w)
+	}
+}
+
+// PrintError writes err as {"error": {"code": ..., "message": ...}}, with secrets
+// redacted from the message. It must not be used in Text format.
+func (p *Printer) PrintError(err error) error {
+	obj := struct {
+		Error ErrorInfo `json:"error"`
+	}{ErrorInfo{Code: Code(err), Message: redact.String(err.Error())}}
+	return p.Print(obj, nil)
+}
+
+// toYAML encodes v as block-style YAML with the same keys and field order as its
+// JSON encoding, so every structured format shares one schema.
+func toYAML(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	clearStyle(&doc)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// clearStyle drops the flow and quoting styles picked up from JSON so the encoder
+// chooses plain block YAML, quoting only where required.
+func clearStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		clearStyle(c)
+	}
+}
@@ -0,0 +1,8 @@
+// Package redact scrubs secrets from text before it reaches logs, the terminal or
+// the TUI. It recognises GitHub token formats, Authorization header values, URL
+// credentials and signed URL query parameters (such as S3's X-Amz-Signature), plus
+// any exact secret values registered at runtime with AddSecret.
+//
+// It also provides an opt-in HTTP transport that logs request and response
+// metadata with the same redaction applied.
+package redact
@@ -0,0 +1,83 @@
+package redact
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// traceHeaders lists the headers whose (redacted) values are included in traces.
+var traceHeaders = []string{
+	"Accept",
+	"Authorization",
+	"Content-Length",
+	"Content-Type",
+	"Location",
+	"WWW-Authenticate",
+	"X-GitHub-Request-Id",
+	"X-RateLimit-Remaining",
+	"X-RateLimit-Reset",
+}
+
+// TraceTransport wraps base so that every request and response is reported to logf
+// as a message and key/value pairs. URLs and header values are redacted, and
+// Authorization values are never logged. Bodies are not read.
+// If base is nil, http.DefaultTransport is used.
+func TraceTransport(base http.RoundTripper, logf func(msg any, keyvals ...any)) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return traceTransport{base: base, logf: logf}
+}
+
+type traceTransport struct {
+	base http.RoundTripper
+	logf func(msg any, keyvals ...any)
+}
+
+func (t traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	t.logf("http request",
+		"method", req.Method,
+		"url", String(req.URL.String()),
+		"headers", headerSummary(req.Header),
+	)
+
+	resp, err := t.base.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		t.logf("http error",
+			"method", req.Method,
+			"url", String(req.URL.String()),
+			"elapsed", elapsed,
+			"err", String(err.Error()),
+		)
+		return resp, err
+	}
+
+	t.logf("http response",
+		"method", req.Method,
+		"url", String(req.URL.String()),
+		"status", resp.Status,
+		"elapsed", elapsed,
+		"headers", headerSummary(resp.Header),
+	)
+	return resp, nil
+}
+
+// headerSummary renders the traced headers as "Name=value" pairs.
+func headerSummary(h http.Header) string {
+	var parts []string
+	for _, name := range traceHeaders {
+		v := h.Get(name)
+		if v == "" {
+			continue
+		}
+		if name == "Authorization" {
+			scheme, _, _ := strings.Cut(v, " ")
+			v = scheme + " " + Placeholder
+		}
+		parts = append(parts, name+"="+String(v))
+	}
+	return strings.Join(parts, " ")
+}
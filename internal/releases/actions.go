@@ -0,0 +1,226 @@
+package releases
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"automelonloaderinstallergo/internal/ghrel"
+)
+
+// NightlyTagPrefix prefixes the synthetic tags reported by the Actions source.
+// The remainder of the tag is the workflow run ID, e.g. "nightly-9876543210".
+const NightlyTagPrefix = "nightly-"
+
+// IsNightlyTag reports whether tag names a CI build rather than a release.
+func IsNightlyTag(tag string) bool {
+	_, ok := NightlyRunID(tag)
+	return ok
+}
+
+// NightlyRunID extracts the workflow run ID from a nightly tag.
+func NightlyRunID(tag string) (int64, bool) {
+	rest, ok := strings.CutPrefix(tag, NightlyTagPrefix)
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(rest, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// NightlyTag formats the synthetic tag for workflow run runID.
+func NightlyTag(runID int64) string {
+	return NightlyTagPrefix + strconv.FormatInt(runID, 10)
+}
+
+type actionsSource struct {
+	client     *http.Client
+	apiBaseURL string
+	branch     string
+	limit      int
+}
+
+// NewActionsSource returns a releases.Source that exposes successful GitHub Actions
+// workflow runs as nightly builds. Each run is reported as a NightlyTag and its
+// artifacts as assets. If branch is empty, runs on every branch are listed.
+//
+// Artifact downloads always require a GitHub token, even for public repositories.
+func NewActionsSource(branch string) Source {
+	return actionsSource{
+		client:     ghrel.NewGitHubClient(),
+		apiBaseURL: ghrel.APIBaseURL,
+		branch:     branch,
+		limit:      20,
+	}
+}
+
+func (s actionsSource) ListTags(ctx context.Context, owner, repo, githubToken string) ([]string, error) {
+	runs, err := ghrel.ListSuccessfulRuns(ctx, s.client, s.apiBaseURL, owner, repo, s.branch, githubToken, s.limit)
+	if err != nil {
+		return nil, classify(err)
+	}
+
+	tags := make([]string, 0, len(runs))
+	for _, r := range runs {
+		tags = append(tags, NightlyTag(r.ID))
+	}
+	return tags, nil
+}
+
+// TagDates reports when each listed workflow run was created.
+func (s actionsSource) TagDates(ctx context.Context, owner, repo, githubToken string) (map[string]time.Time, error) {
+	runs, err := ghrel.ListSuccessfulRuns(ctx, s.client, s.apiBaseURL, owner, repo, s.branch, githubToken, s.limit)
+	if err != nil {
+		return nil, classify(err)
+	}
+	dates := make(map[string]time.Time, len(runs))
+	for _, r := range runs {
+		dates[NightlyTag(r.ID)] = r.CreatedAt
+	}
+	return dates, nil
+}
+
+func (s actionsSource) ListAssets(ctx context.Context, owner, repo, tag, githubToken string) ([]Asset, error) {
+	artifacts, err := s.artifacts(ctx, owner, repo, tag, githubToken)
+	if err != nil {
+		return nil, err
+	}
+
+	assets := make([]Asset, 0, len(artifacts))
+	for _, a := range artifacts {
+		assets = append(assets, Asset{Name: a.Name, URL: a.ArchiveDownloadURL, Size: a.SizeInBytes})
+	}
+	return assets, nil
+}
+
+// DownloadAsset downloads the artifact named assetName from the run identified by tag.
+//
+// GitHub serves artifacts as zip archives. An archive holding a single file (such as a
+// zipped MelonLoader build) is unwrapped so outPath receives that file; otherwise the
+// archive itself is written to outPath.
+func (s actionsSource) DownloadAsset(
+	ctx context.Context,
+	owner, repo, tag, assetName, outPath, githubToken string,
+) error {
+	if outPath == "" {
+		outPath = assetName
+	}
+	return ghrel.WriteFileAtomically(outPath, func(f *os.File) error {
+		return s.WriteAsset(ctx, owner, repo, tag, assetName, f, githubToken)
+	})
+}
+
+// WriteAsset streams the artifact named assetName to w, unwrapped as in DownloadAsset.
+func (s actionsSource) WriteAsset(
+	ctx context.Context,
+	owner, repo, tag, assetName string,
+	w io.Writer,
+	githubToken string,
+) error {
+	if githubToken == "" {
+		return errors.New("actions source: downloading workflow artifacts requires a GitHub token")
+	}
+
+	artifacts, err := s.artifacts(ctx, owner, repo, tag, githubToken)
+	if err != nil {
+		return err
+	}
+
+	var art *ghrel.Artifact
+	for i := range artifacts {
+		if artifacts[i].Name == assetName {
+			art = &artifacts[i]
+			break
+		}
+	}
+	if art == nil {
+		return fmt.Errorf("artifact %q %w in %s", assetName, ErrNotFound, tag)
+	}
+	if art.Expired {
+		return fmt.Errorf("artifact %q in %s has expired: %w", assetName, tag, ErrNotFound)
+	}
+
+	archive, err := os.CreateTemp("", "amlinstall-artifact-*.zip")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer func() {
+		_ = archive.Close()
+		_ = os.Remove(archive.Name())
+	}()
+
+	if err := ghrel.DownloadToWriter(ctx, s.client, art.ArchiveDownloadURL, githubToken, archive); err != nil {
+		return classify(err)
+	}
+
+	return unwrapArtifact(archive, w)
+}
+
+func (s actionsSource) artifacts(ctx context.Context, owner, repo, tag, githubToken string) ([]ghrel.Artifact, error) {
+	runID, ok := NightlyRunID(tag)
+	if !ok {
+		return nil, fmt.Errorf("tag %q is not a nightly build: %w", tag, ErrNotFound)
+	}
+	artifacts, err := ghrel.ListRunArtifacts(ctx, s.client, s.apiBaseURL, owner, repo, runID, githubToken)
+	if err != nil {
+		return nil, classify(err)
+	}
+	return artifacts, nil
+}
+
+// maxArtifact caps the size of the file unwrapped from an artifact archive.
+const maxArtifact = 512 << 20
+
+// unwrapArtifact copies the single file inside the archive to w, or the whole
+// archive when it holds more than one file.
+func unwrapArtifact(archive *os.File, w io.Writer) error {
+	info, err := archive.Stat()
+	if err != nil {
+		return err
+	}
+	zr, err := zip.NewReader(archive, info.Size())
+	if err != nil {
+		return fmt.Errorf("open artifact archive: %w", err)
+	}
+
+	var files []*zip.File
+	for _, f := range zr.File {
+		if !f.FileInfo().IsDir() {
+			files = append(files, f)
+		}
+	}
+
+	if len(files) == 1 {
+		rc, err := files[0].Open()
+		if err != nil {
+			return fmt.Errorf("open %s in artifact: %w", files[0].Name, err)
+		}
+		defer rc.Close()
+		n, err := io.Copy(w, io.LimitReader(rc, maxArtifact+1))
+		if err != nil {
+			return fmt.Errorf("extract %s: %w", files[0].Name, err)
+		}
+		if n > maxArtifact {
+			return fmt.Errorf("%s in artifact is larger than %d MiB", files[0].Name, maxArtifact>>20)
+		}
+		return nil
+	}
+
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, archive); err != nil {
+		return fmt.Errorf("copy artifact archive: %w", err)
+	}
+	return nil
+}
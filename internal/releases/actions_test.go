@@ -0,0 +1,156 @@
+package releases
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func zipBytes(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newActionsServer(t *testing.T) (*httptest.Server, actionsSource) {
+	t.Helper()
+	single := zipBytes(t, map[string]string{"MelonLoader.x64.zip": "inner zip"})
+	multi := zipBytes(t, map[string]string{"a.dll": "a", "b.dll": "b"})
+
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("/repos/LavaGang/MelonLoader/actions/runs", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("status") != "success" || r.URL.Query().Get("branch") != "alpha-development" {
+			http.Error(w, "bad query", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"workflow_runs":[{"id":200,"run_number":12},{"id":150,"run_number":11}]}`))
+	})
+	mux.HandleFunc("/repos/LavaGang/MelonLoader/actions/runs/200/artifacts", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"artifacts":[
+			{"name":"MelonLoader.x64","size_in_bytes":10,"archive_download_url":"%[1]s/dl/single"},
+			{"name":"Libs","size_in_bytes":20,"archive_download_url":"%[1]s/dl/multi"},
+			{"name":"Old","expired":true,"archive_download_url":"%[1]s/dl/single"}]}`, srv.URL)
+	})
+	serveZip := func(b []byte) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer tok" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write(b)
+		}
+	}
+	mux.HandleFunc("/dl/single", serveZip(single))
+	mux.HandleFunc("/dl/multi", serveZip(multi))
+
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	src := NewActionsSource("alpha-development").(actionsSource)
+	src.apiBaseURL = srv.URL
+	return srv, src
+}
+
+func TestNightlyTag(t *testing.T) {
+	if tag := NightlyTag(42); tag != "nightly-42" || !IsNightlyTag(tag) {
+		t.Fatalf("NightlyTag(42)=%q", tag)
+	}
+	for _, tag := range []string{"v0.6.5", "nightly-", "nightly-abc", "nightly--1"} {
+		if IsNightlyTag(tag) {
+			t.Fatalf("IsNightlyTag(%q)=true", tag)
+		}
+	}
+}
+
+func TestActionsSource_ListTagsAndAssets(t *testing.T) {
+	_, src := newActionsServer(t)
+	ctx := context.Background()
+
+	tags, err := src.ListTags(ctx, "LavaGang", "MelonLoader", "")
+	if err != nil || !reflect.DeepEqual(tags, []string{"nightly-200", "nightly-150"}) {
+		t.Fatalf("tags=%v err=%v", tags, err)
+	}
+
+	assets, err := src.ListAssets(ctx, "LavaGang", "MelonLoader", "nightly-200", "")
+	if err != nil || len(assets) != 3 || assets[0].Name != "MelonLoader.x64" || assets[0].Size != 10 {
+		t.Fatalf("assets=%+v err=%v", assets, err)
+	}
+
+	if _, err := src.ListAssets(ctx, "LavaGang", "MelonLoader", "nightly-999", ""); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err=%v; want ErrNotFound for unknown run", err)
+	}
+	if _, err := src.ListAssets(ctx, "LavaGang", "MelonLoader", "v0.6.5", ""); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err=%v; want ErrNotFound for release tag", err)
+	}
+}
+
+func TestActionsSource_Download(t *testing.T) {
+	_, src := newActionsServer(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	out := filepath.Join(dir, "ml.zip")
+	if err := src.DownloadAsset(ctx, "LavaGang", "MelonLoader", "nightly-200", "MelonLoader.x64", out, "tok"); err != nil {
+		t.Fatalf("DownloadAsset single: %v", err)
+	}
+	if b, _ := os.ReadFile(out); string(b) != "inner zip" {
+		t.Fatalf("single-file artifact should be unwrapped, got %q", b)
+	}
+
+	out = filepath.Join(dir, "libs.zip")
+	if err := src.DownloadAsset(ctx, "LavaGang", "MelonLoader", "nightly-200", "Libs", out, "tok"); err != nil {
+		t.Fatalf("DownloadAsset multi: %v", err)
+	}
+	zr, err := zip.OpenReader(out)
+	if err != nil {
+		t.Fatalf("multi-file artifact should be kept as zip: %v", err)
+	}
+	defer zr.Close()
+	if len(zr.File) != 2 {
+		t.Fatalf("zip entries=%d; want 2", len(zr.File))
+	}
+}
+
+func TestActionsSource_DownloadFailures(t *testing.T) {
+	_, src := newActionsServer(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	out := filepath.Join(dir, "x.zip")
+
+	if err := src.DownloadAsset(ctx, "LavaGang", "MelonLoader", "nightly-200", "MelonLoader.x64", out, ""); err == nil || !strings.Contains(err.Error(), "requires a GitHub token") {
+		t.Fatalf("err=%v; want token required", err)
+	}
+	if err := src.DownloadAsset(ctx, "LavaGang", "MelonLoader", "nightly-200", "Missing", out, "tok"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err=%v; want ErrNotFound", err)
+	}
+	if err := src.DownloadAsset(ctx, "LavaGang", "MelonLoader", "nightly-200", "Old", out, "tok"); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Fatalf("err=%v; want expired", err)
+	}
+	if err := src.DownloadAsset(ctx, "LavaGang", "MelonLoader", "nightly-200", "Libs", out, "wrong"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("err=%v; want 401", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("expected no files left behind, found %d", len(entries))
+	}
+}
@@ -0,0 +1,76 @@
+package releases
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os/exec"
+
+	"automelonloaderinstallergo/internal/ghrel"
+)
+
+// Typed failure categories reported by sources. Callers test for them with errors.Is.
+var (
+	// ErrNotFound reports a missing repository, release, tag or asset. It is the
+	// same value as ghrel.ErrNotFound, so ghrel errors match without classify.
+	ErrNotFound = ghrel.ErrNotFound
+
+	// ErrRateLimited reports that the backend refused the request due to quota limits.
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrUnavailable reports that the backend could not be reached or failed server-side.
+	ErrUnavailable = errors.New("source unavailable")
+)
+
+// kindError attaches a failure category to err without altering its message.
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string   { return e.err.Error() }
+func (e *kindError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// classify wraps err with the matching failure category, if one applies.
+// Errors that already carry a category are returned unchanged.
+func classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrRateLimited) || errors.Is(err, ErrUnavailable) {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	var kind error
+	var se *ghrel.StatusError
+	var ne net.Error
+	var ee *exec.Error
+	var xe *exec.ExitError
+	switch {
+	case errors.As(err, &se):
+		switch {
+		case se.RateLimited:
+			kind = ErrRateLimited
+		case se.StatusCode == http.StatusNotFound || se.StatusCode == http.StatusGone:
+			kind = ErrNotFound
+		case se.StatusCode >= 500:
+			kind = ErrUnavailable
+		}
+	case errors.As(err, &ne), errors.As(err, &ee), errors.As(err, &xe):
+		kind = ErrUnavailable
+	}
+
+	if kind == nil {
+		return err
+	}
+	return &kindError{kind: kind, err: err}
+}
+
+// isFallbackError reports whether err allows a fallback source to be tried.
+func isFallbackError(err error) bool {
+	return errors.Is(err, ErrNotFound) || errors.Is(err, ErrRateLimited) || errors.Is(err, ErrUnavailable)
+}
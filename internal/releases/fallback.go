@@ -0,0 +1,196 @@
+package releases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// NamedSource pairs a Source with the name used in error messages.
+type NamedSource struct {
+	Name   string
+	Source Source
+}
+
+type fallbackSource struct {
+	sources []NamedSource
+}
+
+// NewFallbackSource returns a Source that consults sources in order.
+//
+// ListTags merges the tags of every source that answers, keeping first-seen order.
+// DownloadAsset tries each source in turn and returns on the first success.
+// A source is skipped only when it fails with ErrNotFound, ErrRateLimited or
+// ErrUnavailable; any other error (including cancellation and integrity failures)
+// is returned immediately.
+func NewFallbackSource(sources ...NamedSource) Source {
+	return fallbackSource{sources: sources}
+}
+
+func (s fallbackSource) ListTags(ctx context.Context, owner, repo, githubToken string) ([]string, error) {
+	if len(s.sources) == 0 {
+		return nil, errors.New("fallback source: no sources configured")
+	}
+
+	var (
+		tags     []string
+		seen     = make(map[string]struct{})
+		answered bool
+		errs     []error
+	)
+	for _, ns := range s.sources {
+		got, err := ns.Source.ListTags(ctx, owner, repo, githubToken)
+		if err != nil {
+			if !isFallbackError(err) {
+				return nil, fmt.Errorf("%s: %w", ns.Name, err)
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", ns.Name, err))
+			continue
+		}
+		answered = true
+		for _, t := range got {
+			if _, ok := seen[t]; ok {
+				continue
+			}
+			seen[t] = struct{}{}
+			tags = append(tags, t)
+		}
+	}
+
+	if !answered {
+		return nil, errors.Join(errs...)
+	}
+	return tags, nil
+}
+
+// ListAssets returns the assets reported by the first source that lists them.
+func (s fallbackSource) ListAssets(ctx context.Context, owner, repo, tag, githubToken string) ([]Asset, error) {
+	var errs []error
+	for _, ns := range s.sources {
+		al, ok := ns.Source.(AssetLister)
+		if !ok {
+			continue
+		}
+		assets, err := al.ListAssets(ctx, owner, repo, tag, githubToken)
+		if err == nil {
+			return assets, nil
+		}
+		if !isFallbackError(err) {
+			return nil, fmt.Errorf("%s: %w", ns.Name, err)
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", ns.Name, err))
+	}
+	if len(errs) == 0 {
+		return nil, errors.New("fallback source: no source can list assets")
+	}
+	return nil, errors.Join(errs...)
+}
+
+// GetRelease describes the release using the first source that can.
+func (s fallbackSource) GetRelease(ctx context.Context, owner, repo, tag, githubToken string) (Release, error) {
+	var errs []error
+	for _, ns := range s.sources {
+		rel, err := GetRelease(ctx, ns.Source, owner, repo, tag, githubToken)
+		if err == nil {
+			return rel, nil
+		}
+		if errors.Is(err, errors.ErrUnsupported) {
+			continue
+		}
+		if !isFallbackError(err) {
+			return Release{}, fmt.Errorf("%s: %w", ns.Name, err)
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", ns.Name, err))
+	}
+	if len(errs) == 0 {
+		return Release{}, fmt.Errorf("fallback source: no source can describe releases: %w", errors.ErrUnsupported)
+	}
+	return Release{}, errors.Join(errs...)
+}
+
+// TagDates merges the dates reported by every source that can date tags; earlier
+// sources win when several date the same tag.
+func (s fallbackSource) TagDates(ctx context.Context, owner, repo, githubToken string) (map[string]time.Time, error) {
+	var (
+		dates    = make(map[string]time.Time)
+		answered bool
+		errs     []error
+	)
+	for _, ns := range s.sources {
+		got, err := TagDates(ctx, ns.Source, owner, repo, githubToken)
+		if errors.Is(err, errors.ErrUnsupported) {
+			continue
+		}
+		if err != nil {
+			if !isFallbackError(err) {
+				return nil, fmt.Errorf("%s: %w", ns.Name, err)
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", ns.Name, err))
+			continue
+		}
+		answered = true
+		for tag, t := range got {
+			if _, ok := dates[tag]; !ok {
+				dates[tag] = t
+			}
+		}
+	}
+	if !answered {
+		if len(errs) == 0 {
+			return nil, fmt.Errorf("fallback source: no source can report tag dates: %w", errors.ErrUnsupported)
+		}
+		return nil, errors.Join(errs...)
+	}
+	return dates, nil
+}
+
+func (s fallbackSource) DownloadAsset(
+	ctx context.Context,
+	owner, repo, tag, assetName, outPath, githubToken string,
+) error {
+	if len(s.sources) == 0 {
+		return errors.New("fallback source: no sources configured")
+	}
+
+	var errs []error
+	for _, ns := range s.sources {
+		err := ns.Source.DownloadAsset(ctx, owner, repo, tag, assetName, outPath, githubToken)
+		if err == nil {
+			return nil
+		}
+		if !isFallbackError(err) {
+			return fmt.Errorf("%s: %w", ns.Name, err)
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", ns.Name, err))
+	}
+	return errors.Join(errs...)
+}
+
+// WriteAsset streams the asset to w from the first source that has it. Once a
+// source has written to w the chain stops, since w cannot be rewound.
+func (s fallbackSource) WriteAsset(
+	ctx context.Context,
+	owner, repo, tag, assetName string,
+	w io.Writer,
+	githubToken string,
+) error {
+	if len(s.sources) == 0 {
+		return errors.New("fallback source: no sources configured")
+	}
+
+	var errs []error
+	for _, ns := range s.sources {
+		cw := &countingWriter{w: w}
+		err := WriteAsset(ctx, ns.Source, owner, repo, tag, assetName, cw, githubToken)
+		if err == nil {
+			return nil
+		}
+		if cw.n > 0 || !isFallbackError(err) {
+			return fmt.Errorf("%s: %w", ns.Name, err)
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", ns.Name, err))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,199 @@
+package releases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"automelonloaderinstallergo/internal/ghrel"
+)
+
+type fakeSource struct {
+	tags      []string
+	tagsErr   error
+	dlErr     error
+	downloads int
+}
+
+func (f *fakeSource) ListTags(ctx context.Context, owner, repo, githubToken string) ([]string, error) {
+	return f.tags, f.tagsErr
+}
+
+func (f *fakeSource) DownloadAsset(ctx context.Context, owner, repo, tag, assetName, outPath, githubToken string) error {
+	f.downloads++
+	if f.dlErr != nil {
+		return f.dlErr
+	}
+	return os.WriteFile(outPath, []byte(tag), 0o644)
+}
+
+func TestFallbackSource_ListTagsMerges(t *testing.T) {
+	a := &fakeSource{tags: []string{"v2", "v1"}}
+	b := &fakeSource{tagsErr: fmt.Errorf("down: %w", ErrRateLimited)}
+	c := &fakeSource{tags: []string{"v1", "v0"}}
+	src := NewFallbackSource(NamedSource{"a", a}, NamedSource{"b", b}, NamedSource{"c", c})
+
+	tags, err := src.ListTags(context.Background(), "o", "r", "")
+	if err != nil {
+		t.Fatalf("ListTags: %v", err)
+	}
+	if want := []string{"v2", "v1", "v0"}; !reflect.DeepEqual(tags, want) {
+		t.Fatalf("tags=%v; want %v", tags, want)
+	}
+}
+
+func TestFallbackSource_ListTagsAllFail(t *testing.T) {
+	a := &fakeSource{tagsErr: fmt.Errorf("gone: %w", ErrNotFound)}
+	b := &fakeSource{tagsErr: fmt.Errorf("down: %w", ErrUnavailable)}
+	src := NewFallbackSource(NamedSource{"a", a}, NamedSource{"b", b})
+
+	_, err := src.ListTags(context.Background(), "o", "r", "")
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("err=%v; want both categories joined", err)
+	}
+	if !strings.Contains(err.Error(), "a: gone") || !strings.Contains(err.Error(), "b: down") {
+		t.Fatalf("err=%q; want per-source messages", err)
+	}
+}
+
+func TestFallbackSource_ListTagsHardError(t *testing.T) {
+	a := &fakeSource{tagsErr: errors.New("boom")}
+	b := &fakeSource{tags: []string{"v1"}}
+	src := NewFallbackSource(NamedSource{"a", a}, NamedSource{"b", b})
+
+	if _, err := src.ListTags(context.Background(), "o", "r", ""); err == nil || !strings.Contains(err.Error(), "a: boom") {
+		t.Fatalf("err=%v; want untyped error to stop the chain", err)
+	}
+}
+
+func TestFallbackSource_DownloadFailsOver(t *testing.T) {
+	a := &fakeSource{dlErr: fmt.Errorf("quota: %w", ErrRateLimited)}
+	b := &fakeSource{dlErr: fmt.Errorf("missing: %w", ErrNotFound)}
+	c := &fakeSource{}
+	src := NewFallbackSource(NamedSource{"a", a}, NamedSource{"b", b}, NamedSource{"c", c})
+	out := filepath.Join(t.TempDir(), "asset")
+
+	if err := src.DownloadAsset(context.Background(), "o", "r", "v1", "x.zip", out, ""); err != nil {
+		t.Fatalf("DownloadAsset: %v", err)
+	}
+	if a.downloads != 1 || b.downloads != 1 || c.downloads != 1 {
+		t.Fatalf("downloads a=%d b=%d c=%d; want 1 each", a.downloads, b.downloads, c.downloads)
+	}
+}
+
+func TestFallbackSource_DownloadStopsOnHardError(t *testing.T) {
+	a := &fakeSource{dlErr: errors.New("sha256 mismatch")}
+	b := &fakeSource{}
+	src := NewFallbackSource(NamedSource{"a", a}, NamedSource{"b", b})
+
+	err := src.DownloadAsset(context.Background(), "o", "r", "v1", "x.zip", filepath.Join(t.TempDir(), "x"), "")
+	if err == nil || b.downloads != 0 {
+		t.Fatalf("err=%v b.downloads=%d; want hard error without failover", err, b.downloads)
+	}
+}
+
+func TestFallbackSource_Empty(t *testing.T) {
+	src := NewFallbackSource()
+	if _, err := src.ListTags(context.Background(), "o", "r", ""); err == nil {
+		t.Fatalf("expected error for empty chain")
+	}
+	if err := src.DownloadAsset(context.Background(), "o", "r", "t", "a", "x", ""); err == nil {
+		t.Fatalf("expected error for empty chain")
+	}
+}
+
+func TestClassify(t *testing.T) {
+	status := func(code int, hdr http.Header) error {
+		rec := httptest.NewRecorder()
+		for k, v := range hdr {
+			rec.Header()[k] = v
+		}
+		rec.WriteHeader(code)
+		return ghrel.NewStatusError("op", rec.Result())
+	}
+
+	cases := []struct {
+		err  error
+		want error
+	}{
+		{status(http.StatusNotFound, nil), ErrNotFound},
+		{status(http.StatusTooManyRequests, nil), ErrRateLimited},
+		{status(http.StatusForbidden, http.Header{"X-Ratelimit-Remaining": {"0"}}), ErrRateLimited},
+		{status(http.StatusBadGateway, nil), ErrUnavailable},
+	}
+	for _, tc := range cases {
+		if got := classify(tc.err); !errors.Is(got, tc.want) {
+			t.Fatalf("classify(%v) does not match %v", tc.err, tc.want)
+		}
+	}
+
+	// ghrel's sentinel is the same value, so its errors need no wrapping.
+	if err := fmt.Errorf("resolve asset URL: %w", fmt.Errorf("asset %q %w", "x", ghrel.ErrNotFound)); !errors.Is(err, ErrNotFound) || classify(err) != err {
+		t.Fatalf("ghrel.ErrNotFound should be releases.ErrNotFound")
+	}
+
+	if got := classify(status(http.StatusForbidden, nil)); isFallbackError(got) {
+		t.Fatalf("plain 403 should not be a fallback error")
+	}
+	if got := classify(context.Canceled); isFallbackError(got) {
+		t.Fatalf("cancellation should not be a fallback error")
+	}
+}
+
+func TestLocalSource(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "LavaGang", "MelonLoader", "v0.6.5")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "MelonLoader.x64.zip"), []byte("cached"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src := NewLocalSource(root)
+
+	tags, err := src.ListTags(context.Background(), "LavaGang", "MelonLoader", "")
+	if err != nil || !reflect.DeepEqual(tags, []string{"v0.6.5"}) {
+		t.Fatalf("tags=%v err=%v", tags, err)
+	}
+
+	out := filepath.Join(t.TempDir(), "out.zip")
+	if err := src.DownloadAsset(context.Background(), "LavaGang", "MelonLoader", "v0.6.5", "MelonLoader.x64.zip", out, ""); err != nil {
+		t.Fatalf("DownloadAsset: %v", err)
+	}
+	if b, _ := os.ReadFile(out); string(b) != "cached" {
+		t.Fatalf("content=%q", b)
+	}
+
+	if _, err := src.ListTags(context.Background(), "other", "repo", ""); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err=%v; want ErrNotFound", err)
+	}
+	if err := src.DownloadAsset(context.Background(), "LavaGang", "MelonLoader", "v9", "MelonLoader.x64.zip", out, ""); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err=%v; want ErrNotFound", err)
+	}
+	if err := src.DownloadAsset(context.Background(), "LavaGang", "MelonLoader", "..", "x", out, ""); err == nil {
+		t.Fatalf("expected path traversal to be rejected")
+	}
+}
+
+func TestOpenChain(t *testing.T) {
+	src, err := OpenChain([]string{"github", " local "}, Options{LocalDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("OpenChain: %v", err)
+	}
+	if _, ok := src.(fallbackSource); !ok {
+		t.Fatalf("got %T; want fallbackSource", src)
+	}
+	if _, err := OpenChain([]string{"github", "bogus"}, Options{}); err == nil {
+		t.Fatalf("expected unknown kind to fail")
+	}
+	if src, err := OpenChain(nil, Options{}); err != nil || src == nil {
+		t.Fatalf("empty chain should default to github: %v", err)
+	}
+}
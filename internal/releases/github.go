@@ -2,28 +2,115 @@ package releases
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
 
 	"automelonloaderinstallergo/internal/ghrel"
 )
 
-type gitHubSource struct{}
+// Git protocols accepted for tag listing by the GitHub source.
+const (
+	GitProtocolHTTPS = "https"
+	GitProtocolSSH   = "ssh"
+)
+
+type gitHubSource struct {
+	gitProtocol string
+}
 
 // NewGitHubSource returns a releases.Source backed by the existing internal/ghrel
-// implementation.
+// implementation. Tags are listed over HTTPS.
 func NewGitHubSource() Source {
-	return gitHubSource{}
+	return gitHubSource{gitProtocol: GitProtocolHTTPS}
+}
+
+// NewGitHubSourceWithGitProtocol is like NewGitHubSource but lists tags over the given
+// git protocol: GitProtocolHTTPS (authenticated with the GitHub token when one is
+// provided) or GitProtocolSSH (authenticated by the user's SSH agent and keys).
+func NewGitHubSourceWithGitProtocol(protocol string) (Source, error) {
+	switch protocol {
+	case "", GitProtocolHTTPS:
+		return gitHubSource{gitProtocol: GitProtocolHTTPS}, nil
+	case GitProtocolSSH:
+		return gitHubSource{gitProtocol: GitProtocolSSH}, nil
+	default:
+		return nil, fmt.Errorf("unknown git protocol %q (want https or ssh)", protocol)
+	}
 }
 
 func (s gitHubSource) ListTags(ctx context.Context, owner, repo, githubToken string) ([]string, error) {
-	// Tag listing uses `git ls-remote`; authentication is not currently applied.
-	_ = githubToken
 	remote := ghrel.GitRemoteURL(owner, repo)
-	return ghrel.GetTagsViaGit(ctx, remote)
+	if s.gitProtocol == GitProtocolSSH {
+		remote = ghrel.GitSSHRemoteURL(owner, repo)
+	}
+	tags, err := ghrel.GetTagsViaGit(ctx, remote, githubToken)
+	return tags, classify(err)
 }
 
 func (s gitHubSource) DownloadAsset(
 	ctx context.Context,
 	owner, repo, tag, assetName, outPath, githubToken string,
 ) error {
-	return ghrel.DownloadReleaseAssetByTag(ctx, owner, repo, tag, assetName, outPath, githubToken)
+	return classify(ghrel.DownloadReleaseAssetByTag(ctx, owner, repo, tag, assetName, outPath, githubToken))
+}
+
+// WriteAsset streams the release asset to w.
+func (s gitHubSource) WriteAsset(
+	ctx context.Context,
+	owner, repo, tag, assetName string,
+	w io.Writer,
+	githubToken string,
+) error {
+	return classify(ghrel.WriteReleaseAssetByTag(ctx, owner, repo, tag, assetName, w, githubToken))
+}
+
+// ListAssets lists the assets of the release for tag through the GitHub Releases API.
+func (s gitHubSource) ListAssets(ctx context.Context, owner, repo, tag, githubToken string) ([]Asset, error) {
+	rel, err := s.GetRelease(ctx, owner, repo, tag, githubToken)
+	return rel.Assets, err
+}
+
+// GetRelease describes the release for tag through the GitHub Releases API.
+func (s gitHubSource) GetRelease(ctx context.Context, owner, repo, tag, githubToken string) (Release, error) {
+	rel, err := ghrel.GetReleaseByTag(ctx, ghrel.NewGitHubClient(), owner, repo, tag, githubToken)
+	if err != nil {
+		return Release{}, classify(err)
+	}
+	out := Release{
+		Tag:         tag,
+		Name:        rel.Name,
+		PublishedAt: rel.PublishedAt,
+		Prerelease:  rel.Prerelease,
+		Body:        rel.Body,
+		URL:         rel.HTMLURL,
+		Assets:      make([]Asset, 0, len(rel.Assets)),
+	}
+	for _, a := range rel.Assets {
+		out.Assets = append(out.Assets, Asset{
+			Name:          a.Name,
+			URL:           a.BrowserDownloadURL,
+			Size:          a.Size,
+			SHA256:        strings.TrimPrefix(a.Digest, "sha256:"),
+			DownloadCount: a.DownloadCount,
+		})
+	}
+	return out, nil
+}
+
+// TagDates reports the publish time of each published release through the GitHub
+// Releases API. Tags without a release are omitted.
+func (s gitHubSource) TagDates(ctx context.Context, owner, repo, githubToken string) (map[string]time.Time, error) {
+	rels, err := ghrel.ListReleases(ctx, ghrel.NewGitHubClient(), ghrel.APIBaseURL, owner, repo, githubToken, 10)
+	if err != nil {
+		return nil, classify(err)
+	}
+	dates := make(map[string]time.Time, len(rels))
+	for _, r := range rels {
+		if !r.Draft && !r.PublishedAt.IsZero() {
+			dates[r.TagName] = r.PublishedAt
+		}
+	}
+	return dates, nil
 }
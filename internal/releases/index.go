@@ -0,0 +1,292 @@
+package releases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"automelonloaderinstallergo/internal/ghrel"
+)
+
+// IndexSchemaVersion is the only index document version understood by the index source.
+const IndexSchemaVersion = 1
+
+// Index is the JSON document served by a self-hosted release mirror.
+//
+// Example:
+//
+//	{
+//	  "schema": 1,
+//	  "releases": [
+//	    {
+//	      "tag": "v0.6.5",
+//	      "name": "v0.6.5 Open-Beta",
+//	      "body": "## Changes\n- ...",
+//	      "prerelease": false,
+//	      "published_at": "2024-08-01T12:00:00Z",
+//	      "assets": [
+//	        {
+//	          "name": "MelonLoader.x64.zip",
+//	          "url": "v0.6.5/MelonLoader.x64.zip",
+//	          "size": 1234567,
+//	          "sha256": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+//	        }
+//	      ]
+//	    }
+//	  ]
+//	}
+//
+// Asset URLs may be absolute or relative to the index URL. Size and sha256 are
+// optional; when present, downloads are verified against them before the file is
+// moved into place.
+type Index struct {
+	Schema   int            `json:"schema"`
+	Releases []IndexRelease `json:"releases"`
+}
+
+// IndexRelease describes a single tagged release in an Index.
+type IndexRelease struct {
+	Tag         string       `json:"tag"`
+	Name        string       `json:"name,omitempty"`
+	Body        string       `json:"body,omitempty"` // Markdown release notes
+	Prerelease  bool         `json:"prerelease,omitempty"`
+	PublishedAt string       `json:"published_at,omitempty"`
+	Assets      []IndexAsset `json:"assets"`
+}
+
+// IndexAsset describes a downloadable file belonging to an IndexRelease.
+type IndexAsset = Asset
+
+type indexSource struct {
+	client   *http.Client
+	indexURL string
+}
+
+// NewIndexSource returns a releases.Source backed by a JSON Index served over HTTP(S).
+//
+// indexURL may contain "{owner}" and "{repo}" placeholders so a single mirror can
+// serve several repositories, e.g. "https://mirror.example/{owner}/{repo}/index.json".
+// The GitHub token passed to Source methods is never sent to the index host.
+func NewIndexSource(indexURL string) Source {
+	return indexSource{client: ghrel.NewGitHubClient(), indexURL: indexURL}
+}
+
+func (s indexSource) ListTags(ctx context.Context, owner, repo, githubToken string) ([]string, error) {
+	_ = githubToken
+	idx, _, err := s.fetchIndex(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]string, 0, len(idx.Releases))
+	for _, r := range idx.Releases {
+		if r.Tag != "" {
+			tags = append(tags, r.Tag)
+		}
+	}
+	return tags, nil
+}
+
+func (s indexSource) DownloadAsset(
+	ctx context.Context,
+	owner, repo, tag, assetName, outPath, githubToken string,
+) error {
+	if outPath == "" {
+		outPath = assetName
+	}
+	return ghrel.WriteFileAtomically(outPath, func(f *os.File) error {
+		return s.WriteAsset(ctx, owner, repo, tag, assetName, f, githubToken)
+	})
+}
+
+// WriteAsset streams the asset to w, verifying it against the size and sha256
+// recorded in the index. On mismatch the data has already been written.
+func (s indexSource) WriteAsset(
+	ctx context.Context,
+	owner, repo, tag, assetName string,
+	w io.Writer,
+	githubToken string,
+) error {
+	_ = githubToken
+	idx, base, err := s.fetchIndex(ctx, owner, repo)
+	if err != nil {
+		return err
+	}
+
+	asset, err := idx.findAsset(tag, assetName)
+	if err != nil {
+		return err
+	}
+
+	ref, err := url.Parse(asset.URL)
+	if err != nil {
+		return fmt.Errorf("parse asset URL %q: %w", asset.URL, err)
+	}
+	downloadURL := base.ResolveReference(ref).String()
+
+	h := sha256.New()
+	cw := &countingWriter{w: io.MultiWriter(w, h)}
+	if err := ghrel.DownloadToWriter(ctx, s.client, downloadURL, "", cw); err != nil {
+		return classify(err)
+	}
+	return verifyAsset(asset, cw.n, h.Sum(nil))
+}
+
+func (s indexSource) ListAssets(ctx context.Context, owner, repo, tag, githubToken string) ([]Asset, error) {
+	rel, err := s.GetRelease(ctx, owner, repo, tag, githubToken)
+	return rel.Assets, err
+}
+
+// GetRelease describes the release for tag from the index. An unparsable
+// published_at is left as the zero time.
+func (s indexSource) GetRelease(ctx context.Context, owner, repo, tag, githubToken string) (Release, error) {
+	_ = githubToken
+	idx, base, err := s.fetchIndex(ctx, owner, repo)
+	if err != nil {
+		return Release{}, err
+	}
+
+	for _, r := range idx.Releases {
+		if r.Tag != tag {
+			continue
+		}
+		published, _ := time.Parse(time.RFC3339, r.PublishedAt)
+		rel := Release{
+			Tag:         r.Tag,
+			Name:        r.Name,
+			PublishedAt: published,
+			Prerelease:  r.Prerelease,
+			Body:        r.Body,
+			Assets:      make([]Asset, 0, len(r.Assets)),
+		}
+		for _, a := range r.Assets {
+			if ref, err := url.Parse(a.URL); err == nil {
+				a.URL = base.ResolveReference(ref).String()
+			}
+			rel.Assets = append(rel.Assets, a)
+		}
+		return rel, nil
+	}
+	return Release{}, fmt.Errorf("release %q %w in index", tag, ErrNotFound)
+}
+
+// TagDates reports the published_at time of each release in the index. Releases
+// without a parsable RFC 3339 time are omitted.
+func (s indexSource) TagDates(ctx context.Context, owner, repo, githubToken string) (map[string]time.Time, error) {
+	_ = githubToken
+	idx, _, err := s.fetchIndex(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	dates := make(map[string]time.Time, len(idx.Releases))
+	for _, r := range idx.Releases {
+		if t, err := time.Parse(time.RFC3339, r.PublishedAt); err == nil {
+			dates[r.Tag] = t
+		}
+	}
+	return dates, nil
+}
+
+// expandIndexURL substitutes the {owner} and {repo} placeholders in indexURL.
+func expandIndexURL(indexURL, owner, repo string) string {
+	r := strings.NewReplacer(
+		"{owner}", url.PathEscape(owner),
+		"{repo}", url.PathEscape(repo),
+	)
+	return r.Replace(indexURL)
+}
+
+// fetchIndex downloads and decodes the index for owner/repo. The returned URL is the
+// final index location and serves as the base for relative asset URLs.
+func (s indexSource) fetchIndex(ctx context.Context, owner, repo string) (Index, *url.URL, error) {
+	var idx Index
+
+	if strings.TrimSpace(s.indexURL) == "" {
+		return idx, nil, fmt.Errorf("index source: index URL is empty")
+	}
+
+	u, err := url.Parse(expandIndexURL(s.indexURL, owner, repo))
+	if err != nil {
+		return idx, nil, fmt.Errorf("parse index URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return idx, nil, fmt.Errorf("index URL must be http or https: %q", s.indexURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return idx, nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return idx, nil, classify(fmt.Errorf("fetch index: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return idx, nil, classify(ghrel.NewStatusError("fetch index", resp))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&idx); err != nil {
+		return idx, nil, fmt.Errorf("decode index JSON: %w", err)
+	}
+	if idx.Schema != IndexSchemaVersion {
+		return idx, nil, fmt.Errorf("unsupported index schema %d (want %d)", idx.Schema, IndexSchemaVersion)
+	}
+
+	return idx, resp.Request.URL, nil
+}
+
+func (idx Index) findAsset(tag, assetName string) (IndexAsset, error) {
+	for _, r := range idx.Releases {
+		if r.Tag != tag {
+			continue
+		}
+		for _, a := range r.Assets {
+			if a.Name != assetName {
+				continue
+			}
+			if a.URL == "" {
+				return a, fmt.Errorf("asset %q has empty url", assetName)
+			}
+			return a, nil
+		}
+		return IndexAsset{}, fmt.Errorf("asset %q %w", assetName, ErrNotFound)
+	}
+	return IndexAsset{}, fmt.Errorf("release %q %w in index", tag, ErrNotFound)
+}
+
+// verifyAsset checks the downloaded size and digest against the expected values in a.
+func verifyAsset(a Asset, size int64, sum []byte) error {
+	if a.Size > 0 && size != a.Size {
+		return fmt.Errorf("asset %q: size mismatch: got %d bytes, want %d", a.Name, size, a.Size)
+	}
+	if a.SHA256 != "" {
+		got := hex.EncodeToString(sum)
+		if !strings.EqualFold(got, a.SHA256) {
+			return fmt.Errorf("asset %q: sha256 mismatch: got %s, want %s", a.Name, got, a.SHA256)
+		}
+	}
+	return nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,169 @@
+package releases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const indexPayload = "melonloader zip bytes"
+
+func newIndexServer(t *testing.T, sha string, size int) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/LavaGang/MelonLoader/index.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{
+  "schema": 1,
+  "releases": [
+    {"tag": "v0.6.5", "assets": [{"name": "MelonLoader.x64.zip", "url": "files/ml.zip", "size": %d, "sha256": %q}]},
+    {"tag": "v0.6.4", "name": "Beta", "body": "- fixes", "published_at": "2024-05-01T10:00:00Z", "prerelease": true, "assets": []}
+  ]
+}`, size, sha)
+	})
+	mux.HandleFunc("/LavaGang/MelonLoader/files/ml.zip", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(indexPayload))
+	})
+	mux.HandleFunc("/bad/schema/index.json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"schema": 99, "releases": []}`))
+	})
+	mux.HandleFunc("/bad/json/index.json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{not json`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func payloadSHA() string {
+	sum := sha256.Sum256([]byte(indexPayload))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestIndexSource_ListTags(t *testing.T) {
+	srv := newIndexServer(t, payloadSHA(), len(indexPayload))
+	src := NewIndexSource(srv.URL + "/{owner}/{repo}/index.json")
+
+	tags, err := src.ListTags(context.Background(), "LavaGang", "MelonLoader", "")
+	if err != nil {
+		t.Fatalf("ListTags: %v", err)
+	}
+	if want := []string{"v0.6.5", "v0.6.4"}; !reflect.DeepEqual(tags, want) {
+		t.Fatalf("tags=%v; want %v", tags, want)
+	}
+}
+
+func TestIndexSource_GetRelease(t *testing.T) {
+	srv := newIndexServer(t, payloadSHA(), len(indexPayload))
+	src := NewIndexSource(srv.URL + "/{owner}/{repo}/index.json")
+
+	rel, err := GetRelease(context.Background(), src, "LavaGang", "MelonLoader", "v0.6.4", "")
+	if err != nil {
+		t.Fatalf("GetRelease: %v", err)
+	}
+	if rel.Name != "Beta" || rel.Body != "- fixes" || !rel.Prerelease || rel.PublishedAt.Year() != 2024 {
+		t.Fatalf("rel=%+v", rel)
+	}
+
+	rel, err = GetRelease(context.Background(), src, "LavaGang", "MelonLoader", "v0.6.5", "")
+	if err != nil || len(rel.Assets) != 1 || rel.Assets[0].URL != srv.URL+"/LavaGang/MelonLoader/files/ml.zip" {
+		t.Fatalf("rel=%+v err=%v", rel, err)
+	}
+
+	if _, err := GetRelease(context.Background(), src, "LavaGang", "MelonLoader", "v9", ""); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err=%v; want ErrNotFound", err)
+	}
+}
+
+func TestIndexSource_ListTagsErrors(t *testing.T) {
+	srv := newIndexServer(t, "", 0)
+	cases := []struct {
+		owner, repo string
+		want        string
+	}{
+		{"missing", "repo", "status=404"},
+		{"bad", "schema", "unsupported index schema"},
+		{"bad", "json", "decode index JSON"},
+	}
+	for _, tc := range cases {
+		src := NewIndexSource(srv.URL + "/{owner}/{repo}/index.json")
+		_, err := src.ListTags(context.Background(), tc.owner, tc.repo, "")
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s/%s: err=%v; want containing %q", tc.owner, tc.repo, err, tc.want)
+		}
+	}
+
+	if _, err := NewIndexSource("file:///etc/index.json").ListTags(context.Background(), "o", "r", ""); err == nil {
+		t.Fatalf("expected non-http scheme to be rejected")
+	}
+}
+
+func TestIndexSource_DownloadVerified(t *testing.T) {
+	srv := newIndexServer(t, payloadSHA(), len(indexPayload))
+	src := NewIndexSource(srv.URL + "/{owner}/{repo}/index.json")
+	out := filepath.Join(t.TempDir(), "nested", "ml.zip")
+
+	if err := src.DownloadAsset(context.Background(), "LavaGang", "MelonLoader", "v0.6.5", "MelonLoader.x64.zip", out, "secret"); err != nil {
+		t.Fatalf("DownloadAsset: %v", err)
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	if string(b) != indexPayload {
+		t.Fatalf("content=%q; want %q", b, indexPayload)
+	}
+}
+
+func TestIndexSource_DownloadFailures(t *testing.T) {
+	cases := []struct {
+		name      string
+		sha       string
+		size      int
+		tag       string
+		asset     string
+		wantError string
+	}{
+		{"checksum mismatch", strings.Repeat("0", 64), len(indexPayload), "v0.6.5", "MelonLoader.x64.zip", "sha256 mismatch"},
+		{"size mismatch", payloadSHA(), 3, "v0.6.5", "MelonLoader.x64.zip", "size mismatch"},
+		{"missing tag", payloadSHA(), 0, "v9.9.9", "MelonLoader.x64.zip", "release \"v9.9.9\" not found"},
+		{"missing asset", payloadSHA(), 0, "v0.6.4", "MelonLoader.x64.zip", "asset \"MelonLoader.x64.zip\" not found"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := newIndexServer(t, tc.sha, tc.size)
+			src := NewIndexSource(srv.URL + "/{owner}/{repo}/index.json")
+			dir := t.TempDir()
+			out := filepath.Join(dir, "ml.zip")
+
+			err := src.DownloadAsset(context.Background(), "LavaGang", "MelonLoader", tc.tag, tc.asset, out, "")
+			if err == nil || !strings.Contains(err.Error(), tc.wantError) {
+				t.Fatalf("err=%v; want containing %q", err, tc.wantError)
+			}
+			entries, _ := os.ReadDir(dir)
+			if len(entries) != 0 {
+				t.Fatalf("expected no files left behind, found %d", len(entries))
+			}
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	if _, err := Open("", Options{}); err != nil {
+		t.Fatalf("default source: %v", err)
+	}
+	if _, err := Open("index", Options{}); err == nil {
+		t.Fatalf("expected index source without URL to fail")
+	}
+	if _, err := Open("nope", Options{}); err == nil {
+		t.Fatalf("expected unknown source to fail")
+	}
+}
@@ -0,0 +1,173 @@
+package releases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"automelonloaderinstallergo/internal/ghrel"
+)
+
+type localSource struct {
+	root string
+}
+
+// NewLocalSource returns a releases.Source backed by a directory tree laid out as
+//
+//	<root>/<owner>/<repo>/<tag>/<asset>
+//
+// It is intended as a last-resort local cache or an offline mirror.
+func NewLocalSource(root string) Source {
+	return localSource{root: root}
+}
+
+// DefaultLocalDir returns the default root for the local source:
+// "$XDG_CACHE_HOME/amlinstall/releases" (or the platform equivalent).
+func DefaultLocalDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(".", "cache", "releases")
+	}
+	return filepath.Join(dir, "amlinstall", "releases")
+}
+
+func (s localSource) ListTags(ctx context.Context, owner, repo, githubToken string) ([]string, error) {
+	_ = githubToken
+	dir, err := s.repoDir(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("local source: %s/%s %w", owner, repo, ErrNotFound)
+		}
+		return nil, fmt.Errorf("local source: %w", err)
+	}
+
+	tags := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			tags = append(tags, e.Name())
+		}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// TagDates reports the modification time of each tag directory.
+func (s localSource) TagDates(ctx context.Context, owner, repo, githubToken string) (map[string]time.Time, error) {
+	tags, err := s.ListTags(ctx, owner, repo, githubToken)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := s.repoDir(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	dates := make(map[string]time.Time, len(tags))
+	for _, tag := range tags {
+		if fi, err := os.Stat(filepath.Join(dir, tag)); err == nil {
+			dates[tag] = fi.ModTime()
+		}
+	}
+	return dates, nil
+}
+
+func (s localSource) ListAssets(ctx context.Context, owner, repo, tag, githubToken string) ([]Asset, error) {
+	_ = githubToken
+	dir, err := s.repoDir(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	if !safeElem(tag) {
+		return nil, fmt.Errorf("local source: invalid tag %q", tag)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, tag))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("local source: tag %q %w", tag, ErrNotFound)
+		}
+		return nil, fmt.Errorf("local source: %w", err)
+	}
+
+	var assets []Asset
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		assets = append(assets, Asset{Name: e.Name(), Size: info.Size()})
+	}
+	return assets, nil
+}
+
+func (s localSource) DownloadAsset(
+	ctx context.Context,
+	owner, repo, tag, assetName, outPath, githubToken string,
+) error {
+	if outPath == "" {
+		outPath = assetName
+	}
+	return ghrel.WriteFileAtomically(outPath, func(f *os.File) error {
+		return s.WriteAsset(ctx, owner, repo, tag, assetName, f, githubToken)
+	})
+}
+
+// WriteAsset copies the asset file to w.
+func (s localSource) WriteAsset(
+	ctx context.Context,
+	owner, repo, tag, assetName string,
+	w io.Writer,
+	githubToken string,
+) error {
+	_ = githubToken
+	dir, err := s.repoDir(owner, repo)
+	if err != nil {
+		return err
+	}
+	if !safeElem(tag) || !safeElem(assetName) {
+		return fmt.Errorf("local source: invalid tag or asset name %q/%q", tag, assetName)
+	}
+
+	src, err := os.Open(filepath.Join(dir, tag, assetName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("local source: asset %q for tag %q %w", assetName, tag, ErrNotFound)
+		}
+		return fmt.Errorf("local source: %w", err)
+	}
+	defer src.Close()
+
+	if _, err := io.Copy(w, src); err != nil {
+		return fmt.Errorf("copy asset: %w", err)
+	}
+	return ctx.Err()
+}
+
+func (s localSource) repoDir(owner, repo string) (string, error) {
+	if s.root == "" {
+		return "", fmt.Errorf("local source: root directory is empty")
+	}
+	if !safeElem(owner) || !safeElem(repo) {
+		return "", fmt.Errorf("local source: invalid owner or repo %q/%q", owner, repo)
+	}
+	return filepath.Join(s.root, owner, repo), nil
+}
+
+// safeElem reports whether name is usable as a single path element.
+func safeElem(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
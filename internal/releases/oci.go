@@ -0,0 +1,110 @@
+package releases
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"automelonloaderinstallergo/internal/ghrel"
+	"automelonloaderinstallergo/internal/oci"
+)
+
+// DefaultOCIRepository is the repository name template used when none is configured.
+const DefaultOCIRepository = "{owner}/{repo}"
+
+type ociSource struct {
+	client     *oci.Client
+	repository string
+}
+
+// NewOCISource returns a releases.Source backed by an OCI registry.
+//
+// Each release is an OCI artifact tagged with the release tag; its layers are the
+// release assets, named by their "org.opencontainers.image.title" annotation (the
+// layout produced by `oras push`). repository may contain "{owner}" and "{repo}"
+// placeholders and is lower-cased, as registries require. The GitHub token is never
+// sent to the registry; username and password are used instead when set.
+func NewOCISource(registryURL, repository, username, password string) Source {
+	if strings.TrimSpace(repository) == "" {
+		repository = DefaultOCIRepository
+	}
+	return ociSource{
+		client:     oci.NewClient(registryURL, username, password),
+		repository: repository,
+	}
+}
+
+func (s ociSource) name(owner, repo string) string {
+	r := strings.NewReplacer("{owner}", owner, "{repo}", repo)
+	return strings.ToLower(r.Replace(s.repository))
+}
+
+func (s ociSource) ListTags(ctx context.Context, owner, repo, githubToken string) ([]string, error) {
+	_ = githubToken
+	tags, err := s.client.Tags(ctx, s.name(owner, repo))
+	if err != nil {
+		return nil, classify(err)
+	}
+	return tags, nil
+}
+
+func (s ociSource) ListAssets(ctx context.Context, owner, repo, tag, githubToken string) ([]Asset, error) {
+	_ = githubToken
+	m, err := s.client.Manifest(ctx, s.name(owner, repo), tag)
+	if err != nil {
+		return nil, classify(err)
+	}
+
+	var assets []Asset
+	for _, l := range m.Layers {
+		if l.Title() == "" {
+			continue
+		}
+		_, sum, _ := strings.Cut(l.Digest, ":")
+		assets = append(assets, Asset{Name: l.Title(), Size: l.Size, SHA256: sum})
+	}
+	return assets, nil
+}
+
+func (s ociSource) DownloadAsset(
+	ctx context.Context,
+	owner, repo, tag, assetName, outPath, githubToken string,
+) error {
+	if outPath == "" {
+		outPath = assetName
+	}
+	return ghrel.WriteFileAtomically(outPath, func(f *os.File) error {
+		return s.WriteAsset(ctx, owner, repo, tag, assetName, f, githubToken)
+	})
+}
+
+// WriteAsset streams the layer titled assetName to w, verifying its size and
+// digest. On mismatch the data has already been written.
+func (s ociSource) WriteAsset(
+	ctx context.Context,
+	owner, repo, tag, assetName string,
+	w io.Writer,
+	githubToken string,
+) error {
+	_ = githubToken
+	name := s.name(owner, repo)
+	m, err := s.client.Manifest(ctx, name, tag)
+	if err != nil {
+		return classify(err)
+	}
+
+	var layer *oci.Descriptor
+	for i := range m.Layers {
+		if m.Layers[i].Title() == assetName {
+			layer = &m.Layers[i]
+			break
+		}
+	}
+	if layer == nil {
+		return fmt.Errorf("asset %q %w in %s:%s", assetName, ErrNotFound, name, tag)
+	}
+
+	return classify(s.client.FetchBlob(ctx, name, *layer, w))
+}
@@ -0,0 +1,145 @@
+package releases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newRegistry starts a registry:2-style stand-in that requires bearer tokens issued
+// by its own token endpoint for user "ci" with password "pw".
+func newRegistry(t *testing.T, blob string, corrupt bool) *httptest.Server {
+	t.Helper()
+	sum := sha256.Sum256([]byte(blob))
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "ci" || pass != "pw" || r.URL.Query().Get("scope") != "repository:lavagang/melonloader:pull" {
+			http.Error(w, "denied", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"token":"registry-token"}`))
+	})
+
+	authed := func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer registry-token" {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:lavagang/melonloader:pull"`, srv.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			h(w, r)
+		}
+	}
+
+	mux.HandleFunc("/v2/lavagang/melonloader/tags/list", authed(func(w http.ResponseWriter, r *http.Request) {
+		// Serve one tag per page, linking onwards like registry:2 does for ?n=.
+		if r.URL.Query().Get("last") == "v0.6.5" {
+			_, _ = w.Write([]byte(`{"name":"lavagang/melonloader","tags":["v0.6.4"]}`))
+			return
+		}
+		w.Header().Set("Link", `</v2/lavagang/melonloader/tags/list?last=v0.6.5&n=1>; rel="next"`)
+		_, _ = w.Write([]byte(`{"name":"lavagang/melonloader","tags":["v0.6.5"]}`))
+	}))
+	mux.HandleFunc("/v2/lavagang/melonloader/manifests/", authed(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept"), "application/vnd.oci.image.manifest.v1+json") {
+			http.Error(w, "bad accept", http.StatusNotAcceptable)
+			return
+		}
+		if !strings.HasSuffix(r.URL.Path, "/v0.6.5") {
+			http.Error(w, `{"errors":[{"code":"MANIFEST_UNKNOWN"}]}`, http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.oci.image.manifest.v1+json",
+  "config": {"mediaType": "application/vnd.oci.empty.v1+json", "digest": "sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a", "size": 2},
+  "layers": [
+    {"mediaType": "application/zip", "digest": %q, "size": %d, "annotations": {"org.opencontainers.image.title": "MelonLoader.x64.zip"}},
+    {"mediaType": "application/octet-stream", "digest": "sha256:00", "size": 1}
+  ]
+}`, digest, len(blob))
+	}))
+	mux.HandleFunc("/v2/lavagang/melonloader/blobs/"+digest, authed(func(w http.ResponseWriter, r *http.Request) {
+		if corrupt {
+			_, _ = w.Write([]byte(strings.ToUpper(blob)))
+			return
+		}
+		_, _ = w.Write([]byte(blob))
+	}))
+
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOCISource(t *testing.T) {
+	srv := newRegistry(t, "zip bytes", false)
+	src, err := Open("oci", Options{OCIRegistry: srv.URL, OCIUsername: "ci", OCIPassword: "pw"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	ctx := context.Background()
+
+	tags, err := src.ListTags(ctx, "LavaGang", "MelonLoader", "gh-token")
+	if err != nil || !reflect.DeepEqual(tags, []string{"v0.6.5", "v0.6.4"}) {
+		t.Fatalf("tags=%v err=%v", tags, err)
+	}
+
+	assets, err := src.(AssetLister).ListAssets(ctx, "LavaGang", "MelonLoader", "v0.6.5", "")
+	if err != nil || len(assets) != 1 || assets[0].Name != "MelonLoader.x64.zip" || assets[0].Size != 9 {
+		t.Fatalf("assets=%+v err=%v", assets, err)
+	}
+
+	out := filepath.Join(t.TempDir(), "ml.zip")
+	if err := src.DownloadAsset(ctx, "LavaGang", "MelonLoader", "v0.6.5", "MelonLoader.x64.zip", out, ""); err != nil {
+		t.Fatalf("DownloadAsset: %v", err)
+	}
+	if b, _ := os.ReadFile(out); string(b) != "zip bytes" {
+		t.Fatalf("content=%q", b)
+	}
+}
+
+func TestOCISource_Failures(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := Open("oci", Options{}); err == nil {
+		t.Fatalf("expected oci source without registry to fail")
+	}
+
+	srv := newRegistry(t, "zip bytes", true)
+	src := NewOCISource(srv.URL, "", "ci", "pw")
+	dir := t.TempDir()
+	out := filepath.Join(dir, "ml.zip")
+
+	if err := src.DownloadAsset(ctx, "LavaGang", "MelonLoader", "v0.6.5", "MelonLoader.x64.zip", out, ""); err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Fatalf("err=%v; want digest mismatch", err)
+	}
+	if err := src.DownloadAsset(ctx, "LavaGang", "MelonLoader", "v0.6.5", "Other.zip", out, ""); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err=%v; want ErrNotFound for missing layer", err)
+	}
+	if err := src.DownloadAsset(ctx, "LavaGang", "MelonLoader", "v9", "MelonLoader.x64.zip", out, ""); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err=%v; want ErrNotFound for missing manifest", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("expected no files left behind, found %d", len(entries))
+	}
+
+	bad := NewOCISource(srv.URL, "", "ci", "wrong")
+	if _, err := bad.ListTags(ctx, "LavaGang", "MelonLoader", ""); err == nil || !strings.Contains(err.Error(), "registry token") {
+		t.Fatalf("err=%v; want token failure", err)
+	}
+}
@@ -0,0 +1,115 @@
+package releases
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Source kinds accepted by Open.
+const (
+	KindGitHub  = "github"
+	KindIndex   = "index"
+	KindLocal   = "local"
+	KindNightly = "nightly"
+	KindOCI     = "oci"
+)
+
+// Options carries backend-specific settings used by Open.
+type Options struct {
+	// GitProtocol selects how the "github" source lists tags: "https" (default) or "ssh".
+	GitProtocol string
+
+	// IndexURL is the location of the JSON index used by the "index" source.
+	IndexURL string
+
+	// LocalDir is the root directory used by the "local" source.
+	// When empty, DefaultLocalDir is used.
+	LocalDir string
+
+	// NightlyBranch restricts the "nightly" source to workflow runs on one branch.
+	NightlyBranch string
+
+	// OCIRegistry is the registry base URL used by the "oci" source.
+	OCIRegistry string
+	// OCIRepository is the repository name template for the "oci" source.
+	// When empty, DefaultOCIRepository is used.
+	OCIRepository string
+	// OCIUsername and OCIPassword authenticate against the registry or its token service.
+	OCIUsername string
+	OCIPassword string
+}
+
+// Open constructs the Source identified by kind.
+// An empty kind selects the GitHub source. Kinds that are not built in are resolved
+// to an "amlinstall-source-<kind>" plugin executable on PATH.
+func Open(kind string, opts Options) (Source, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", KindGitHub:
+		return NewGitHubSourceWithGitProtocol(strings.ToLower(strings.TrimSpace(opts.GitProtocol)))
+	case KindIndex:
+		if strings.TrimSpace(opts.IndexURL) == "" {
+			return nil, fmt.Errorf("source %q requires an index URL (--index-url)", KindIndex)
+		}
+		return NewIndexSource(opts.IndexURL), nil
+	case KindLocal:
+		dir := opts.LocalDir
+		if strings.TrimSpace(dir) == "" {
+			dir = DefaultLocalDir()
+		}
+		return NewLocalSource(dir), nil
+	case KindNightly:
+		return NewActionsSource(opts.NightlyBranch), nil
+	case KindOCI:
+		if strings.TrimSpace(opts.OCIRegistry) == "" {
+			return nil, fmt.Errorf("source %q requires a registry URL (--oci-registry)", KindOCI)
+		}
+		return NewOCISource(opts.OCIRegistry, opts.OCIRepository, opts.OCIUsername, opts.OCIPassword), nil
+	default:
+		name := strings.TrimSpace(kind)
+		path, err := LookupPlugin(name)
+		if err != nil {
+			if plugins := DiscoverPlugins(); len(plugins) > 0 {
+				return nil, fmt.Errorf("unknown source %q (available plugins: %s)", kind, strings.Join(plugins, ", "))
+			}
+			return nil, fmt.Errorf("unknown source %q", kind)
+		}
+		return NewPluginSource(name, path), nil
+	}
+}
+
+// KnownKind reports whether Open would recognise kind, either as a built-in source
+// or as a plugin on PATH. It does not check backend-specific options.
+func KnownKind(kind string) bool {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", KindGitHub, KindIndex, KindLocal, KindNightly, KindOCI:
+		return true
+	}
+	_, err := LookupPlugin(strings.TrimSpace(kind))
+	return err == nil
+}
+
+// OpenChain constructs a Source from an ordered list of kinds.
+// A single kind is opened directly; several kinds are wrapped in a fallback source.
+func OpenChain(kinds []string, opts Options) (Source, error) {
+	var named []NamedSource
+	for _, k := range kinds {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+		src, err := Open(k, opts)
+		if err != nil {
+			return nil, err
+		}
+		named = append(named, NamedSource{Name: k, Source: src})
+	}
+
+	switch len(named) {
+	case 0:
+		return Open("", opts)
+	case 1:
+		return named[0].Source, nil
+	default:
+		return NewFallbackSource(named...), nil
+	}
+}
@@ -0,0 +1,220 @@
+package releases
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"automelonloaderinstallergo/internal/ghrel"
+)
+
+// PluginPrefix is the executable name prefix of external source plugins.
+// A plugin named "amlinstall-source-artifactory" is selected with --source artifactory.
+const PluginPrefix = "amlinstall-source-"
+
+// PluginProtocolVersion is the protocol version sent in every plugin request.
+const PluginProtocolVersion = 1
+
+// Plugin operations.
+const (
+	PluginOpListTags   = "list-tags"
+	PluginOpListAssets = "list-assets"
+	PluginOpDownload   = "download-to-path"
+)
+
+// PluginRequest is written as a single JSON document to a plugin's stdin.
+// Each request runs a fresh plugin process.
+type PluginRequest struct {
+	Protocol int    `json:"protocol"`
+	Op       string `json:"op"`
+	Owner    string `json:"owner"`
+	Repo     string `json:"repo"`
+	Tag      string `json:"tag,omitempty"`
+	Asset    string `json:"asset,omitempty"`
+
+	// Path is the file the plugin must write the asset to for "download-to-path".
+	Path string `json:"path,omitempty"`
+}
+
+// PluginResponse is the single JSON document a plugin writes to stdout.
+//
+// For "download-to-path", Size and SHA256 are optional; when set, the written
+// file is verified against them.
+type PluginResponse struct {
+	Tags   []string     `json:"tags,omitempty"`
+	Assets []Asset      `json:"assets,omitempty"`
+	Size   int64        `json:"size,omitempty"`
+	SHA256 string       `json:"sha256,omitempty"`
+	Error  *PluginError `json:"error,omitempty"`
+}
+
+// PluginError reports a failure from a plugin. Code is one of "not_found",
+// "rate_limited" or "unavailable" to opt into fallback handling; any other value
+// is treated as a hard error.
+type PluginError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type pluginSource struct {
+	name string
+	path string
+}
+
+// NewPluginSource returns a releases.Source that delegates to the plugin executable at path.
+// The GitHub token is not forwarded; plugins obtain their own credentials.
+func NewPluginSource(name, path string) Source {
+	return pluginSource{name: name, path: path}
+}
+
+// LookupPlugin resolves the executable for plugin name on PATH.
+func LookupPlugin(name string) (string, error) {
+	if !safeElem(name) {
+		return "", fmt.Errorf("invalid plugin name %q", name)
+	}
+	return exec.LookPath(PluginPrefix + name)
+}
+
+// DiscoverPlugins returns the names of all source plugins found on PATH, sorted.
+func DiscoverPlugins() []string {
+	seen := make(map[string]struct{})
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name, ok := strings.CutPrefix(e.Name(), PluginPrefix)
+			if !ok || name == "" || e.IsDir() {
+				continue
+			}
+			if _, err := LookupPlugin(name); err == nil {
+				seen[name] = struct{}{}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for n := range seen {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s pluginSource) ListTags(ctx context.Context, owner, repo, githubToken string) ([]string, error) {
+	_ = githubToken
+	resp, err := s.call(ctx, PluginRequest{Op: PluginOpListTags, Owner: owner, Repo: repo})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Tags, nil
+}
+
+func (s pluginSource) ListAssets(ctx context.Context, owner, repo, tag, githubToken string) ([]Asset, error) {
+	_ = githubToken
+	resp, err := s.call(ctx, PluginRequest{Op: PluginOpListAssets, Owner: owner, Repo: repo, Tag: tag})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Assets, nil
+}
+
+func (s pluginSource) DownloadAsset(
+	ctx context.Context,
+	owner, repo, tag, assetName, outPath, githubToken string,
+) error {
+	_ = githubToken
+	if outPath == "" {
+		outPath = assetName
+	}
+
+	return ghrel.WriteFileAtomically(outPath, func(f *os.File) error {
+		resp, err := s.call(ctx, PluginRequest{
+			Op:    PluginOpDownload,
+			Owner: owner,
+			Repo:  repo,
+			Tag:   tag,
+			Asset: assetName,
+			Path:  f.Name(),
+		})
+		if err != nil {
+			return err
+		}
+
+		// The plugin wrote through its own handle; hash what landed on disk.
+		written, err := os.Open(f.Name())
+		if err != nil {
+			return fmt.Errorf("plugin %s: open output: %w", s.name, err)
+		}
+		defer written.Close()
+
+		h := sha256.New()
+		n, err := io.Copy(h, written)
+		if err != nil {
+			return fmt.Errorf("plugin %s: read output: %w", s.name, err)
+		}
+		return verifyAsset(Asset{Name: assetName, Size: resp.Size, SHA256: resp.SHA256}, n, h.Sum(nil))
+	})
+}
+
+// call runs one plugin request and decodes its response.
+func (s pluginSource) call(ctx context.Context, req PluginRequest) (PluginResponse, error) {
+	var resp PluginResponse
+
+	req.Protocol = PluginProtocolVersion
+	in, err := json.Marshal(req)
+	if err != nil {
+		return resp, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.path)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	runErr := cmd.Run()
+	if ctx.Err() != nil {
+		return resp, ctx.Err()
+	}
+
+	// A plugin may exit non-zero after writing a structured error; prefer that.
+	if err := json.Unmarshal(bytes.TrimSpace(stdout.Bytes()), &resp); err != nil {
+		if runErr != nil {
+			return resp, classify(fmt.Errorf("plugin %s: %s failed: %w; stderr=%s", s.name, req.Op, runErr, strings.TrimSpace(stderr.String())))
+		}
+		return resp, fmt.Errorf("plugin %s: decode %s response: %w", s.name, req.Op, err)
+	}
+	if resp.Error != nil {
+		return resp, resp.Error.asError(s.name, req.Op)
+	}
+	if runErr != nil {
+		return resp, classify(fmt.Errorf("plugin %s: %s failed: %w; stderr=%s", s.name, req.Op, runErr, strings.TrimSpace(stderr.String())))
+	}
+	return resp, nil
+}
+
+func (e *PluginError) asError(plugin, op string) error {
+	err := fmt.Errorf("plugin %s: %s: %s", plugin, op, e.Message)
+	var kind error
+	switch e.Code {
+	case "not_found":
+		kind = ErrNotFound
+	case "rate_limited":
+		kind = ErrRateLimited
+	case "unavailable":
+		kind = ErrUnavailable
+	default:
+		return err
+	}
+	return &kindError{kind: kind, err: err}
+}
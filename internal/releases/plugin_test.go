@@ -0,0 +1,94 @@
+package releases
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// buildFakePlugin compiles testdata/fakeplugin into a temp dir and puts it on PATH.
+func buildFakePlugin(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not available")
+	}
+	dir := t.TempDir()
+	bin := filepath.Join(dir, PluginPrefix+"fake")
+	cmd := exec.Command("go", "build", "-o", bin, "./testdata/fakeplugin")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("build fake plugin: %v\n%s", err, out)
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestPluginSource(t *testing.T) {
+	buildFakePlugin(t)
+
+	if got := DiscoverPlugins(); !reflect.DeepEqual(got, []string{"fake"}) {
+		t.Fatalf("DiscoverPlugins=%v; want [fake]", got)
+	}
+
+	src, err := Open("fake", Options{})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	ctx := context.Background()
+
+	tags, err := src.ListTags(ctx, "acme", "tools", "")
+	if err != nil || !reflect.DeepEqual(tags, []string{"v1.0.0", "v0.9.0"}) {
+		t.Fatalf("tags=%v err=%v", tags, err)
+	}
+
+	assets, err := src.(AssetLister).ListAssets(ctx, "acme", "tools", "v1.0.0", "")
+	if err != nil || len(assets) != 1 || assets[0].Name != "pkg.zip" || assets[0].Size != 7 {
+		t.Fatalf("assets=%+v err=%v", assets, err)
+	}
+
+	out := filepath.Join(t.TempDir(), "pkg.zip")
+	if err := src.DownloadAsset(ctx, "acme", "tools", "v1.0.0", "pkg.zip", out, "gh-token"); err != nil {
+		t.Fatalf("DownloadAsset: %v", err)
+	}
+	if b, _ := os.ReadFile(out); string(b) != "payload" {
+		t.Fatalf("content=%q", b)
+	}
+}
+
+func TestPluginSource_Failures(t *testing.T) {
+	buildFakePlugin(t)
+	src, err := Open("fake", Options{})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	ctx := context.Background()
+
+	if _, err := src.ListTags(ctx, "acme", "limited", ""); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("err=%v; want ErrRateLimited", err)
+	}
+	if _, err := src.ListTags(ctx, "acme", "crash", ""); !errors.Is(err, ErrUnavailable) || !strings.Contains(err.Error(), "plugin crashed") {
+		t.Fatalf("err=%v; want ErrUnavailable with stderr", err)
+	}
+	if _, err := src.ListTags(ctx, "acme", "garbage", ""); err == nil || !strings.Contains(err.Error(), "decode list-tags response") {
+		t.Fatalf("err=%v; want decode error", err)
+	}
+
+	dir := t.TempDir()
+	out := filepath.Join(dir, "x.zip")
+	if err := src.DownloadAsset(ctx, "acme", "tools", "v1.0.0", "missing.zip", out, ""); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err=%v; want ErrNotFound", err)
+	}
+	if err := src.DownloadAsset(ctx, "acme", "tools", "short", "pkg.zip", out, ""); err == nil || !strings.Contains(err.Error(), "size mismatch") {
+		t.Fatalf("err=%v; want size mismatch", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("expected no files left behind, found %d", len(entries))
+	}
+
+	if _, err := Open("missing", Options{}); err == nil || !strings.Contains(err.Error(), "available plugins: fake") {
+		t.Fatalf("err=%v; want unknown source listing plugins", err)
+	}
+}
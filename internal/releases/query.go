@@ -0,0 +1,168 @@
+package releases
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"automelonloaderinstallergo/internal/version"
+)
+
+// TagSort orders the result of QueryTags.
+type TagSort string
+
+// Tag orderings accepted by TagQuery.
+const (
+	// SortSemver lists releases newest version first (see version.Greater), then
+	// nightly CI builds newest run first. It is the default.
+	SortSemver TagSort = "semver"
+	// SortLexical lists tags in ascending byte order, as git does.
+	SortLexical TagSort = "lexical"
+	// SortDate lists tags newest publish time first; undated tags follow in
+	// SortSemver order. It requires TagQuery.Dates.
+	SortDate TagSort = "date"
+)
+
+// TagSorts lists every accepted ordering.
+var TagSorts = []TagSort{SortSemver, SortLexical, SortDate}
+
+// ParseTagSort parses an ordering name. An empty name means SortSemver.
+func ParseTagSort(s string) (TagSort, error) {
+	if s == "" {
+		return SortSemver, nil
+	}
+	for _, o := range TagSorts {
+		if string(o) == strings.ToLower(s) {
+			return o, nil
+		}
+	}
+	return "", fmt.Errorf("unknown tag sort %q (want semver, lexical or date)", s)
+}
+
+// TagQuery selects and orders tags. The zero value lists every tag in SortSemver order.
+type TagQuery struct {
+	Sort TagSort
+	// Dates holds publish times for SortDate; see TagDates.
+	Dates map[string]time.Time
+
+	// Include keeps only tags matching at least one expression; Exclude drops tags
+	// matching any. Expressions match the raw tag, e.g. "v0.6.5" or "nightly-42".
+	Include []*regexp.Regexp
+	Exclude []*regexp.Regexp
+
+	// NoPrerelease drops prereleases and nightly CI builds.
+	NoPrerelease bool
+	// Constraint, when set, keeps only versions it admits (dropping nightly builds).
+	Constraint *version.Constraint
+
+	// Latest keeps only the newest stable tag that passed the filters, or the newest
+	// prerelease when none is stable.
+	Latest bool
+	// Limit keeps at most this many tags after sorting; zero means no limit.
+	Limit int
+}
+
+// QueryTags filters and orders tags according to q. The Latest marker of each
+// result refers to the repository as a whole, not to the filtered selection.
+func QueryTags(tags []string, q TagQuery) []TagInfo {
+	var out []TagInfo
+	for _, info := range DescribeTags(tags) {
+		if q.keep(info) {
+			out = append(out, info)
+		}
+	}
+
+	if q.Latest {
+		best := -1
+		for i, info := range out {
+			if info.Nightly {
+				continue
+			}
+			if best < 0 || newerRelease(info, out[best]) {
+				best = i
+			}
+		}
+		if best < 0 {
+			return nil
+		}
+		return []TagInfo{out[best]}
+	}
+
+	switch q.Sort {
+	case SortLexical:
+		sort.SliceStable(out, func(i, j int) bool { return out[i].Tag < out[j].Tag })
+	case SortDate:
+		sort.SliceStable(out, func(i, j int) bool {
+			di, iok := q.Dates[out[i].Tag]
+			dj, jok := q.Dates[out[j].Tag]
+			switch {
+			case iok && jok && !di.Equal(dj):
+				return di.After(dj)
+			case iok != jok:
+				return iok
+			}
+			return semverLess(out[i], out[j])
+		})
+	default:
+		sort.SliceStable(out, func(i, j int) bool { return semverLess(out[i], out[j]) })
+	}
+
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[:q.Limit]
+	}
+	return out
+}
+
+func (q TagQuery) keep(info TagInfo) bool {
+	if q.NoPrerelease && (info.Prerelease || info.Nightly) {
+		return false
+	}
+	if q.Constraint != nil && (info.Nightly || !q.Constraint.Check(info.Version)) {
+		return false
+	}
+	if len(q.Include) > 0 {
+		matched := false
+		for _, re := range q.Include {
+			if re.MatchString(info.Tag) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for _, re := range q.Exclude {
+		if re.MatchString(info.Tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// semverLess reports whether a sorts before b in SortSemver order.
+func semverLess(a, b TagInfo) bool {
+	if a.Nightly != b.Nightly {
+		return !a.Nightly
+	}
+	if a.Nightly {
+		ra, _ := NightlyRunID(a.Tag)
+		rb, _ := NightlyRunID(b.Tag)
+		return ra > rb
+	}
+	if a.Version == b.Version {
+		return a.Tag > b.Tag
+	}
+	return version.Greater(a.Version, b.Version)
+}
+
+// newerRelease reports whether a is a better "latest" candidate than b: stable
+// releases beat prereleases, then higher versions win.
+func newerRelease(a, b TagInfo) bool {
+	if a.Prerelease != b.Prerelease {
+		return !a.Prerelease
+	}
+	return version.Greater(a.Version, b.Version)
+}
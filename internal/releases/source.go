@@ -1,9 +1,243 @@
 package releases
 
-import "context"
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"automelonloaderinstallergo/internal/ghrel"
+)
 
 // Source abstracts release/tag listing and release asset downloads.
 type Source interface {
 	ListTags(ctx context.Context, owner, repo, githubToken string) ([]string, error)
 	DownloadAsset(ctx context.Context, owner, repo, tag, assetName, outPath, githubToken string) error
 }
+
+// Asset describes a downloadable file attached to a release.
+type Asset struct {
+	Name   string `json:"name"`
+	URL    string `json:"url,omitempty"`
+	Size   int64  `json:"size,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+
+	// DownloadCount is reported by sources that track downloads, such as GitHub.
+	DownloadCount int64 `json:"download_count,omitempty"`
+}
+
+// AssetLister is implemented by sources that can enumerate the assets of a release.
+type AssetLister interface {
+	ListAssets(ctx context.Context, owner, repo, tag, githubToken string) ([]Asset, error)
+}
+
+// AssetWriter is implemented by sources that can stream an asset to a writer
+// rather than a file. Nothing is written to w when the asset cannot be found.
+type AssetWriter interface {
+	WriteAsset(ctx context.Context, owner, repo, tag, assetName string, w io.Writer, githubToken string) error
+}
+
+// WriteAsset streams the asset to w. Sources that are not AssetWriters download
+// it to a temporary file first, which is then copied to w.
+func WriteAsset(ctx context.Context, src Source, owner, repo, tag, assetName string, w io.Writer, githubToken string) error {
+	if aw, ok := src.(AssetWriter); ok {
+		return aw.WriteAsset(ctx, owner, repo, tag, assetName, w, githubToken)
+	}
+
+	dir, err := os.MkdirTemp("", "amlinstall-asset-*")
+	if err != nil {
+		return fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tmp := filepath.Join(dir, "asset")
+	if err := src.DownloadAsset(ctx, owner, repo, tag, assetName, tmp, githubToken); err != nil {
+		return err
+	}
+	f, err := os.Open(tmp)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := io.Copy(w, f); err != nil {
+		return fmt.Errorf("copy asset: %w", err)
+	}
+	return nil
+}
+
+// Download describes an asset written to disk by DownloadFile.
+type Download struct {
+	Asset  Asset
+	Path   string
+	Size   int64
+	SHA256 string
+}
+
+// DownloadFile downloads asset to outPath atomically, hashing it as it is written.
+func DownloadFile(ctx context.Context, src Source, owner, repo, tag string, asset Asset, outPath, githubToken string) (Download, error) {
+	d := Download{Asset: asset, Path: outPath}
+	err := ghrel.WriteFileAtomically(outPath, func(f *os.File) error {
+		h := sha256.New()
+		cw := &countingWriter{w: io.MultiWriter(f, h)}
+		if err := WriteAsset(ctx, src, owner, repo, tag, asset.Name, cw, githubToken); err != nil {
+			return err
+		}
+		d.Size, d.SHA256 = cw.n, hex.EncodeToString(h.Sum(nil))
+		return nil
+	})
+	return d, err
+}
+
+// Release describes a published release. Fields a source does not know are left
+// at their zero values.
+type Release struct {
+	Tag         string    `json:"tag"`
+	Name        string    `json:"name,omitempty"`
+	PublishedAt time.Time `json:"published_at,omitzero"`
+	Prerelease  bool      `json:"prerelease"`
+	// Body holds the release notes as Markdown.
+	Body string `json:"body,omitempty"`
+	// URL is the release's web page, if any.
+	URL    string  `json:"url,omitempty"`
+	Assets []Asset `json:"assets,omitempty"`
+}
+
+// ReleaseDescriber is implemented by sources that can describe a release in full.
+type ReleaseDescriber interface {
+	GetRelease(ctx context.Context, owner, repo, tag, githubToken string) (Release, error)
+}
+
+// GetRelease describes the release for tag. Sources that are not ReleaseDescribers
+// but can list assets yield a Release with only Tag and Assets set; other sources
+// return an error wrapping errors.ErrUnsupported.
+func GetRelease(ctx context.Context, src Source, owner, repo, tag, githubToken string) (Release, error) {
+	if rd, ok := src.(ReleaseDescriber); ok {
+		return rd.GetRelease(ctx, owner, repo, tag, githubToken)
+	}
+	assets, err := ListAssets(ctx, src, owner, repo, tag, githubToken)
+	if err != nil {
+		return Release{}, err
+	}
+	return Release{Tag: tag, Assets: assets}, nil
+}
+
+// ListAssets lists the assets of a release if src implements AssetLister, and
+// returns an error wrapping errors.ErrUnsupported otherwise.
+func ListAssets(ctx context.Context, src Source, owner, repo, tag, githubToken string) ([]Asset, error) {
+	al, ok := src.(AssetLister)
+	if !ok {
+		return nil, fmt.Errorf("source cannot list release assets: %w", errors.ErrUnsupported)
+	}
+	return al.ListAssets(ctx, owner, repo, tag, githubToken)
+}
+
+// TagDater is implemented by sources that know when their tags were published.
+type TagDater interface {
+	// TagDates maps tags to their publish time. Tags without a known time are omitted.
+	TagDates(ctx context.Context, owner, repo, githubToken string) (map[string]time.Time, error)
+}
+
+// TagDates returns the publish times of the tags of owner/repo if src implements
+// TagDater, and an error wrapping errors.ErrUnsupported otherwise.
+func TagDates(ctx context.Context, src Source, owner, repo, githubToken string) (map[string]time.Time, error) {
+	td, ok := src.(TagDater)
+	if !ok {
+		return nil, fmt.Errorf("source cannot report tag dates: %w", errors.ErrUnsupported)
+	}
+	return td.TagDates(ctx, owner, repo, githubToken)
+}
+
+// IsAssetPattern reports whether name contains glob metacharacters (*, ? or [).
+func IsAssetPattern(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
+
+// MatchAssets returns the assets whose names match the glob pattern, using the syntax
+// of path.Match. An empty pattern matches every asset.
+func MatchAssets(assets []Asset, pattern string) ([]Asset, error) {
+	if pattern == "" {
+		return assets, nil
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("asset pattern %q: %w", pattern, err)
+	}
+	var out []Asset
+	for _, a := range assets {
+		if ok, _ := path.Match(pattern, a.Name); ok {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+// ResolveAsset returns the single asset name in owner/repo@tag matching the
+// glob pattern. Plain names are returned unchanged without listing assets.
+func ResolveAsset(ctx context.Context, src Source, owner, repo, tag, pattern, token string) (string, error) {
+	if !IsAssetPattern(pattern) {
+		return pattern, nil
+	}
+	a, err := FindAsset(ctx, src, owner, repo, tag, pattern, token)
+	return a.Name, err
+}
+
+// FindAsset describes the single asset in owner/repo@tag matching the glob
+// pattern, or the asset named pattern when it is a plain name. For plain names
+// the listing is only descriptive: when the source cannot list assets or does
+// not list that name, an Asset with only Name set is returned and the download
+// reports whether it exists.
+func FindAsset(ctx context.Context, src Source, owner, repo, tag, pattern, token string) (Asset, error) {
+	assets, err := ListAssets(ctx, src, owner, repo, tag, token)
+	if !IsAssetPattern(pattern) {
+		for _, a := range assets {
+			if a.Name == pattern {
+				return a, nil
+			}
+		}
+		return Asset{Name: pattern}, nil
+	}
+	if err != nil {
+		return Asset{}, fmt.Errorf("resolve asset pattern %q: %w", pattern, err)
+	}
+
+	matches, err := MatchAssets(assets, pattern)
+	if err != nil {
+		return Asset{}, err
+	}
+	switch len(matches) {
+	case 0:
+		return Asset{}, fmt.Errorf("no assets in %s/%s@%s match %q", owner, repo, tag, pattern)
+	case 1:
+		return matches[0], nil
+	default:
+		names := make([]string, len(matches))
+		for i, a := range matches {
+			names[i] = a.Name
+		}
+		return Asset{}, fmt.Errorf("asset pattern %q matches %d assets (%s); narrow it down", pattern, len(matches), strings.Join(names, ", "))
+	}
+}
+
+// HumanSize formats the asset size with binary units, e.g. "12.3 MiB".
+// It returns "?" when the source did not report a size.
+func (a Asset) HumanSize() string {
+	if a.Size <= 0 {
+		return "?"
+	}
+	const unit = 1024
+	if a.Size < unit {
+		return fmt.Sprintf("%d B", a.Size)
+	}
+	div, exp := int64(unit), 0
+	for n := a.Size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(a.Size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,108 @@
+package releases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchAssets(t *testing.T) {
+	assets := []Asset{
+		{Name: "MelonLoader.x64.zip"},
+		{Name: "MelonLoader.x86.zip"},
+		{Name: "MelonLoader.Installer.exe"},
+	}
+
+	got, err := MatchAssets(assets, "MelonLoader.x*.zip")
+	if err != nil || len(got) != 2 || got[1].Name != "MelonLoader.x86.zip" {
+		t.Fatalf("got=%v err=%v", got, err)
+	}
+	if got, _ := MatchAssets(assets, ""); len(got) != 3 {
+		t.Fatalf("empty pattern should match all, got %v", got)
+	}
+	if _, err := MatchAssets(assets, "[unclosed"); err == nil {
+		t.Fatalf("expected bad pattern error")
+	}
+	if !IsAssetPattern("*.zip") || IsAssetPattern("MelonLoader.x64.zip") {
+		t.Fatalf("IsAssetPattern misclassified input")
+	}
+}
+
+func TestHumanSize(t *testing.T) {
+	for _, tc := range []struct {
+		size int64
+		want string
+	}{
+		{0, "?"},
+		{512, "512 B"},
+		{1536, "1.5 KiB"},
+		{25 << 20, "25.0 MiB"},
+		{3 << 30, "3.0 GiB"},
+	} {
+		if got := (Asset{Size: tc.size}).HumanSize(); got != tc.want {
+			t.Errorf("HumanSize(%d)=%q; want %q", tc.size, got, tc.want)
+		}
+	}
+}
+
+type tagsOnlySource struct{}
+
+func (tagsOnlySource) ListTags(context.Context, string, string, string) ([]string, error) {
+	return nil, nil
+}
+
+func (tagsOnlySource) DownloadAsset(context.Context, string, string, string, string, string, string) error {
+	return nil
+}
+
+func TestListAssetsUnsupported(t *testing.T) {
+	_, err := ListAssets(context.Background(), tagsOnlySource{}, "o", "r", "v1", "")
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("err=%v; want ErrUnsupported", err)
+	}
+}
+
+func TestDownloadFile(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "LavaGang", "MelonLoader", "v0.6.5")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "MelonLoader.x64.zip"), []byte("cached"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	src := NewLocalSource(root)
+
+	asset, err := FindAsset(ctx, src, "LavaGang", "MelonLoader", "v0.6.5", "MelonLoader.x*.zip", "")
+	if err != nil || asset.Name != "MelonLoader.x64.zip" || asset.Size != 6 {
+		t.Fatalf("asset=%+v err=%v", asset, err)
+	}
+
+	out := filepath.Join(t.TempDir(), "ml.zip")
+	d, err := DownloadFile(ctx, src, "LavaGang", "MelonLoader", "v0.6.5", asset, out, "")
+	sum := sha256.Sum256([]byte("cached"))
+	if err != nil || d.Size != 6 || d.SHA256 != hex.EncodeToString(sum[:]) || d.Path != out {
+		t.Fatalf("download=%+v err=%v", d, err)
+	}
+	if b, _ := os.ReadFile(out); string(b) != "cached" {
+		t.Fatalf("content=%q", b)
+	}
+
+	// Sources that only download to files are staged through a temporary file.
+	d, err = DownloadFile(ctx, &fakeSource{}, "o", "r", "v1", Asset{Name: "x.zip"}, out, "")
+	if err != nil || d.Size != 2 {
+		t.Fatalf("download=%+v err=%v", d, err)
+	}
+	if b, _ := os.ReadFile(out); string(b) != "v1" {
+		t.Fatalf("content=%q", b)
+	}
+
+	if asset, err := FindAsset(ctx, tagsOnlySource{}, "o", "r", "v1", "x.zip", ""); err != nil || asset.Name != "x.zip" {
+		t.Fatalf("asset=%+v err=%v; want plain name kept when listing is unsupported", asset, err)
+	}
+}
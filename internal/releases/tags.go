@@ -0,0 +1,37 @@
+package releases
+
+import "automelonloaderinstallergo/internal/version"
+
+// TagInfo describes a tag for display and machine-readable output.
+type TagInfo struct {
+	Tag string `json:"tag"`
+	// Version is the tag without its leading "v".
+	Version    string `json:"version"`
+	Prerelease bool   `json:"prerelease"`
+	Nightly    bool   `json:"nightly"`
+	// Latest marks the newest stable release, or the newest release of any kind
+	// when every release is a prerelease.
+	Latest bool `json:"latest"`
+}
+
+// DescribeTags describes tags in their original order.
+func DescribeTags(tags []string) []TagInfo {
+	out := make([]TagInfo, len(tags))
+	latest := -1
+	for i, t := range tags {
+		v := version.NormalizeTag(t)
+		out[i] = TagInfo{
+			Tag:        t,
+			Version:    v,
+			Prerelease: version.IsPrerelease(v),
+			Nightly:    IsNightlyTag(t),
+		}
+		if !out[i].Nightly && (latest < 0 || newerRelease(out[i], out[latest])) {
+			latest = i
+		}
+	}
+	if latest >= 0 {
+		out[latest].Latest = true
+	}
+	return out
+}
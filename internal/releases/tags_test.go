@@ -0,0 +1,67 @@
+package releases
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"automelonloaderinstallergo/internal/version"
+)
+
+func TestDescribeTags(t *testing.T) {
+	got := DescribeTags([]string{"v0.6.1", "nightly-7", "v0.7.0-beta.1", "v0.6.5"})
+	want := []TagInfo{
+		{Tag: "v0.6.1", Version: "0.6.1"},
+		{Tag: "nightly-7", Version: "nightly-7", Nightly: true},
+		{Tag: "v0.7.0-beta.1", Version: "0.7.0-beta.1", Prerelease: true},
+		{Tag: "v0.6.5", Version: "0.6.5", Latest: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("DescribeTags=%+v\nwant %+v", got, want)
+	}
+
+	pre := DescribeTags([]string{"v1.0.0-rc.1", "v1.0.0-rc.2"})
+	if pre[0].Latest || !pre[1].Latest {
+		t.Fatalf("prerelease-only latest=%+v", pre)
+	}
+}
+
+func TestQueryTags(t *testing.T) {
+	tags := []string{"v0.5.7", "nightly-3", "v0.6.10", "v0.6.2", "v0.7.0-beta.1", "nightly-12", "v0.6.5"}
+	names := func(infos []TagInfo) []string {
+		var out []string
+		for _, i := range infos {
+			out = append(out, i.Tag)
+		}
+		return out
+	}
+	c, err := version.ParseConstraint(">=0.6, <0.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+
+	cases := []struct {
+		name string
+		q    TagQuery
+		want []string
+	}{
+		{"semver", TagQuery{}, []string{"v0.7.0-beta.1", "v0.6.10", "v0.6.5", "v0.6.2", "v0.5.7", "nightly-12", "nightly-3"}},
+		{"lexical", TagQuery{Sort: SortLexical}, []string{"nightly-12", "nightly-3", "v0.5.7", "v0.6.10", "v0.6.2", "v0.6.5", "v0.7.0-beta.1"}},
+		{"date", TagQuery{Sort: SortDate, Dates: map[string]time.Time{"v0.5.7": day(9), "v0.6.2": day(3)}},
+			[]string{"v0.5.7", "v0.6.2", "v0.7.0-beta.1", "v0.6.10", "v0.6.5", "nightly-12", "nightly-3"}},
+		{"no prerelease, limit", TagQuery{NoPrerelease: true, Limit: 2}, []string{"v0.6.10", "v0.6.5"}},
+		{"include/exclude", TagQuery{Include: []*regexp.Regexp{regexp.MustCompile(`^v0\.6`)}, Exclude: []*regexp.Regexp{regexp.MustCompile(`10$`)}},
+			[]string{"v0.6.5", "v0.6.2"}},
+		{"constraint", TagQuery{Constraint: &c}, []string{"v0.6.10", "v0.6.5", "v0.6.2"}},
+		{"latest", TagQuery{Latest: true}, []string{"v0.6.10"}},
+		{"latest prerelease", TagQuery{Latest: true, Include: []*regexp.Regexp{regexp.MustCompile(`beta`)}}, []string{"v0.7.0-beta.1"}},
+		{"latest of nothing", TagQuery{Latest: true, Include: []*regexp.Regexp{regexp.MustCompile(`nightly`)}}, nil},
+	}
+	for _, tc := range cases {
+		if got := names(QueryTags(tags, tc.q)); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %v\nwant %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Command fakeplugin is a minimal amlinstall source plugin used by the releases tests.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+type request struct {
+	Op    string `json:"op"`
+	Owner string `json:"owner"`
+	Repo  string `json:"repo"`
+	Tag   string `json:"tag"`
+	Asset string `json:"asset"`
+	Path  string `json:"path"`
+}
+
+func reply(v any) {
+	_ = json.NewEncoder(os.Stdout).Encode(v)
+}
+
+func fail(code, msg string) {
+	reply(map[string]any{"error": map[string]string{"code": code, "message": msg}})
+	os.Exit(1)
+}
+
+func main() {
+	var req request
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		fmt.Fprintln(os.Stderr, "bad request:", err)
+		os.Exit(2)
+	}
+
+	switch req.Repo {
+	case "crash":
+		fmt.Fprintln(os.Stderr, "plugin crashed")
+		os.Exit(3)
+	case "garbage":
+		fmt.Println("not json")
+		return
+	case "limited":
+		fail("rate_limited", "slow down")
+	}
+
+	switch req.Op {
+	case "list-tags":
+		reply(map[string]any{"tags": []string{"v1.0.0", "v0.9.0"}})
+	case "list-assets":
+		reply(map[string]any{"assets": []map[string]any{{"name": "pkg.zip", "size": 7}}})
+	case "download-to-path":
+		if req.Asset != "pkg.zip" {
+			fail("not_found", "no asset "+req.Asset)
+		}
+		if err := os.WriteFile(req.Path, []byte("payload"), 0o644); err != nil {
+			fail("io", err.Error())
+		}
+		size := 7
+		if req.Tag == "short" {
+			size = 99
+		}
+		reply(map[string]any{"size": size})
+	default:
+		fail("unsupported", "unknown op "+req.Op)
+	}
+}
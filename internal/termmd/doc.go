@@ -0,0 +1,4 @@
+// Package termmd renders the subset of Markdown found in release notes as styled,
+// word-wrapped terminal text: headings, paragraphs, bullet and numbered lists,
+// block quotes, fenced code, rules, emphasis, inline code and links.
+package termmd
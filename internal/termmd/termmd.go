@@ -0,0 +1,187 @@
+package termmd
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var (
+	h1Style    = lipgloss.NewStyle().Bold(true).Underline(true)
+	hStyle     = lipgloss.NewStyle().Bold(true)
+	codeStyle  = lipgloss.NewStyle().Faint(true)
+	quoteStyle = lipgloss.NewStyle().Faint(true)
+	boldStyle  = lipgloss.NewStyle().Bold(true)
+	emStyle    = lipgloss.NewStyle().Italic(true)
+	linkStyle  = lipgloss.NewStyle().Underline(true)
+)
+
+var (
+	headingRe  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	bulletRe   = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	numberedRe = regexp.MustCompile(`^(\s*)(\d+[.)])\s+(.*)$`)
+	ruleRe     = regexp.MustCompile(`^\s*([-*_])(\s*([-*_])){2,}\s*$`)
+	commentRe  = regexp.MustCompile(`<!--.*?-->`)
+	tagRe      = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+
+	imageRe      = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)[^)]*\)`)
+	linkRe       = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)[^)]*\)`)
+	inlineCodeRe = regexp.MustCompile("`([^`]+)`")
+	boldRe       = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	emRe         = regexp.MustCompile(`(^|[^*\w])\*([^*\s][^*]*)\*`)
+)
+
+// Render converts md to terminal text wrapped to width columns. A width below 10
+// is treated as 10.
+func Render(md string, width int) string {
+	width = max(width, 10)
+	md = strings.ReplaceAll(md, "\r\n", "\n")
+	md = commentRe.ReplaceAllString(md, "")
+
+	var (
+		out   []string
+		para  []string
+		fence string // the opening fence while inside a code block
+	)
+	flush := func() {
+		if len(para) == 0 {
+			return
+		}
+		out = append(out, ansi.Wrap(inline(strings.Join(para, " ")), width, ""))
+		para = nil
+	}
+	blank := func() {
+		if len(out) > 0 && out[len(out)-1] != "" {
+			out = append(out, "")
+		}
+	}
+
+	for _, line := range strings.Split(md, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+				blank()
+				continue
+			}
+			out = append(out, codeStyle.Render("  "+strings.ReplaceAll(line, "\t", "    ")))
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			flush()
+			blank()
+			fence = trimmed[:3]
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			flush()
+			blank()
+
+		case headingRe.MatchString(trimmed):
+			flush()
+			blank()
+			m := headingRe.FindStringSubmatch(trimmed)
+			style := hStyle
+			if len(m[1]) == 1 {
+				style = h1Style
+			}
+			out = append(out, style.Render(ansi.Wrap(stripInline(m[2]), width, "")), "")
+
+		case ruleRe.MatchString(trimmed):
+			flush()
+			out = append(out, strings.Repeat("─", width))
+
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			text := strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+			for _, l := range strings.Split(ansi.Wrap(inline(text), width-2, ""), "\n") {
+				out = append(out, quoteStyle.Render("│ ")+l)
+			}
+
+		case bulletRe.MatchString(line):
+			flush()
+			m := bulletRe.FindStringSubmatch(line)
+			out = append(out, listItem(indent(m[1]), "• ", m[2], width))
+
+		case numberedRe.MatchString(line):
+			flush()
+			m := numberedRe.FindStringSubmatch(line)
+			out = append(out, listItem(indent(m[1]), m[2]+" ", m[3], width))
+
+		default:
+			para = append(para, trimmed)
+		}
+	}
+	flush()
+
+	return strings.TrimRight(strings.Join(out, "\n"), "\n")
+}
+
+// indent maps the leading whitespace of a nested list item to two columns per level.
+func indent(lead string) string {
+	n := len(strings.ReplaceAll(lead, "\t", "    "))
+	return strings.Repeat("  ", n/2)
+}
+
+// listItem wraps text with a hanging indent under marker.
+func listItem(prefix, marker, text string, width int) string {
+	pad := ansi.StringWidth(prefix + marker)
+	lines := strings.Split(ansi.Wrap(inline(text), max(width-pad, 5), ""), "\n")
+	for i := range lines {
+		if i == 0 {
+			lines[i] = prefix + marker + lines[i]
+		} else {
+			lines[i] = strings.Repeat(" ", pad) + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// inline applies emphasis, code and link styling within a line.
+func inline(s string) string {
+	s = tagRe.ReplaceAllString(s, "")
+	s = imageRe.ReplaceAllString(s, "[image: $1]")
+
+	// Protect inline code from the emphasis rules below.
+	var codes []string
+	s = inlineCodeRe.ReplaceAllStringFunc(s, func(m string) string {
+		codes = append(codes, codeStyle.Render(inlineCodeRe.FindStringSubmatch(m)[1]))
+		return "\x00" + strconv.Itoa(len(codes)-1) + "\x00"
+	})
+
+	s = linkRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := linkRe.FindStringSubmatch(m)
+		if sub[1] == sub[2] {
+			return linkStyle.Render(sub[1])
+		}
+		return linkStyle.Render(sub[1]) + " (" + sub[2] + ")"
+	})
+	s = boldRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := boldRe.FindStringSubmatch(m)
+		return boldStyle.Render(sub[1] + sub[2])
+	})
+	s = emRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := emRe.FindStringSubmatch(m)
+		return sub[1] + emStyle.Render(sub[2])
+	})
+
+	for i, c := range codes {
+		s = strings.Replace(s, "\x00"+strconv.Itoa(i)+"\x00", c, 1)
+	}
+	return s
+}
+
+// stripInline removes inline markup without styling, for text that gets a style of
+// its own such as headings.
+func stripInline(s string) string {
+	s = linkRe.ReplaceAllString(s, "$1")
+	s = inlineCodeRe.ReplaceAllString(s, "$1")
+	s = boldRe.ReplaceAllString(s, "$1$2")
+	return s
+}
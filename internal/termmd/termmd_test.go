@@ -0,0 +1,52 @@
+package termmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestRender(t *testing.T) {
+	md := "# v0.6.5\r\n\r\n<!-- generated -->\n" +
+		"## Changes\n" +
+		"- Fixed **Il2Cpp** startup on `Proton`\n" +
+		"  - nested item\n" +
+		"1. Read the [docs](https://melonwiki.xyz)\n" +
+		"> Breaking: mods built for 0.5 need a rebuild\n" +
+		"\n```\nMelonLoader.exe --help\n```\n" +
+		"---\n" +
+		"Plain *emphasis* and snake_case_names stay intact. ![logo](x.png)\n"
+
+	got := ansi.Strip(Render(md, 80))
+	for _, want := range []string{
+		"v0.6.5",
+		"Changes",
+		"• Fixed Il2Cpp startup on Proton",
+		"  • nested item",
+		"1. Read the docs (https://melonwiki.xyz)",
+		"│ Breaking: mods built for 0.5 need a rebuild",
+		"  MelonLoader.exe --help",
+		strings.Repeat("─", 80),
+		"Plain emphasis and snake_case_names stay intact. [image: logo]",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "generated") || strings.Contains(got, "\r") {
+		t.Errorf("comments and CRs should be removed:\n%s", got)
+	}
+}
+
+func TestRenderWraps(t *testing.T) {
+	md := "- " + strings.Repeat("word ", 20)
+	for _, line := range strings.Split(ansi.Strip(Render(md, 30)), "\n") {
+		if ansi.StringWidth(line) > 30 {
+			t.Fatalf("line exceeds width: %q", line)
+		}
+		if !strings.HasPrefix(line, "• ") && !strings.HasPrefix(line, "  ") {
+			t.Fatalf("continuation lines need a hanging indent: %q", line)
+		}
+	}
+}
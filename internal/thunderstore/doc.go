@@ -0,0 +1,11 @@
+// Package thunderstore reads Thunderstore mod packages and resolves their
+// dependencies against a Thunderstore API.
+//
+// A package is a zip archive with a manifest.json naming the package, its version
+// and its dependencies as "Namespace-Name-1.2.3" strings. Dependencies are fetched
+// from the API's experimental package endpoints and resolved recursively into an
+// install order, rejecting dependency cycles and incompatible version requirements.
+//
+// Profiles exported by r2modman and Thunderstore Mod Manager (.r2z) are read with
+// ReadProfile: a zip holding the profile's package list and its config files.
+package thunderstore
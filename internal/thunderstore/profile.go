@@ -0,0 +1,120 @@
+package thunderstore
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProfileList is the mod list of a profile export.
+const ProfileList = "export.r2x"
+
+// Profile is a profile exported by r2modman or Thunderstore Mod Manager: a zip
+// (.r2z) holding the mod list, export.r2x, and the profile's config files.
+type Profile struct {
+	Name string       `json:"name"`
+	Mods []ProfileMod `json:"mods"`
+	// Files are the config files bundled with the profile, as slash-separated paths
+	// in the archive, e.g. "UserData/MelonPreferences.cfg".
+	Files []string `json:"files,omitempty"`
+}
+
+// ProfileMod is a package in a profile.
+type ProfileMod struct {
+	Ref     Ref  `json:"ref"`
+	Enabled bool `json:"enabled"`
+}
+
+// r2x is the YAML of export.r2x.
+type r2x struct {
+	ProfileName string `yaml:"profileName"`
+	Mods        []struct {
+		Name    string `yaml:"name"`
+		Version struct {
+			Major int `yaml:"major"`
+			Minor int `yaml:"minor"`
+			Patch int `yaml:"patch"`
+		} `yaml:"version"`
+		Enabled *bool `yaml:"enabled"`
+	} `yaml:"mods"`
+}
+
+// ReadProfile reads the profile export at name.
+func ReadProfile(name string) (Profile, error) {
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return Profile{}, fmt.Errorf("open profile: %w", err)
+	}
+	defer zr.Close()
+
+	var p Profile
+	var list *zip.File
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		fn := strings.ReplaceAll(f.Name, `\`, "/")
+		if path.Clean(fn) == ProfileList {
+			list = f
+			continue
+		}
+		p.Files = append(p.Files, fn)
+	}
+	if list == nil {
+		return Profile{}, errors.New("not a profile export: no " + ProfileList)
+	}
+
+	rc, err := list.Open()
+	if err != nil {
+		return Profile{}, fmt.Errorf("read %s: %w", ProfileList, err)
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(io.LimitReader(rc, maxManifest))
+	if err != nil {
+		return Profile{}, fmt.Errorf("read %s: %w", ProfileList, err)
+	}
+	var doc r2x
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return Profile{}, fmt.Errorf("parse %s: %w", ProfileList, err)
+	}
+
+	p.Name = doc.ProfileName
+	for i, m := range doc.Mods {
+		r, err := ParseRef(m.Name)
+		if err != nil || r.Version != "" {
+			return Profile{}, fmt.Errorf("%s: mods[%d]: name %q is not Namespace-Name", ProfileList, i, m.Name)
+		}
+		v := m.Version
+		r.Version = fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+		// A mod without an enabled field is enabled.
+		p.Mods = append(p.Mods, ProfileMod{Ref: r, Enabled: m.Enabled == nil || *m.Enabled})
+	}
+	return p, nil
+}
+
+// Roots returns the nodes to resolve to install the packages of p at their listed
+// versions. Packages installed at that version, by Ref.Key, and MelonLoader are
+// kept as they are, so they are not looked up; the others are looked up with
+// client for their dependencies.
+func (p Profile) Roots(ctx context.Context, client *Client, installed map[string]Ref) ([]Node, error) {
+	var roots []Node
+	for _, pm := range p.Mods {
+		// The resolver keeps these without looking at their dependencies.
+		if have, ok := installed[pm.Ref.Key()]; pm.Ref.IsMelonLoader() || ok && have.Version == pm.Ref.Version {
+			roots = append(roots, Node{Ref: pm.Ref})
+			continue
+		}
+		n, err := client.Node(ctx, pm.Ref)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, n)
+	}
+	return roots, nil
+}
@@ -0,0 +1,214 @@
+package thunderstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"automelonloaderinstallergo/internal/version"
+)
+
+// Resolution failures, tested for with errors.Is.
+var (
+	// ErrCycle reports packages that depend on each other.
+	ErrCycle = errors.New("dependency cycle")
+	// ErrConflict reports requirements no single version satisfies.
+	ErrConflict = errors.New("version conflict")
+)
+
+// Node is a package version with its dependencies.
+type Node struct {
+	Ref  Ref   `json:"ref"`
+	Deps []Ref `json:"dependencies,omitempty"`
+	// Path is the archive of a package given as a local file; Version describes a
+	// package to download.
+	Path    string          `json:"path,omitempty"`
+	Version *PackageVersion `json:"-"`
+}
+
+// NodeOf returns the Node of a package version described by the API.
+func NodeOf(v PackageVersion) (Node, error) {
+	deps, err := Manifest{Dependencies: v.Dependencies}.Deps()
+	if err != nil {
+		return Node{}, fmt.Errorf("package %s: %w", v.Ref(), err)
+	}
+	return Node{Ref: v.Ref(), Deps: deps, Version: &v}, nil
+}
+
+// Resolver resolves the dependencies of packages into an install plan.
+//
+// Dependency versions are minimums, as Thunderstore mod managers treat them: the
+// highest version required of a package is chosen, and requirements on different
+// major versions conflict. Packages requested explicitly are used at exactly the
+// requested version, and are not reinstalled when that version is installed.
+type Resolver struct {
+	// Fetch describes an exact package version, e.g. Client.Package.
+	Fetch func(ctx context.Context, r Ref) (PackageVersion, error)
+	// Installed holds the installed packages by Ref.Key. Requirements they satisfy
+	// are not fetched.
+	Installed map[string]Ref
+	// Skip reports packages not installed as files, such as MelonLoader itself;
+	// requirements on them are collected in Plan.Skipped. It may be nil.
+	Skip func(Ref) bool
+}
+
+// Plan is the result of Resolve.
+type Plan struct {
+	// Install lists the packages to install, each after its dependencies.
+	Install []Node `json:"install"`
+	// Satisfied lists required packages that are installed already.
+	Satisfied []Ref `json:"satisfied,omitempty"`
+	// Skipped lists requirements on packages Resolver.Skip excludes.
+	Skipped []Ref `json:"skipped,omitempty"`
+}
+
+type selection struct {
+	node      Node
+	pinned    bool   // requested explicitly
+	installed bool   // already installed; node has no deps
+	by        string // who required this version, for messages
+}
+
+type resolution struct {
+	Resolver
+	ctx  context.Context
+	sel  map[string]*selection
+	plan Plan
+}
+
+// Resolve resolves roots, the packages requested, and their dependencies.
+func (r Resolver) Resolve(ctx context.Context, roots []Node) (Plan, error) {
+	res := &resolution{Resolver: r, ctx: ctx, sel: make(map[string]*selection), plan: Plan{Install: []Node{}}}
+	for i := range roots {
+		if err := res.require(roots[i].Ref, "requested", nil, &roots[i]); err != nil {
+			return Plan{}, err
+		}
+	}
+
+	// Order the final selection depth-first, dependencies first.
+	done := make(map[string]bool)
+	var walk func(key string)
+	walk = func(key string) {
+		s, ok := res.sel[key]
+		if !ok || done[key] {
+			return
+		}
+		done[key] = true
+		for _, d := range s.node.Deps {
+			walk(d.Key())
+		}
+		if !s.installed {
+			res.plan.Install = append(res.plan.Install, s.node)
+		}
+	}
+	for _, n := range roots {
+		walk(n.Ref.Key())
+	}
+	return res.plan, nil
+}
+
+// require selects a version of want, a requirement of by, and resolves its
+// dependencies. stack holds the packages being resolved, to find cycles; root is
+// the node of a requested package.
+func (res *resolution) require(want Ref, by string, stack []Ref, root *Node) error {
+	key := want.Key()
+	for i, s := range stack {
+		if s.Key() == key {
+			var path []string
+			for _, p := range append(stack[i:], want) {
+				path = append(path, p.Namespace+"-"+p.Name)
+			}
+			return fmt.Errorf("%w: %s", ErrCycle, strings.Join(path, " -> "))
+		}
+	}
+	if res.Skip != nil && res.Skip(want) {
+		res.plan.Skipped = appendRef(res.plan.Skipped, want)
+		return nil
+	}
+
+	if s, ok := res.sel[key]; ok {
+		have := s.node.Ref
+		newer, err := compare(want, have)
+		if err != nil {
+			return err
+		}
+		switch {
+		case !sameMajor(want, have):
+			return fmt.Errorf("%w: %s requires %s, but %s requires %s", ErrConflict, by, want, s.by, have)
+		case root != nil && newer < 0:
+			return fmt.Errorf("%w: %s requires %s, but %s was requested", ErrConflict, s.by, have, want)
+		case s.pinned && newer > 0:
+			return fmt.Errorf("%w: %s requires %s, but %s was requested", ErrConflict, by, want, have)
+		case newer <= 0:
+			s.pinned = s.pinned || root != nil
+			return nil
+		}
+		// A newer version is required: select it instead and resolve its dependencies.
+	} else if have, ok := res.Installed[key]; ok && (root == nil || have.Version == want.Version) {
+		newer, err := compare(want, have)
+		if err != nil {
+			return err
+		}
+		if !sameMajor(want, have) {
+			return fmt.Errorf("%w: %s requires %s, but %s is installed", ErrConflict, by, want, have)
+		}
+		if newer <= 0 {
+			res.sel[key] = &selection{node: Node{Ref: have}, pinned: root != nil, installed: true, by: "the installed version"}
+			res.plan.Satisfied = appendRef(res.plan.Satisfied, have)
+			return nil
+		}
+	}
+
+	var node Node
+	if root != nil {
+		node = *root
+	} else {
+		v, err := res.Fetch(res.ctx, want)
+		if err != nil {
+			return fmt.Errorf("%s requires %s: %w", by, want, err)
+		}
+		if node, err = NodeOf(v); err != nil {
+			return err
+		}
+	}
+	res.sel[key] = &selection{node: node, pinned: root != nil, by: by}
+
+	stack = append(stack, node.Ref)
+	for _, d := range node.Deps {
+		if err := res.require(d, node.Ref.String(), stack, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compare compares the versions of a and b.
+func compare(a, b Ref) (int, error) {
+	av, err := version.Parse(a.Version)
+	if err != nil {
+		return 0, fmt.Errorf("package %s: %w", a, err)
+	}
+	bv, err := version.Parse(b.Version)
+	if err != nil {
+		return 0, fmt.Errorf("package %s: %w", b, err)
+	}
+	return version.Compare(av, bv), nil
+}
+
+// sameMajor reports whether a and b have the same major version.
+func sameMajor(a, b Ref) bool {
+	am, _, _ := strings.Cut(a.Version, ".")
+	bm, _, _ := strings.Cut(b.Version, ".")
+	return am == bm
+}
+
+// appendRef appends r unless refs has the same package at the same version.
+func appendRef(refs []Ref, r Ref) []Ref {
+	for _, x := range refs {
+		if x.Key() == r.Key() && x.Version == r.Version {
+			return refs
+		}
+	}
+	return append(refs, r)
+}
@@ -0,0 +1,284 @@
+package thunderstore
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"automelonloaderinstallergo/internal/ghrel"
+)
+
+// DefaultURL is the public Thunderstore site.
+const DefaultURL = "https://thunderstore.io"
+
+// ErrNotFound reports a package or package version the API does not know.
+var ErrNotFound = errors.New("not found")
+
+var (
+	identRe   = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+	versionRe = regexp.MustCompile(`^\d+\.\d+\.\d+$`)
+)
+
+// Ref names a package, and optionally one of its versions.
+type Ref struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Version   string `json:"version,omitempty"`
+}
+
+// ParseRef parses "Namespace-Name" or "Namespace-Name-1.2.3".
+func ParseRef(s string) (Ref, error) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 2 || len(parts) > 3 || !identRe.MatchString(parts[0]) || !identRe.MatchString(parts[1]) {
+		return Ref{}, fmt.Errorf("package %q is not Namespace-Name or Namespace-Name-1.2.3", s)
+	}
+	r := Ref{Namespace: parts[0], Name: parts[1]}
+	if len(parts) == 3 {
+		if !versionRe.MatchString(parts[2]) {
+			return Ref{}, fmt.Errorf("package %q: version %q is not major.minor.patch", s, parts[2])
+		}
+		r.Version = parts[2]
+	}
+	return r, nil
+}
+
+// String returns the dependency string form, e.g. "Author-CoolMod-1.2.3".
+func (r Ref) String() string {
+	if r.Version == "" {
+		return r.Namespace + "-" + r.Name
+	}
+	return r.Namespace + "-" + r.Name + "-" + r.Version
+}
+
+// IsMelonLoader reports whether r is the MelonLoader package, which mods depend on
+// to name the loader version they need. It is not installed as a mod.
+func (r Ref) IsMelonLoader() bool {
+	return strings.EqualFold(r.Namespace, "LavaGang") && strings.EqualFold(r.Name, "MelonLoader")
+}
+
+// Key identifies the package regardless of version and case.
+func (r Ref) Key() string {
+	return strings.ToLower(r.Namespace + "-" + r.Name)
+}
+
+// Manifest is a package's manifest.json.
+type Manifest struct {
+	Name          string   `json:"name"`
+	VersionNumber string   `json:"version_number"`
+	WebsiteURL    string   `json:"website_url,omitempty"`
+	Description   string   `json:"description,omitempty"`
+	Dependencies  []string `json:"dependencies"`
+	// AuthorName is the package's namespace. Thunderstore fills it in for uploads;
+	// hand-made packages usually leave it out.
+	AuthorName string `json:"author_name,omitempty"`
+}
+
+// Deps parses the manifest's dependencies, which must name versions.
+func (m Manifest) Deps() ([]Ref, error) {
+	deps := make([]Ref, 0, len(m.Dependencies))
+	for _, d := range m.Dependencies {
+		r, err := ParseRef(d)
+		if err != nil {
+			return nil, err
+		}
+		if r.Version == "" {
+			return nil, fmt.Errorf("dependency %q has no version", d)
+		}
+		deps = append(deps, r)
+	}
+	return deps, nil
+}
+
+// maxManifest caps the size of manifest.json.
+const maxManifest = 1 << 20
+
+// ReadManifest reads manifest.json from the root of the package archive at name.
+func ReadManifest(name string) (Manifest, error) {
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("open package: %w", err)
+	}
+	defer zr.Close()
+	return readManifest(&zr.Reader)
+}
+
+func readManifest(zr *zip.Reader) (Manifest, error) {
+	var m Manifest
+	for _, f := range zr.File {
+		if path.Clean(f.Name) != "manifest.json" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return m, fmt.Errorf("read manifest.json: %w", err)
+		}
+		defer rc.Close()
+		b, err := io.ReadAll(io.LimitReader(rc, maxManifest))
+		if err != nil {
+			return m, fmt.Errorf("read manifest.json: %w", err)
+		}
+		// Many packages are written by tools that prepend a UTF-8 byte order mark.
+		b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+		if err := json.Unmarshal(b, &m); err != nil {
+			return m, fmt.Errorf("parse manifest.json: %w", err)
+		}
+		if !identRe.MatchString(m.Name) {
+			return m, fmt.Errorf("manifest.json: name %q is not a package name", m.Name)
+		}
+		if !versionRe.MatchString(m.VersionNumber) {
+			return m, fmt.Errorf("manifest.json: version_number %q is not major.minor.patch", m.VersionNumber)
+		}
+		if _, err := m.Deps(); err != nil {
+			return m, fmt.Errorf("manifest.json: %w", err)
+		}
+		return m, nil
+	}
+	return m, errors.New("not a Thunderstore package: no manifest.json")
+}
+
+// LocalRef returns the Ref of the package archive at name with manifest m. The
+// namespace is the manifest's author_name, or else taken from an archive named
+// like Thunderstore downloads, "Namespace-Name-1.2.3.zip"; failing both, it is
+// "local".
+func LocalRef(name string, m Manifest) Ref {
+	r := Ref{Namespace: m.AuthorName, Name: m.Name, Version: m.VersionNumber}
+	if !identRe.MatchString(r.Namespace) {
+		r.Namespace = "local"
+		base := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+		if fr, err := ParseRef(base); err == nil && strings.EqualFold(fr.Name, m.Name) {
+			r.Namespace = fr.Namespace
+		}
+	}
+	return r
+}
+
+// PackageVersion is one version of a package as the API describes it.
+type PackageVersion struct {
+	Namespace     string   `json:"namespace"`
+	Name          string   `json:"name"`
+	VersionNumber string   `json:"version_number"`
+	Description   string   `json:"description,omitempty"`
+	Dependencies  []string `json:"dependencies"`
+	DownloadURL   string   `json:"download_url"`
+	WebsiteURL    string   `json:"website_url,omitempty"`
+}
+
+// Ref returns the Ref of v.
+func (v PackageVersion) Ref() Ref {
+	return Ref{Namespace: v.Namespace, Name: v.Name, Version: v.VersionNumber}
+}
+
+// Client talks to a Thunderstore API.
+type Client struct {
+	http    *http.Client
+	baseURL string
+}
+
+// NewClient returns a client for the Thunderstore site at baseURL, e.g. DefaultURL.
+func NewClient(baseURL string) *Client {
+	return &Client{
+		http:    &http.Client{Timeout: 60 * time.Second},
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// Package describes version r.Version of a package, or its latest version when
+// r.Version is empty.
+func (c *Client) Package(ctx context.Context, r Ref) (PackageVersion, error) {
+	p := "/api/experimental/package/" + url.PathEscape(r.Namespace) + "/" + url.PathEscape(r.Name) + "/"
+	if r.Version != "" {
+		var v PackageVersion
+		err := c.getJSON(ctx, p+url.PathEscape(r.Version)+"/", r, &v)
+		return v, err
+	}
+	var pkg struct {
+		Latest PackageVersion `json:"latest"`
+	}
+	if err := c.getJSON(ctx, p, r, &pkg); err != nil {
+		return PackageVersion{}, err
+	}
+	if pkg.Latest.VersionNumber == "" {
+		return PackageVersion{}, fmt.Errorf("package %s has no versions", r)
+	}
+	return pkg.Latest, nil
+}
+
+// Node describes version r.Version of a package, or its latest version when
+// r.Version is empty, with its dependencies.
+func (c *Client) Node(ctx context.Context, r Ref) (Node, error) {
+	v, err := c.Package(ctx, r)
+	if err != nil {
+		return Node{}, err
+	}
+	return NodeOf(v)
+}
+
+func (c *Client) getJSON(ctx context.Context, p string, r Ref, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+p, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch package %s: %w", r, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return fmt.Errorf("package %s %w on %s", r, ErrNotFound, c.baseURL)
+	default:
+		return ghrel.NewStatusError("fetch package "+r.String(), resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("fetch package %s: decode JSON: %w", r, err)
+	}
+	return nil
+}
+
+// Download writes the archive of v to outPath atomically, and checks that its
+// manifest names the package and version that was asked for.
+func (c *Client) Download(ctx context.Context, v PackageVersion, outPath string) error {
+	if v.DownloadURL == "" {
+		return fmt.Errorf("package %s has no download URL", v.Ref())
+	}
+	err := ghrel.WriteFileAtomically(outPath, func(f *os.File) error {
+		if err := ghrel.DownloadToWriter(ctx, c.http, v.DownloadURL, "", f); err != nil {
+			return err
+		}
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		zr, err := zip.NewReader(f, info.Size())
+		if err != nil {
+			return fmt.Errorf("package %s: %w", v.Ref(), err)
+		}
+		m, err := readManifest(zr)
+		if err != nil {
+			return fmt.Errorf("package %s: %w", v.Ref(), err)
+		}
+		if !strings.EqualFold(m.Name, v.Name) || m.VersionNumber != v.VersionNumber {
+			return fmt.Errorf("package %s: downloaded archive is %s %s", v.Ref(), m.Name, m.VersionNumber)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("download %s: %w", v.Ref(), err)
+	}
+	return nil
+}
@@ -0,0 +1,385 @@
+package thunderstore
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func packageZip(t *testing.T, m Manifest) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("manifest.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Prepend a byte order mark, as many packaging tools do.
+	_, _ = w.Write([]byte("\xef\xbb\xbf"))
+	if err := json.NewEncoder(w).Encode(m); err != nil {
+		t.Fatal(err)
+	}
+	if w, err = zw.Create("Mods/" + m.Name + ".dll"); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write([]byte("MZ"))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// newRegistry serves the experimental package API for the given packages,
+// "Namespace-Name-1.2.3" mapped to their dependencies. The last version listed of
+// a package is its latest.
+func newRegistry(t *testing.T, packages [][2]any) *httptest.Server {
+	t.Helper()
+	versions := map[string]PackageVersion{}
+	latest := map[string]PackageVersion{}
+	var srv *httptest.Server
+	for _, p := range packages {
+		r, err := ParseRef(p[0].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := PackageVersion{
+			Namespace:     r.Namespace,
+			Name:          r.Name,
+			VersionNumber: r.Version,
+			Dependencies:  p[1].([]string),
+		}
+		versions[r.String()] = v
+		latest[r.Key()] = v
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/experimental/package/", func(w http.ResponseWriter, req *http.Request) {
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(req.URL.Path, "/api/experimental/package/"), "/"), "/")
+		var v PackageVersion
+		var ok bool
+		switch len(parts) {
+		case 2:
+			v, ok = latest[strings.ToLower(parts[0]+"-"+parts[1])]
+		case 3:
+			v, ok = versions[strings.Join(parts, "-")]
+		}
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		v.DownloadURL = srv.URL + "/dl/" + v.Ref().String() + ".zip"
+		var body any = v
+		if len(parts) == 2 {
+			body = map[string]any{"namespace": v.Namespace, "name": v.Name, "latest": v}
+		}
+		_ = json.NewEncoder(w).Encode(body)
+	})
+	mux.HandleFunc("/dl/", func(w http.ResponseWriter, req *http.Request) {
+		name := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/dl/"), ".zip")
+		v, ok := versions[name]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		_, _ = w.Write(packageZip(t, Manifest{Name: v.Name, VersionNumber: v.VersionNumber, Dependencies: v.Dependencies}))
+	})
+	mux.HandleFunc("/dl-wrong/", func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write(packageZip(t, Manifest{Name: "Core", VersionNumber: "9.9.9"}))
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestParseRef(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    Ref
+		wantErr bool
+	}{
+		{in: "Author-CoolMod", want: Ref{Namespace: "Author", Name: "CoolMod"}},
+		{in: "Author-Cool_Mod-1.2.3", want: Ref{Namespace: "Author", Name: "Cool_Mod", Version: "1.2.3"}},
+		{in: "CoolMod", wantErr: true},
+		{in: "Author-CoolMod-1.2", wantErr: true},
+		{in: "Author-Cool Mod-1.2.3", wantErr: true},
+		{in: "A-B-1.0.0-x", wantErr: true},
+	} {
+		got, err := ParseRef(tc.in)
+		if (err != nil) != tc.wantErr || (!tc.wantErr && got != tc.want) {
+			t.Errorf("ParseRef(%q) = %+v, %v; want %+v, error %v", tc.in, got, err, tc.want, tc.wantErr)
+		}
+	}
+	if r := (Ref{Namespace: "lavagang", Name: "melonloader", Version: "0.6.1"}); !r.IsMelonLoader() {
+		t.Error("IsMelonLoader is case-sensitive")
+	}
+}
+
+func TestLocalRef(t *testing.T) {
+	m := Manifest{Name: "CoolMod", VersionNumber: "1.0.0"}
+	for name, want := range map[string]string{
+		"/tmp/Author-CoolMod-1.0.0.zip": "Author-CoolMod-1.0.0",
+		"/tmp/Author-Other-1.0.0.zip":   "local-CoolMod-1.0.0",
+		"/tmp/CoolMod.zip":              "local-CoolMod-1.0.0",
+	} {
+		if got := LocalRef(name, m).String(); got != want {
+			t.Errorf("LocalRef(%s) = %s; want %s", name, got, want)
+		}
+	}
+	m.AuthorName = "Someone"
+	if got := LocalRef("/tmp/Author-CoolMod-1.0.0.zip", m).String(); got != "Someone-CoolMod-1.0.0" {
+		t.Errorf("LocalRef with author_name = %s", got)
+	}
+}
+
+var registry = [][2]any{
+	{"A-Core-1.0.0", []string{}},
+	{"A-Core-1.2.0", []string{}},
+	{"A-Core-2.0.0", []string{}},
+	{"A-Lib-1.0.0", []string{"A-Core-1.0.0"}},
+	{"A-App-2.0.0", []string{"A-Lib-1.0.0", "A-Core-1.2.0", "LavaGang-MelonLoader-0.6.1"}},
+	{"A-Next-1.0.0", []string{"A-Core-2.0.0"}},
+	{"X-One-1.0.0", []string{"X-Two-1.0.0"}},
+	{"X-Two-1.0.0", []string{"X-Three-1.0.0"}},
+	{"X-Three-1.0.0", []string{"X-One-1.0.0"}},
+}
+
+func resolve(t *testing.T, c *Client, installed map[string]Ref, roots ...string) (Plan, error) {
+	t.Helper()
+	var nodes []Node
+	for _, s := range roots {
+		r, err := ParseRef(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := c.Package(context.Background(), r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := NodeOf(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes = append(nodes, n)
+	}
+	return Resolver{Fetch: c.Package, Installed: installed, Skip: Ref.IsMelonLoader}.Resolve(context.Background(), nodes)
+}
+
+func refs(nodes []Node) []string {
+	var out []string
+	for _, n := range nodes {
+		out = append(out, n.Ref.String())
+	}
+	return out
+}
+
+func TestResolve(t *testing.T) {
+	c := NewClient(newRegistry(t, registry).URL)
+
+	plan, err := resolve(t, c, nil, "A-App")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Lib asks for Core 1.0.0 first; App's 1.2.0 wins as the higher minimum.
+	if got, want := refs(plan.Install), []string{"A-Core-1.2.0", "A-Lib-1.0.0", "A-App-2.0.0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("install %v; want %v", got, want)
+	}
+	if len(plan.Skipped) != 1 || plan.Skipped[0].String() != "LavaGang-MelonLoader-0.6.1" {
+		t.Errorf("skipped %v", plan.Skipped)
+	}
+
+	installed := map[string]Ref{"a-core": {Namespace: "A", Name: "Core", Version: "1.5.0"}}
+	plan, err = resolve(t, c, installed, "A-App")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := refs(plan.Install), []string{"A-Lib-1.0.0", "A-App-2.0.0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("install %v; want %v", got, want)
+	}
+	if len(plan.Satisfied) != 1 || plan.Satisfied[0].Version != "1.5.0" {
+		t.Errorf("satisfied %v", plan.Satisfied)
+	}
+
+	// A requested package installed at that version is not reinstalled.
+	plan, err = resolve(t, c, map[string]Ref{"a-app": {Namespace: "A", Name: "App", Version: "2.0.0"}}, "A-App-2.0.0")
+	if err != nil || len(plan.Install) != 0 || len(plan.Satisfied) != 1 {
+		t.Errorf("reinstall plan = %+v, %v; want App satisfied", plan, err)
+	}
+
+	// An installed version too old is upgraded.
+	installed["a-core"] = Ref{Namespace: "A", Name: "Core", Version: "1.1.0"}
+	if plan, err = resolve(t, c, installed, "A-App"); err != nil {
+		t.Fatal(err)
+	}
+	if got := refs(plan.Install); got[0] != "A-Core-1.2.0" {
+		t.Errorf("install %v; want Core upgraded", got)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	c := NewClient(newRegistry(t, registry).URL)
+
+	_, err := resolve(t, c, nil, "X-One")
+	if !errors.Is(err, ErrCycle) || !strings.Contains(err.Error(), "X-One -> X-Two -> X-Three -> X-One") {
+		t.Errorf("cycle: %v", err)
+	}
+
+	_, err = resolve(t, c, nil, "A-App", "A-Next")
+	if !errors.Is(err, ErrConflict) || !strings.Contains(err.Error(), "A-Core-2.0.0") {
+		t.Errorf("major conflict: %v", err)
+	}
+
+	// A requested version older than a dependency requires.
+	_, err = resolve(t, c, nil, "A-App", "A-Core-1.0.0")
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("pinned conflict: %v", err)
+	}
+
+	installed := map[string]Ref{"a-core": {Namespace: "A", Name: "Core", Version: "2.1.0"}}
+	if _, err = resolve(t, c, installed, "A-Lib"); !errors.Is(err, ErrConflict) || !strings.Contains(err.Error(), "is installed") {
+		t.Errorf("installed conflict: %v", err)
+	}
+
+	_, err = c.Package(context.Background(), Ref{Namespace: "A", Name: "Nope"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing package: %v", err)
+	}
+}
+
+func TestDownload(t *testing.T) {
+	srv := newRegistry(t, registry)
+	c := NewClient(srv.URL)
+	dir := t.TempDir()
+
+	v, err := c.Package(context.Background(), Ref{Namespace: "A", Name: "Lib", Version: "1.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "lib.zip")
+	if err := c.Download(context.Background(), v, out); err != nil {
+		t.Fatal(err)
+	}
+	m, err := ReadManifest(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "Lib" || m.VersionNumber != "1.0.0" || !reflect.DeepEqual(m.Dependencies, []string{"A-Core-1.0.0"}) {
+		t.Errorf("manifest %+v", m)
+	}
+
+	v.DownloadURL = srv.URL + "/dl-wrong/x.zip"
+	bad := filepath.Join(dir, "bad.zip")
+	if err := c.Download(context.Background(), v, bad); err == nil || !strings.Contains(err.Error(), "Core 9.9.9") {
+		t.Errorf("mismatched archive: %v", err)
+	}
+	if _, err := os.Stat(bad); !os.IsNotExist(err) {
+		t.Errorf("mismatched archive was kept: %v", err)
+	}
+
+	v.DownloadURL = fmt.Sprintf("%s/dl/A-Lib-7.0.0.zip", srv.URL)
+	if err := c.Download(context.Background(), v, bad); err == nil {
+		t.Error("missing archive downloaded")
+	}
+}
+
+func TestReadProfile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "profile.r2z")
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for fn, body := range map[string]string{
+		"export.r2x": `profileName: Default
+mods:
+  - name: LavaGang-MelonLoader
+    version: {major: 0, minor: 6, patch: 1}
+    enabled: true
+  - name: Jane-CoolMod
+    version:
+      major: 1
+      minor: 2
+      patch: 3
+    enabled: false
+  - name: Jane-CoolLib
+    version: {major: 2, minor: 0, patch: 0}
+`,
+		"UserData/MelonPreferences.cfg": "[CoolMod]",
+		"BepInEx/config/x.cfg":          "x",
+	} {
+		w, err := zw.Create(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	p, err := ReadProfile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ProfileMod{
+		{Ref: Ref{Namespace: "LavaGang", Name: "MelonLoader", Version: "0.6.1"}, Enabled: true},
+		{Ref: Ref{Namespace: "Jane", Name: "CoolMod", Version: "1.2.3"}},
+		{Ref: Ref{Namespace: "Jane", Name: "CoolLib", Version: "2.0.0"}, Enabled: true},
+	}
+	if p.Name != "Default" || !reflect.DeepEqual(p.Mods, want) {
+		t.Errorf("profile = %+v; want mods %+v", p, want)
+	}
+	if len(p.Files) != 2 {
+		t.Errorf("files = %v; want the two config files", p.Files)
+	}
+
+	if _, err := ReadProfile(filepath.Join("..", "clrmeta", "testdata", "CoolMod.dll")); err == nil {
+		t.Error("ReadProfile accepted a DLL")
+	}
+}
+
+func TestProfileRoots(t *testing.T) {
+	c := NewClient(newRegistry(t, registry).URL)
+	ml := Ref{Namespace: "LavaGang", Name: "MelonLoader", Version: "0.6.1"}
+	gone := Ref{Namespace: "Z", Name: "Gone", Version: "1.0.0"} // not on the site
+	lib := Ref{Namespace: "A", Name: "Lib", Version: "1.0.0"}
+	p := Profile{Mods: []ProfileMod{{Ref: ml}, {Ref: gone}, {Ref: lib}}}
+
+	// MelonLoader and packages installed at the listed version are not looked up.
+	roots, err := p.Roots(context.Background(), c, map[string]Ref{gone.Key(): gone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Node{{Ref: ml}, {Ref: gone}}
+	if len(roots) != 3 || !reflect.DeepEqual(roots[:2], want) {
+		t.Fatalf("roots = %+v", roots)
+	}
+	if roots[2].Ref != lib || roots[2].Version == nil || !reflect.DeepEqual(refStrings(roots[2].Deps), []string{"A-Core-1.0.0"}) {
+		t.Errorf("looked-up root = %+v", roots[2])
+	}
+
+	// Installed at another version, a package is looked up.
+	older := gone
+	older.Version = "0.9.0"
+	if _, err := p.Roots(context.Background(), c, map[string]Ref{gone.Key(): older}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Roots with an older install = %v, want ErrNotFound", err)
+	}
+}
+
+func refStrings(rs []Ref) []string {
+	var out []string
+	for _, r := range rs {
+		out = append(out, r.String())
+	}
+	return out
+}
@@ -0,0 +1,186 @@
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Constraint restricts versions with comparisons such as ">=0.6.0, <0.7" or
+// "~0.6.2 || ^1.0". Comparisons separated by commas or spaces must all hold;
+// "||" separates alternatives of which one must hold.
+//
+// Supported operators:
+//   - "=" (or "==", or none), "!=", ">", ">=", "<", "<="
+//   - "~1.2.3" allows patch updates: >=1.2.3, <1.3
+//   - "^1.2.3" allows updates that keep the left-most non-zero segment: >=1.2.3, <2
+//   - "0.6.x" or "0.6.*" matches any version starting with 0.6
+//
+// Versions are compared like Greater. As with most semver tools, prereleases
+// satisfy a constraint only if it names a prerelease itself, so "<0.7.0" rejects
+// "0.7.0-beta.1" while ">=0.7.0-beta.1" admits it.
+type Constraint struct {
+	raw  string
+	alts [][]comparison
+	pre  bool // some comparison names a prerelease
+}
+
+type comparison struct {
+	op string
+	v  string
+}
+
+var opSpaceRe = regexp.MustCompile(`(>=|<=|!=|==|>|<|=|~|\^)\s+`)
+
+// ParseConstraint parses a constraint expression. Leading "v"s on versions are ignored.
+func ParseConstraint(s string) (Constraint, error) {
+	c := Constraint{raw: strings.TrimSpace(s)}
+	if c.raw == "" {
+		return c, fmt.Errorf("version constraint is empty")
+	}
+	for _, alt := range strings.Split(c.raw, "||") {
+		alt = opSpaceRe.ReplaceAllString(alt, "$1")
+		fields := strings.FieldsFunc(alt, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+		if len(fields) == 0 {
+			return c, fmt.Errorf("version constraint %q: empty alternative", c.raw)
+		}
+		var cmps []comparison
+		for _, f := range fields {
+			got, err := parseComparison(f)
+			if err != nil {
+				return c, fmt.Errorf("version constraint %q: %w", c.raw, err)
+			}
+			for _, cmp := range got {
+				c.pre = c.pre || IsPrerelease(cmp.v)
+			}
+			cmps = append(cmps, got...)
+		}
+		c.alts = append(c.alts, cmps)
+	}
+	return c, nil
+}
+
+// parseComparison expands one term into plain comparisons.
+func parseComparison(term string) ([]comparison, error) {
+	op := ""
+	for _, o := range []string{">=", "<=", "!=", "==", ">", "<", "=", "~", "^"} {
+		if strings.HasPrefix(term, o) {
+			op = o
+			break
+		}
+	}
+	v := NormalizeTag(term[len(op):])
+	if op == "==" || op == "" {
+		op = "="
+	}
+
+	if i := strings.IndexAny(v, "x*X"); i >= 0 {
+		if op != "=" || (i > 0 && v[i-1] != '.') || i != len(v)-1 {
+			return nil, fmt.Errorf("wildcard %q must be a final segment of an exact match", term)
+		}
+		if i == 0 {
+			return nil, nil // "*" matches everything
+		}
+		prefix := strings.TrimSuffix(v[:i], ".")
+		upper, err := bump(prefix, strings.Count(prefix, "."))
+		if err != nil {
+			return nil, err
+		}
+		return []comparison{{">=", prefix}, {"<", upper}}, nil
+	}
+
+	k, err := Parse(v)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a version", term)
+	}
+
+	switch op {
+	case "~":
+		idx := min(1, len(k.Segments)-1)
+		upper, err := bump(v, idx)
+		if err != nil {
+			return nil, err
+		}
+		return []comparison{{">=", v}, {"<", upper}}, nil
+	case "^":
+		idx := len(k.Segments) - 1
+		for i, n := range k.Segments {
+			if n != 0 {
+				idx = i
+				break
+			}
+		}
+		upper, err := bump(v, idx)
+		if err != nil {
+			return nil, err
+		}
+		return []comparison{{">=", v}, {"<", upper}}, nil
+	}
+	return []comparison{{op, v}}, nil
+}
+
+// bump returns v's core with segment idx incremented and later segments dropped,
+// e.g. bump("1.2.3", 1) == "1.3".
+func bump(v string, idx int) (string, error) {
+	k, err := Parse(v)
+	if err != nil || idx >= len(k.Segments) {
+		return "", fmt.Errorf("%q is not a version", v)
+	}
+	parts := make([]string, idx+1)
+	for i := 0; i <= idx; i++ {
+		parts[i] = strconv.Itoa(k.Segments[i])
+	}
+	parts[idx] = strconv.Itoa(k.Segments[idx] + 1)
+	return strings.Join(parts, "."), nil
+}
+
+// Check reports whether v (with or without a leading "v") satisfies c. Values that
+// are not version-like never do.
+func (c Constraint) Check(v string) bool {
+	v = NormalizeTag(v)
+	k, err := Parse(v)
+	if err != nil || (k.IsPrerelease() && !c.pre) {
+		return false
+	}
+	for _, alt := range c.alts {
+		ok := true
+		for _, cmp := range alt {
+			if !cmp.holds(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (cmp comparison) holds(v string) bool {
+	d := 0
+	switch {
+	case Greater(v, cmp.v):
+		d = 1
+	case Greater(cmp.v, v):
+		d = -1
+	}
+	switch cmp.op {
+	case "=":
+		return d == 0
+	case "!=":
+		return d != 0
+	case ">":
+		return d > 0
+	case ">=":
+		return d >= 0
+	case "<":
+		return d < 0
+	default: // "<="
+		return d <= 0
+	}
+}
+
+// String returns the constraint as written.
+func (c Constraint) String() string { return c.raw }
@@ -0,0 +1,44 @@
+package version
+
+import "testing"
+
+func TestConstraint(t *testing.T) {
+	cases := []struct {
+		expr string
+		yes  []string
+		no   []string
+	}{
+		{">=0.6.0, <0.7", []string{"0.6.0", "v0.6.5"}, []string{"0.5.9", "0.7.0", "0.7.0-beta.1", "nightly-3"}},
+		{">=0.7.0-beta.1", []string{"0.7.0-beta.2", "0.7.0"}, []string{"0.7.0-alpha"}},
+		{">= 0.6.1 <= 0.6.3", []string{"0.6.1", "0.6.3"}, []string{"0.6.0", "0.6.4"}},
+		{"~0.6.2", []string{"0.6.2", "0.6.9"}, []string{"0.6.1", "0.7.0"}},
+		{"^1.2.3", []string{"1.2.3", "1.9.0"}, []string{"2.0.0", "1.2.2"}},
+		{"^0.6.2", []string{"0.6.2", "0.6.8"}, []string{"0.7.0"}},
+		{"0.5.x || =v0.6.5", []string{"0.5.0", "0.5.7", "0.6.5"}, []string{"0.6.4", "0.4.9"}},
+		{"!=0.6.4", []string{"0.6.5"}, []string{"0.6.4"}},
+		{"0.6", []string{"0.6.0"}, []string{"0.6.1"}},
+		{"*", []string{"0.1", "9.9.9"}, []string{"main"}},
+	}
+	for _, tc := range cases {
+		c, err := ParseConstraint(tc.expr)
+		if err != nil {
+			t.Fatalf("ParseConstraint(%q): %v", tc.expr, err)
+		}
+		for _, v := range tc.yes {
+			if !c.Check(v) {
+				t.Errorf("%q should admit %q", tc.expr, v)
+			}
+		}
+		for _, v := range tc.no {
+			if c.Check(v) {
+				t.Errorf("%q should reject %q", tc.expr, v)
+			}
+		}
+	}
+
+	for _, bad := range []string{"", ">=", "~main", "0.x.1", ">0.6.x", "1 ||"} {
+		if _, err := ParseConstraint(bad); err == nil {
+			t.Errorf("ParseConstraint(%q) succeeded", bad)
+		}
+	}
+}
@@ -1,127 +1,256 @@
 package version
 
 import (
+	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"unicode"
 )
 
-// NormalizeTag strips a single leading "v" or "V" from a git tag for display.
+// Version is a parsed release version such as "0.6.5", "v0.7.0-beta.1+build.42"
+// or, with CalVer enabled, "2024.05.1".
+//
+// Versions may have any number of numeric segments ("0.2.7.4", "1.2", "1") and
+// order like SemVer 2.0:
+//   - segments compare numerically, missing segments counting as 0
+//   - a release ranks above its prereleases
+//   - prerelease identifiers compare per SemVer (numeric < non-numeric, etc.)
+//   - build metadata is kept but ignored for ordering
+//
+// With CalVer enabled, calendar versions rank above all other versions, on the
+// assumption that a project switches from SemVer to CalVer and not back.
+type Version struct {
+	// Prefix is the tag prefix stripped before parsing, e.g. "v" or "release-".
+	Prefix     string
+	Segments   []int
+	Prerelease []string
+	Build      string
+	// CalVer marks a calendar version (YYYY.MM[.x...] or YYYY-MM-DD).
+	CalVer bool
+
+	text string // the version as written, without Prefix
+}
+
+// Options configure how tags are parsed; see Configure.
+type Options struct {
+	// TagPrefixes are regular expressions for tag prefixes stripped before parsing,
+	// such as `release-` or `ml-`. They are tried in order after the built-in "v" and
+	// "V", and only apply when the rest of the tag starts with a digit.
+	TagPrefixes []string
+
+	// CalVer recognises calendar versions: a four-digit year followed by a month,
+	// e.g. "2024.05.1", or a date such as "2024-05-01". Without it the former parses
+	// as an ordinary version with major version 2024, and the latter as 2024 with
+	// the prerelease "05-01".
+	CalVer bool
+}
+
+var (
+	optMu    sync.RWMutex
+	prefixes []*regexp.Regexp
+	calVer   bool
+)
+
+// Configure sets the options used by Parse and every function built on it. It is
+// meant to be called once at startup.
+func Configure(o Options) error {
+	res := make([]*regexp.Regexp, 0, len(o.TagPrefixes))
+	for _, p := range o.TagPrefixes {
+		re, err := regexp.Compile(`^(?:` + p + `)`)
+		if err != nil {
+			return fmt.Errorf("tag prefix %q: %w", p, err)
+		}
+		res = append(res, re)
+	}
+	optMu.Lock()
+	defer optMu.Unlock()
+	prefixes, calVer = res, o.CalVer
+	return nil
+}
+
+// splitPrefix separates a tag prefix from the version that follows it.
+func splitPrefix(tag string) (prefix, rest string) {
+	if len(tag) > 1 && (tag[0] == 'v' || tag[0] == 'V') {
+		return tag[:1], tag[1:]
+	}
+	optMu.RLock()
+	defer optMu.RUnlock()
+	for _, re := range prefixes {
+		if loc := re.FindStringIndex(tag); loc != nil && loc[1] < len(tag) && isDigit(tag[loc[1]]) {
+			return tag[:loc[1]], tag[loc[1]:]
+		}
+	}
+	return "", tag
+}
+
+// NormalizeTag strips the tag prefix from a git tag for display: a single leading
+// "v" or "V", or a prefix configured with Configure.
 //
 // Examples:
 //   - "v0.6.5" -> "0.6.5"
 //   - "V1.2"   -> "1.2"
 //   - "1.2"    -> "1.2"
+//   - "release-1.2" -> "1.2" when `release-` is a configured prefix
 func NormalizeTag(tag string) string {
-	tag = strings.TrimSpace(tag)
-	if len(tag) > 1 && (tag[0] == 'v' || tag[0] == 'V') {
-		return tag[1:]
-	}
-	return tag
+	_, rest := splitPrefix(strings.TrimSpace(tag))
+	return rest
 }
 
-// versionKey supports any number of numeric dot segments:
-//   - "0.2.7.4"
-//   - "1.2.3"
-//   - "1.2"
-//   - "1"
-// and semver-like prerelease ordering:
-//   - release > prerelease (same core)
-//   - prerelease identifiers compared per semver rules (numeric < non-numeric, etc.)
-//
-// This mirrors the baseline behavior previously embedded in the TUI.
-// It is intentionally conservative ("version-like" must start with a digit).
-
-type versionKey struct {
-	ok     bool
-	core   []int
-	hasPre bool
-	pre    []string
-}
+var calVerDateRe = regexp.MustCompile(`^(\d{4})-(\d{2})-(\d{2})$`)
 
-func parseVersion(s string) versionKey {
+// Parse parses a tag or version. Tag prefixes are stripped first (see NormalizeTag).
+// The version must start with a digit.
+func Parse(s string) (Version, error) {
 	s = strings.TrimSpace(s)
-	var k versionKey
+	prefix, rest := splitPrefix(s)
+	v := Version{Prefix: prefix, text: rest}
 
-	// Require leading digit to treat as version-like.
-	if s == "" || !unicode.IsDigit(rune(s[0])) {
-		return k
+	if rest == "" || !isDigit(rest[0]) {
+		return Version{}, fmt.Errorf("version %q must start with a digit", s)
 	}
 
-	main := s
-	pre := ""
-	if i := strings.IndexByte(s, '-'); i >= 0 {
-		main = s[:i]
-		pre = s[i+1:]
-		k.hasPre = true
-	}
+	optMu.RLock()
+	cal := calVer
+	optMu.RUnlock()
 
-	coreParts := strings.Split(main, ".")
-	if len(coreParts) == 0 {
-		return versionKey{}
+	main := rest
+	if i := strings.IndexByte(main, '+'); i >= 0 {
+		v.Build = main[i+1:]
+		main = main[:i]
+		if v.Build == "" {
+			return Version{}, fmt.Errorf("version %q has empty build metadata", s)
+		}
 	}
-
-	k.core = make([]int, 0, len(coreParts))
-	for _, p := range coreParts {
-		if p == "" {
-			return versionKey{}
+	if cal {
+		if m := calVerDateRe.FindStringSubmatch(main); m != nil {
+			main = m[1] + "." + m[2] + "." + m[3]
 		}
-		for _, r := range p {
-			if !unicode.IsDigit(r) {
-				return versionKey{}
-			}
+	}
+	hasPre := false
+	if i := strings.IndexByte(main, '-'); i >= 0 {
+		hasPre = true
+		if pre := main[i+1:]; pre != "" {
+			v.Prerelease = strings.Split(pre, ".")
+		}
+		main = main[:i]
+	}
+
+	for _, p := range strings.Split(main, ".") {
+		if p == "" || !allDigits(p) {
+			return Version{}, fmt.Errorf("version %q: segment %q is not a number", s, p)
 		}
-		v, err := strconv.Atoi(p)
+		n, err := strconv.Atoi(p)
 		if err != nil {
-			return versionKey{}
+			return Version{}, fmt.Errorf("version %q: segment %q: %w", s, p, err)
 		}
-		k.core = append(k.core, v)
+		v.Segments = append(v.Segments, n)
 	}
-
-	if k.hasPre && pre != "" {
-		k.pre = strings.Split(pre, ".")
+	if hasPre && len(v.Prerelease) == 0 {
+		// Keep "1.0-" distinct from "1.0": it is a prerelease with no identifiers.
+		v.Prerelease = []string{}
 	}
 
-	k.ok = true
-	return k
+	v.CalVer = cal && len(v.Segments) >= 2 && len(strings.Split(main, ".")[0]) == 4 &&
+		v.Segments[0] >= 1970 && v.Segments[1] >= 1 && v.Segments[1] <= 12
+	return v, nil
 }
 
-func isNumericIdent(s string) (int, bool) {
-	if s == "" {
-		return 0, false
+// IsPrerelease reports whether v has a prerelease suffix, such as "-beta.1".
+func (v Version) IsPrerelease() bool { return v.Prerelease != nil }
+
+// String returns the version as written, without its tag prefix.
+func (v Version) String() string {
+	if v.text != "" {
+		return v.text
+	}
+	segs := make([]string, len(v.Segments))
+	for i, n := range v.Segments {
+		segs[i] = strconv.Itoa(n)
+	}
+	s := strings.Join(segs, ".")
+	if v.IsPrerelease() {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare returns -1, 0 or +1 as a orders before, equal to or after b.
+// Versions differing only in build metadata or trailing zero segments are equal.
+func Compare(a, b Version) int {
+	if a.CalVer != b.CalVer {
+		if a.CalVer {
+			return 1
+		}
+		return -1
 	}
+
+	for i := 0; i < max(len(a.Segments), len(b.Segments)); i++ {
+		av, bv := 0, 0
+		if i < len(a.Segments) {
+			av = a.Segments[i]
+		}
+		if i < len(b.Segments) {
+			bv = b.Segments[i]
+		}
+		if av != bv {
+			if av < bv {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	// Same core: release > prerelease.
+	switch {
+	case a.IsPrerelease() && !b.IsPrerelease():
+		return -1
+	case !a.IsPrerelease() && b.IsPrerelease():
+		return 1
+	}
+	return cmpPrerelease(a.Prerelease, b.Prerelease)
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func allDigits(s string) bool {
 	for _, r := range s {
-		if !unicode.IsDigit(r) {
-			return 0, false
+		if !unicode.IsDigit(r) || r > unicode.MaxASCII {
+			return false
 		}
 	}
-	v, err := strconv.Atoi(s)
-	if err != nil {
-		return 0, false
+	return true
+}
+
+// cmpNumeric compares two strings of ASCII digits by value without overflowing.
+func cmpNumeric(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
 	}
-	return v, true
+	return strings.Compare(a, b)
 }
 
 func cmpPrerelease(a, b []string) int {
 	// -1 if a<b, 0 if equal, +1 if a>b (semver precedence rules)
-	n := len(a)
-	if len(b) < n {
-		n = len(b)
-	}
+	n := min(len(a), len(b))
 	for i := 0; i < n; i++ {
-		ai := a[i]
-		bi := b[i]
-
-		ain, aNum := isNumericIdent(ai)
-		bin, bNum := isNumericIdent(bi)
+		ai, bi := a[i], b[i]
+		aNum := ai != "" && allDigits(ai)
+		bNum := bi != "" && allDigits(bi)
 
 		switch {
 		case aNum && bNum:
-			if ain < bin {
-				return -1
-			}
-			if ain > bin {
-				return 1
+			if c := cmpNumeric(ai, bi); c != 0 {
+				return c
 			}
 		case aNum && !bNum:
 			// numeric < non-numeric
@@ -129,19 +258,16 @@ func cmpPrerelease(a, b []string) int {
 		case !aNum && bNum:
 			return 1
 		default:
-			if ai < bi {
-				return -1
-			}
-			if ai > bi {
-				return 1
+			if c := strings.Compare(ai, bi); c != 0 {
+				return c
 			}
 		}
 	}
 	// If equal prefix, shorter prerelease has lower precedence.
-	if len(a) < len(b) {
+	switch {
+	case len(a) < len(b):
 		return -1
-	}
-	if len(a) > len(b) {
+	case len(a) > len(b):
 		return 1
 	}
 	return 0
@@ -149,51 +275,27 @@ func cmpPrerelease(a, b []string) int {
 
 // Greater returns true if aDisp should sort ahead of bDisp in descending order.
 //
-// The comparison is semver-like when both values are "version-like" (start with a digit).
-// Otherwise, it falls back to lexical descending ordering.
+// Values that Parse accepts compare with Compare and sort ahead of values it
+// rejects, which fall back to lexical descending ordering.
 func Greater(aDisp, bDisp string) bool {
-	a := parseVersion(aDisp)
-	b := parseVersion(bDisp)
+	a, aErr := Parse(aDisp)
+	b, bErr := Parse(bDisp)
 
-	// Prefer version-like values over non-version-like values.
-	if a.ok && !b.ok {
+	switch {
+	case aErr == nil && bErr == nil:
+		return Compare(a, b) > 0
+	case aErr == nil:
 		return true
-	}
-	if !a.ok && b.ok {
+	case bErr == nil:
 		return false
-	}
-	if !a.ok && !b.ok {
-		// fallback: lexical descending
+	default:
 		return aDisp > bDisp
 	}
+}
 
-	// Compare core numeric segments, treating missing segments as 0.
-	n := len(a.core)
-	if len(b.core) > n {
-		n = len(b.core)
-	}
-	for i := 0; i < n; i++ {
-		av := 0
-		if i < len(a.core) {
-			av = a.core[i]
-		}
-		bv := 0
-		if i < len(b.core) {
-			bv = b.core[i]
-		}
-		if av != bv {
-			return av > bv
-		}
-	}
-
-	// Same core: release > prerelease
-	if a.hasPre != b.hasPre {
-		return !a.hasPre && b.hasPre
-	}
-	if !a.hasPre && !b.hasPre {
-		return false
-	}
-
-	// Both prerelease: higher prerelease wins
-	return cmpPrerelease(a.pre, b.pre) > 0
+// IsPrerelease reports whether v is a version-like value with a prerelease suffix,
+// such as "0.7.0-beta.1". Values that are not version-like are never prereleases.
+func IsPrerelease(v string) bool {
+	p, err := Parse(v)
+	return err == nil && p.IsPrerelease()
 }
@@ -1,6 +1,13 @@
 package version
 
-import "testing"
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/quick"
+)
 
 func TestNormalizeTag(t *testing.T) {
 	cases := []struct {
@@ -51,3 +58,192 @@ func TestGreater_FallbackLexical(t *testing.T) {
 		t.Fatalf("expected lexical desc")
 	}
 }
+
+func TestIsPrerelease(t *testing.T) {
+	for v, want := range map[string]bool{
+		"0.7.0-beta.1": true,
+		"0.6.5":        false,
+		"nightly-12":   false,
+		"":             false,
+	} {
+		if got := IsPrerelease(v); got != want {
+			t.Fatalf("IsPrerelease(%q)=%v; want %v", v, got, want)
+		}
+	}
+}
+
+// withOptions applies o for the duration of the test.
+func withOptions(t *testing.T, o Options) {
+	t.Helper()
+	if err := Configure(o); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = Configure(Options{}) })
+}
+
+func TestParse(t *testing.T) {
+	withOptions(t, Options{TagPrefixes: []string{`release-`, `ml-`}})
+
+	cases := []struct {
+		in   string
+		want Version
+	}{
+		{"v0.6.5", Version{Prefix: "v", Segments: []int{0, 6, 5}}},
+		{"0.2.7.4", Version{Segments: []int{0, 2, 7, 4}}},
+		{"1.0.0-beta.1+exp.sha.5114f85", Version{Segments: []int{1, 0, 0}, Prerelease: []string{"beta", "1"}, Build: "exp.sha.5114f85"}},
+		{"release-1.2", Version{Prefix: "release-", Segments: []int{1, 2}}},
+		{"ml-0.6.5+ci", Version{Prefix: "ml-", Segments: []int{0, 6, 5}, Build: "ci"}},
+		{"2024.05.1", Version{Segments: []int{2024, 5, 1}}},
+	}
+	for _, tc := range cases {
+		got, err := Parse(tc.in)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tc.in, err)
+		}
+		got.text = ""
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Parse(%q)=%+v; want %+v", tc.in, got, tc.want)
+		}
+	}
+
+	for _, bad := range []string{"", "v", "main", "nightly-42", "release-", "1..2", "1.2+", "ml-x", "1.a"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) succeeded", bad)
+		}
+	}
+
+	v, _ := Parse("release-0.6.5-rc.1+b7")
+	if v.String() != "0.6.5-rc.1+b7" || NormalizeTag("release-0.6.5") != "0.6.5" {
+		t.Fatalf("String=%q NormalizeTag=%q", v.String(), NormalizeTag("release-0.6.5"))
+	}
+	if s := (Version{Segments: []int{1, 2}, Prerelease: []string{"rc"}, Build: "x"}).String(); s != "1.2-rc+x" {
+		t.Fatalf("String of literal=%q", s)
+	}
+	// Prefixes only apply before a digit.
+	if NormalizeTag("release-notes") != "release-notes" {
+		t.Fatalf("prefix stripped from a non-version tag")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	cmp := func(a, b string) int {
+		t.Helper()
+		va, err := Parse(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vb, err := Parse(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return Compare(va, vb)
+	}
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0+build.1", "1.0.0+build.2", 0},
+		{"1.0", "1.0.0", 0},
+		{"1.0.0-rc.1+b", "1.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.beta", "1.0.0-beta", -1},
+		{"1.0.0-rc.10", "1.0.0-rc.9", 1},
+		{"1.0.0-rc.99999999999999999999", "1.0.0-rc.100000000000000000000", -1},
+		{"0.6.5", "0.6.10", -1},
+	} {
+		if got := cmp(tc.a, tc.b); got != tc.want {
+			t.Errorf("Compare(%s, %s)=%d; want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestCalVer(t *testing.T) {
+	// Without CalVer a date parses as 2024 with a prerelease.
+	if v, _ := Parse("2024-05-01"); !v.IsPrerelease() || v.CalVer {
+		t.Fatalf("plain Parse(2024-05-01)=%+v", v)
+	}
+
+	withOptions(t, Options{CalVer: true})
+	v, err := Parse("2024-05-01")
+	if err != nil || !v.CalVer || !reflect.DeepEqual(v.Segments, []int{2024, 5, 1}) {
+		t.Fatalf("Parse(2024-05-01)=%+v, %v", v, err)
+	}
+	if v, _ := Parse("2024.13.1"); v.CalVer {
+		t.Fatalf("month 13 parsed as CalVer")
+	}
+
+	// CalVer releases rank above SemVer, and compare by date among themselves.
+	if !Greater("2023.01", "99.0.0") || !Greater("2024.05.1", "2024.05") || !Greater("2024-06-01", "2024.05.30") {
+		t.Fatalf("CalVer ordering")
+	}
+}
+
+// genVersion generates tags that exercise every branch of Parse and Compare,
+// including values Parse rejects.
+type genVersion string
+
+func (genVersion) Generate(r *rand.Rand, _ int) reflect.Value {
+	pick := func(xs ...string) string { return xs[r.Intn(len(xs))] }
+	var b strings.Builder
+	b.WriteString(pick("", "", "v", "ml-", "release-"))
+	if r.Intn(10) == 0 {
+		b.WriteString(pick("main", "nightly-7", "", "x.y", "zzz"))
+		return reflect.ValueOf(genVersion(b.String()))
+	}
+	if r.Intn(4) == 0 {
+		b.WriteString(pick("2023", "2024"))
+		b.WriteString(pick(".", "-"))
+		b.WriteString(pick("01", "05", "12", "13"))
+		if r.Intn(2) == 0 {
+			b.WriteString(pick(".", "-"))
+			b.WriteString(pick("1", "01", "30"))
+		}
+	} else {
+		for i := 0; i <= r.Intn(4); i++ {
+			if i > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(strconv.Itoa(r.Intn(3)))
+		}
+	}
+	if r.Intn(3) == 0 {
+		b.WriteByte('-')
+		for i := 0; i <= r.Intn(3); i++ {
+			if i > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(pick("alpha", "beta", "rc", "1", "2", "10", "01", ""))
+		}
+	}
+	if r.Intn(4) == 0 {
+		b.WriteString("+" + pick("build", "1", "sha.5114f85"))
+	}
+	return reflect.ValueOf(genVersion(b.String()))
+}
+
+// TestGreater_StrictWeakOrder checks the properties sort.Slice relies on, with and
+// without CalVer: irreflexivity, asymmetry, transitivity and transitivity of
+// equivalence (neither value greater).
+func TestGreater_StrictWeakOrder(t *testing.T) {
+	for _, cal := range []bool{false, true} {
+		withOptions(t, Options{TagPrefixes: []string{`ml-`, `release-`}, CalVer: cal})
+		gt := func(a, b genVersion) bool { return Greater(string(a), string(b)) }
+		eq := func(a, b genVersion) bool { return !gt(a, b) && !gt(b, a) }
+
+		props := map[string]any{
+			"irreflexive": func(a genVersion) bool { return !gt(a, a) },
+			"asymmetric":  func(a, b genVersion) bool { return !gt(a, b) || !gt(b, a) },
+			"transitive": func(a, b, c genVersion) bool {
+				return !(gt(a, b) && gt(b, c)) || gt(a, c)
+			},
+			"equivalence is transitive": func(a, b, c genVersion) bool {
+				return !(eq(a, b) && eq(b, c)) || eq(a, c)
+			},
+		}
+		for name, prop := range props {
+			if err := quick.Check(prop, &quick.Config{MaxCount: 5000}); err != nil {
+				t.Errorf("calver=%v: %s: %v", cal, name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"automelonloaderinstallergo/internal/changelog"
+	"automelonloaderinstallergo/internal/releases"
+	"automelonloaderinstallergo/internal/termmd"
+	"automelonloaderinstallergo/internal/version"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// changelogLoadedMsg carries the releases fetched for the changelog identified by key.
+type changelogLoadedMsg struct {
+	key  string
+	rels []releases.Release
+}
+
+// getChangelogCmd fetches the releases for tags. Failures are reported as a
+// releaseErrMsg for key so they share the details pane's error handling.
+func getChangelogCmd(ctx context.Context, src releases.Source, t target, key string, tags []string, token string) tea.Cmd {
+	return func() tea.Msg {
+		rels := make([]releases.Release, 0, len(tags))
+		for _, tag := range tags {
+			var (
+				rel         releases.Release
+				unsupported error
+			)
+			err := retryWithBackoff(ctx, 3, 250*time.Millisecond, func() error {
+				r, e := releases.GetRelease(ctx, src, t.owner, t.repo, tag, token)
+				if errors.Is(e, errors.ErrUnsupported) {
+					unsupported = e
+					return nil
+				}
+				if e == nil {
+					rel = r
+				}
+				return e
+			})
+			if unsupported != nil {
+				err = unsupported
+			}
+			if err != nil {
+				return releaseErrMsg{tag: key, err: fmt.Errorf("load changelog: release %s: %w", tag, err)}
+			}
+			if rel.Tag == "" {
+				rel.Tag = tag
+			}
+			rels = append(rels, rel)
+		}
+		return changelogLoadedMsg{key: key, rels: rels}
+	}
+}
+
+// startPane refreshes whichever view the details pane is showing.
+func (m *model) startPane() tea.Cmd {
+	if m.detailsMode == paneChangelog {
+		return m.startChangelog()
+	}
+	return m.startDetails()
+}
+
+// closePane hides the details pane and stops any load in progress.
+func (m *model) closePane() {
+	m.cancelDetails()
+	m.showDetails = false
+	m.detailsTag = ""
+}
+
+// startChangelog shows the release notes from the selected (installed) version up to
+// the highlighted one, loading the releases that are not cached.
+func (m *model) startChangelog() tea.Cmd {
+	from, to := m.selectedVersionTag, m.highlightedTag()
+	key := from + ".." + to
+	if key == m.detailsTag && (m.loadingDetails || m.detailsErr == nil && m.changelogTags != nil && len(m.missingReleases()) == 0) {
+		return nil
+	}
+
+	m.cancelDetails()
+	m.detailsTag = key
+	m.detailsErr = nil
+	m.changelogFrom = from
+	m.changelogTags = nil
+	m.details.GotoTop()
+
+	if from == "" || to == "" {
+		m.detailsErr = errors.New("select your current version with enter, then highlight a newer one")
+		m.setDetailsContent()
+		return nil
+	}
+
+	if !version.Greater(version.NormalizeTag(to), version.NormalizeTag(from)) {
+		m.detailsErr = fmt.Errorf("highlight a version newer than %s", version.NormalizeTag(from))
+		m.setDetailsContent()
+		return nil
+	}
+
+	var tags []string
+	for _, it := range m.versions.Items() {
+		if v, ok := it.(versionItem); ok {
+			tags = append(tags, v.raw)
+		}
+	}
+	inRange, err := changelog.Range(tags, from, to)
+	if err == nil && len(inRange) == 0 {
+		err = fmt.Errorf("no releases after %s up to %s", version.NormalizeTag(from), version.NormalizeTag(to))
+	}
+	if err != nil {
+		m.detailsErr = err
+		m.setDetailsContent()
+		return nil
+	}
+	m.changelogTags = inRange
+
+	missing := m.missingReleases()
+	if len(missing) == 0 {
+		m.setDetailsContent()
+		return nil
+	}
+
+	m.loadingDetails = true
+	m.setDetailsContent()
+
+	baseCtx, cancel := context.WithCancel(context.Background())
+	m.detailsCancel = cancel
+	ctx, timeoutCancel := context.WithTimeout(baseCtx, m.refreshTimeout)
+
+	inner := getChangelogCmd(ctx, m.src, m.refreshedTarget, key, missing, m.resolveToken())
+	return func() tea.Msg {
+		defer timeoutCancel()
+		return inner()
+	}
+}
+
+// missingReleases returns the changelog tags whose releases are not cached yet.
+func (m *model) missingReleases() []string {
+	var out []string
+	for _, tag := range m.changelogTags {
+		if m.detailsCache[tag] == nil {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+// setChangelogContent renders the changelog for detailsTag into the viewport.
+func (m *model) setChangelogContent() {
+	switch {
+	case m.loadingDetails:
+		m.details.SetContent("Loading release notes…")
+	case errors.Is(m.detailsErr, errors.ErrUnsupported):
+		m.details.SetContent("This release source does not provide release notes.")
+	case m.detailsErr != nil:
+		m.details.SetContent("No changelog: " + m.detailsErr.Error() + ".")
+	case len(m.changelogTags) == 0 || len(m.missingReleases()) > 0:
+		m.details.SetContent("Release notes are unavailable.")
+	default:
+		rels := make([]releases.Release, 0, len(m.changelogTags))
+		for _, tag := range m.changelogTags {
+			rels = append(rels, *m.detailsCache[tag])
+		}
+		md := changelog.New(m.changelogFrom, rels).Markdown()
+		m.details.SetContent(termmd.Render(md, m.details.Width))
+	}
+}
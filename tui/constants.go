@@ -1,9 +1,16 @@
 package tui
 
+import "time"
+
+// Fallbacks used when Options leaves the release target empty.
 const (
-	hardOwner = "LavaGang"
-	hardRepo  = "MelonLoader"
-	hardAsset = "MelonLoader.x64.zip"
+	defaultOwner = "LavaGang"
+	defaultRepo  = "MelonLoader"
+	defaultAsset = "MelonLoader.x64.zip"
 )
 
-const helpText = "| ctrl+r: refresh version list | ctrl+d: download | tab: next field | shift+tab: prev field | esc: clear status | q: quit |"
+// targetDebounce is how long typing in the owner, repo or asset field must pause
+// before the version list is refreshed for the new target.
+const targetDebounce = 600 * time.Millisecond
+
+const helpText = "| ctrl+r: refresh version list | ctrl+d: download | enter: select or apply | ctrl+t: release details | ctrl+l: changelog | ctrl+o: mods | tab: next field | shift+tab: prev field | esc: clear status | q: quit |"
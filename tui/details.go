@@ -0,0 +1,177 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"automelonloaderinstallergo/internal/releases"
+	"automelonloaderinstallergo/internal/termmd"
+	"automelonloaderinstallergo/internal/version"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type releaseLoadedMsg struct {
+	tag string
+	rel releases.Release
+}
+
+type releaseErrMsg struct {
+	tag string
+	err error
+}
+
+func getReleaseCmd(ctx context.Context, src releases.Source, t target, tag, token string) tea.Cmd {
+	return func() tea.Msg {
+		var (
+			rel         releases.Release
+			unsupported error
+		)
+		err := retryWithBackoff(ctx, 3, 250*time.Millisecond, func() error {
+			r, e := releases.GetRelease(ctx, src, t.owner, t.repo, tag, token)
+			if errors.Is(e, errors.ErrUnsupported) {
+				unsupported = e // retrying cannot help
+				return nil
+			}
+			if e == nil {
+				rel = r
+			}
+			return e
+		})
+		if unsupported != nil {
+			err = unsupported
+		}
+		if err != nil {
+			return releaseErrMsg{tag: tag, err: fmt.Errorf("load release details: %w", err)}
+		}
+		return releaseLoadedMsg{tag: tag, rel: rel}
+	}
+}
+
+// highlightedTag returns the raw tag under the version list cursor.
+func (m *model) highlightedTag() string {
+	if it, ok := m.versions.SelectedItem().(versionItem); ok {
+		return it.raw
+	}
+	return ""
+}
+
+// startDetails shows the details of the highlighted version, loading them unless
+// they are cached.
+func (m *model) startDetails() tea.Cmd {
+	tag := m.highlightedTag()
+	if tag == m.detailsTag && (m.loadingDetails || m.detailsCache[tag] != nil) {
+		return nil
+	}
+
+	m.cancelDetails()
+	m.detailsTag = tag
+	m.detailsErr = nil
+	m.details.GotoTop()
+	if tag == "" || m.detailsCache[tag] != nil {
+		m.setDetailsContent()
+		return nil
+	}
+
+	m.loadingDetails = true
+	m.setDetailsContent()
+
+	baseCtx, cancel := context.WithCancel(context.Background())
+	m.detailsCancel = cancel
+	ctx, timeoutCancel := context.WithTimeout(baseCtx, m.refreshTimeout)
+
+	inner := getReleaseCmd(ctx, m.src, m.refreshedTarget, tag, m.resolveToken())
+	return func() tea.Msg {
+		defer timeoutCancel()
+		return inner()
+	}
+}
+
+func (m *model) cancelDetails() {
+	if m.detailsCancel != nil {
+		m.detailsCancel()
+		m.detailsCancel = nil
+	}
+	m.loadingDetails = false
+}
+
+// setDetailsContent renders the details of detailsTag, or the changelog, into the
+// viewport at its current width.
+func (m *model) setDetailsContent() {
+	if m.detailsMode == paneChangelog {
+		m.setChangelogContent()
+		return
+	}
+	switch rel := m.detailsCache[m.detailsTag]; {
+	case m.detailsTag == "":
+		m.details.SetContent("No version highlighted.")
+	case m.loadingDetails:
+		m.details.SetContent("Loading release details…")
+	case errors.Is(m.detailsErr, errors.ErrUnsupported):
+		m.details.SetContent("This release source does not provide release details.")
+	case rel == nil:
+		m.details.SetContent("Release details are unavailable.")
+	default:
+		m.details.SetContent(renderRelease(*rel, m.details.Width))
+	}
+}
+
+// renderRelease formats a release's metadata, assets and notes for the details pane.
+func renderRelease(rel releases.Release, width int) string {
+	bold := lipgloss.NewStyle().Bold(true)
+	muted := lipgloss.NewStyle().Faint(true)
+
+	title := rel.Name
+	if strings.TrimSpace(title) == "" {
+		title = version.NormalizeTag(rel.Tag)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", bold.Render(title))
+	fmt.Fprintf(&b, "Tag:        %s\n", rel.Tag)
+	if !rel.PublishedAt.IsZero() {
+		fmt.Fprintf(&b, "Published:  %s\n", rel.PublishedAt.Local().Format("2006-01-02 15:04"))
+	}
+	prerelease := "no"
+	if rel.Prerelease {
+		prerelease = "yes"
+	}
+	fmt.Fprintf(&b, "Prerelease: %s\n", prerelease)
+	if rel.URL != "" {
+		fmt.Fprintf(&b, "URL:        %s\n", rel.URL)
+	}
+
+	fmt.Fprintf(&b, "\n%s\n", bold.Render(fmt.Sprintf("Assets (%d)", len(rel.Assets))))
+	for _, a := range rel.Assets {
+		line := fmt.Sprintf("• %s  %s", a.Name, muted.Render(a.HumanSize()))
+		if a.DownloadCount > 0 {
+			line += muted.Render(fmt.Sprintf("  %d downloads", a.DownloadCount))
+		}
+		fmt.Fprintln(&b, line)
+	}
+
+	fmt.Fprintf(&b, "\n%s\n\n", bold.Render("Release notes"))
+	if strings.TrimSpace(rel.Body) == "" {
+		b.WriteString(muted.Render("No release notes."))
+	} else {
+		b.WriteString(termmd.Render(rel.Body, width))
+	}
+	return b.String()
+}
+
+// resizeDetails fits the details viewport to the right panel and re-wraps its content.
+func (m *model) resizeDetails() {
+	w := m.width - 4
+	if w <= 0 {
+		w = 92
+	}
+	_, rightW := panelWidths(w, true)
+	// Border and padding take 4 columns; the pane's title and hint take 4 rows.
+	m.details.Width = max(rightW-4, 20)
+	m.details.Height = max(m.height-14-4, 6)
+	m.setDetailsContent()
+}
@@ -3,53 +3,134 @@ package tui
 import (
 	"context"
 	"errors"
-	"os"
+	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"automelonloaderinstallergo/internal/credentials"
+	"automelonloaderinstallergo/internal/mods"
+	"automelonloaderinstallergo/internal/redact"
 	"automelonloaderinstallergo/internal/releases"
 
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 )
 
 type focusTarget int
 
 const (
 	focusVersions focusTarget = iota
+	focusAssets
+	focusOwner
+	focusRepo
+	focusAsset
 	focusOutput
 	focusToken
 )
 
+// paneMode selects what the right-hand pane shows when it replaces the settings.
+type paneMode int
+
+const (
+	paneRelease   paneMode = iota // details of the highlighted version
+	paneChangelog                 // notes from the selected version up to the highlighted one
+)
+
+// target identifies the repository and release asset the TUI works with.
+type target struct {
+	owner string
+	repo  string
+	asset string
+}
+
+// sameRepo reports whether t and o name the same repository, whatever their assets.
+func (t target) sameRepo(o target) bool {
+	return t.owner == o.owner && t.repo == o.repo
+}
+
 type versionItem struct {
 	raw      string // exact git tag, e.g. "v0.6.5"
 	display  string // UI value, e.g. "0.6.5"
-	isLatest bool   // visually mark the first (highest) semver
+	isLatest bool   // visually mark the newest stable release
+	nightly  bool   // CI build from the nightly source, grouped after releases
 }
 
 func (t versionItem) Title() string {
 	if t.isLatest {
 		return t.display + "  (latest)"
 	}
+	if t.nightly {
+		return t.display + "  (nightly)"
+	}
 	return t.display
 }
 func (t versionItem) Description() string { return "" }
 func (t versionItem) FilterValue() string { return t.display }
 
+type assetItem struct {
+	asset releases.Asset
+}
+
+func (a assetItem) Title() string { return a.asset.Name }
+func (a assetItem) Description() string {
+	if a.asset.DownloadCount > 0 {
+		return fmt.Sprintf("%s  •  %d downloads", a.asset.HumanSize(), a.asset.DownloadCount)
+	}
+	return a.asset.HumanSize()
+}
+func (a assetItem) FilterValue() string { return a.asset.Name }
+
 type banner struct {
 	status string
 	err    error
 }
 
 type model struct {
+	owner  textinput.Model
+	repo   textinput.Model
+	asset  textinput.Model
 	output textinput.Model
 	token  textinput.Model
 
 	versions list.Model
+	assets   list.Model
 
 	selectedVersionTag string // RAW tag value
 
+	// assetsTag is the release whose assets the asset list shows or is loading.
+	assetsTag         string
+	loadingAssets     bool
+	assetsUnsupported bool // the source cannot list assets; the asset field is used as typed
+
+	// The details pane shows the highlighted version (ctrl+t) or the changelog from the
+	// selected to the highlighted version (ctrl+l). detailsTag identifies what is shown:
+	// a tag, or "<from>..<to>" for a changelog.
+	showDetails    bool
+	detailsMode    paneMode
+	details        viewport.Model
+	detailsTag     string
+	changelogFrom  string
+	changelogTags  []string
+	detailsCache   map[string]*releases.Release
+	detailsErr     error
+	loadingDetails bool
+
+	// The mods screen (ctrl+o) replaces both panels and lists the files of games[gameIdx].
+	showMods    bool
+	games       []mods.Game
+	gameIdx     int
+	modList     list.Model
+	loadingMods bool
+
+	// refreshedTarget is the target of the most recent refresh; editing owner or repo
+	// away from it schedules a new refresh once typing pauses (see targetEditSeq),
+	// while editing only the asset re-matches the loaded asset list.
+	refreshedTarget target
+	targetEditSeq   int
+
 	focus focusTarget
 
 	loadingVersions bool
@@ -61,10 +142,19 @@ type model struct {
 	width  int
 	height int
 
-	src releases.Source
+	src  releases.Source
+	cred credentials.Credential
+	// typedToken is the token field's value as registered with redact.AddSecret.
+	typedToken string
+
+	outputDir       string
+	refreshTimeout  time.Duration
+	downloadTimeout time.Duration
 
 	refreshCancel  context.CancelFunc
 	downloadCancel context.CancelFunc
+	assetsCancel   context.CancelFunc
+	detailsCancel  context.CancelFunc
 }
 
 func (m *model) cancelRefresh() {
@@ -74,6 +164,14 @@ func (m *model) cancelRefresh() {
 	}
 }
 
+func (m *model) cancelAssets() {
+	if m.assetsCancel != nil {
+		m.assetsCancel()
+		m.assetsCancel = nil
+	}
+	m.loadingAssets = false
+}
+
 func (m *model) cancelDownload() {
 	if m.downloadCancel != nil {
 		m.downloadCancel()
@@ -81,7 +179,20 @@ func (m *model) cancelDownload() {
 	}
 }
 
-func newModel() model {
+func newTargetInput(prompt, value string) textinput.Model {
+	in := textinput.New()
+	in.Prompt = prompt
+	in.CharLimit = 200
+	in.Width = 40
+	in.SetValue(value)
+	return in
+}
+
+func newModel(src releases.Source) model {
+	owner := newTargetInput("Owner:  ", defaultOwner)
+	repo := newTargetInput("Repo:   ", defaultRepo)
+	asset := newTargetInput("Asset:  ", defaultAsset)
+
 	output := textinput.New()
 	output.Placeholder = "./downloads/<asset>"
 	output.Prompt = "Output: "
@@ -89,7 +200,7 @@ func newModel() model {
 	output.Width = 40
 
 	token := textinput.New()
-	token.Placeholder = "(optional; overrides GITHUB_TOKEN)"
+	token.Placeholder = "(optional; overrides detected token)"
 	token.Prompt = "Token:  "
 	token.CharLimit = 4000
 	token.Width = 40
@@ -102,16 +213,34 @@ func newModel() model {
 	l.SetFilteringEnabled(false)
 	l.DisableQuitKeybindings()
 
+	al := list.New([]list.Item{}, list.NewDefaultDelegate(), 40, 8)
+	al.Title = "Asset"
+	al.SetShowHelp(false)
+	al.SetFilteringEnabled(false)
+	al.DisableQuitKeybindings()
+	al.SetStatusBarItemName("asset", "assets")
+
 	sp := spinner.New()
 
 	m := model{
-		output:         output,
-		token:          token,
-		versions:       l,
-		focus:          focusVersions,
-		spin:           sp,
-		banner: banner{status: "Ready"},
-		src:    releases.NewGitHubSource(),
+		owner:        owner,
+		repo:         repo,
+		asset:        asset,
+		output:       output,
+		token:        token,
+		versions:     l,
+		assets:       al,
+		modList:      newModsList(),
+		details:      viewport.New(60, 12),
+		detailsCache: make(map[string]*releases.Release),
+		focus:        focusVersions,
+		spin:         sp,
+		banner:       banner{status: "Ready"},
+		src:          src,
+
+		outputDir:       filepath.Join(".", "downloads"),
+		refreshTimeout:  30 * time.Second,
+		downloadTimeout: 2 * time.Minute,
 	}
 
 	m.applyFocus()
@@ -119,27 +248,105 @@ func newModel() model {
 }
 
 func (m *model) resolveToken() string {
-	if v := strings.TrimSpace(m.token.Value()); v != "" {
-		return v
+	m.commitToken()
+	if m.typedToken != "" {
+		return m.typedToken
+	}
+	return m.cred.Token
+}
+
+// commitToken registers the token field's value with redact, so a typed token is
+// scrubbed from banner errors just like a resolved one, and unregisters the value
+// it replaces. It runs when the field loses focus and before each request.
+func (m *model) commitToken() {
+	v := strings.TrimSpace(m.token.Value())
+	if v == m.typedToken {
+		return
+	}
+	if m.typedToken != "" && m.typedToken != m.cred.Token {
+		redact.RemoveSecret(m.typedToken)
+	}
+	redact.AddSecret(v)
+	m.typedToken = v
+}
+
+// tokenSource describes where the token used for requests comes from.
+func (m *model) tokenSource() string {
+	if strings.TrimSpace(m.token.Value()) != "" {
+		return "token field"
+	}
+	if m.cred.Token != "" {
+		return m.cred.Source
+	}
+	return "none (unauthenticated)"
+}
+
+// target returns the trimmed owner, repo and asset field values.
+func (m *model) target() target {
+	return target{
+		owner: strings.TrimSpace(m.owner.Value()),
+		repo:  strings.TrimSpace(m.repo.Value()),
+		asset: strings.TrimSpace(m.asset.Value()),
 	}
-	return strings.TrimSpace(os.Getenv("GITHUB_TOKEN"))
 }
 
 func (m *model) resolveOutput() string {
 	if out := strings.TrimSpace(m.output.Value()); out != "" {
 		return out
 	}
-	return filepath.Join(".", "downloads", hardAsset)
+	asset, err := m.resolveAsset()
+	if err != nil {
+		asset = m.target().asset
+	}
+	return filepath.Join(m.outputDir, asset)
 }
 
 func (m *model) validateRefresh() error {
+	t := m.target()
+	if t.owner == "" {
+		return errors.New("owner is required")
+	}
+	if t.repo == "" {
+		return errors.New("repo is required")
+	}
 	return nil
 }
 
+// resolveAsset returns the asset name to download. A glob in the asset field must
+// match exactly one asset of the selected release's asset list.
+func (m *model) resolveAsset() (string, error) {
+	name := m.target().asset
+	if name == "" {
+		return "", errors.New("asset is required")
+	}
+	if !releases.IsAssetPattern(name) {
+		return name, nil
+	}
+	if m.assetsTag != m.selectedVersionTag || m.loadingAssets || m.assetsUnsupported {
+		return "", fmt.Errorf("asset pattern %q needs the release's asset list; pick an asset instead", name)
+	}
+
+	var assets []releases.Asset
+	for _, it := range m.assets.Items() {
+		assets = append(assets, it.(assetItem).asset)
+	}
+	matches, err := releases.MatchAssets(assets, name)
+	if err != nil {
+		return "", err
+	}
+	if len(matches) != 1 {
+		return "", fmt.Errorf("asset pattern %q matches %d assets; pick one from the asset list", name, len(matches))
+	}
+	return matches[0].Name, nil
+}
+
 func (m *model) validateDownload() error {
 	if strings.TrimSpace(m.selectedVersionTag) == "" {
 		return errors.New("select a version (refresh with 'ctrl+r' and choose one)")
 	}
+	if _, err := m.resolveAsset(); err != nil {
+		return err
+	}
 	if strings.TrimSpace(m.resolveOutput()) == "" {
 		return errors.New("output is required")
 	}
@@ -153,9 +360,10 @@ func (m *model) SetStatus(msg string) {
 }
 
 func (m *model) SetError(err error) {
-	m.banner.err = err
+	// Errors may embed response bodies or URLs; never show secrets in the banner.
+	m.banner.err = redact.Error(err)
 	if err != nil {
-		m.banner.status = err.Error()
+		m.banner.status = m.banner.err.Error()
 	}
 }
 
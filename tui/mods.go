@@ -0,0 +1,200 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"automelonloaderinstallergo/internal/mods"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type modItem struct {
+	mod mods.Mod
+}
+
+func (i modItem) Title() string {
+	title := i.mod.DisplayName()
+	if info := i.mod.Info; info != nil && info.Version != "" {
+		title += " " + info.Version
+	}
+	if !i.mod.Enabled {
+		title += "  (disabled)"
+	}
+	return title
+}
+func (i modItem) Description() string {
+	if len(i.mod.Warnings) > 0 {
+		return "⚠ " + strings.Join(i.mod.Warnings, "; ")
+	}
+	desc := fmt.Sprintf("%s  •  %s  •  %s", i.mod.Kind, modState(i.mod), i.mod.Name)
+	if info := i.mod.Info; info != nil && info.Author != "" {
+		desc += "  •  by " + info.Author
+	}
+	if i.mod.Tracked {
+		desc += "  •  installed by amlinstall"
+	}
+	return desc
+}
+func (i modItem) FilterValue() string { return i.mod.DisplayName() + " " + i.mod.Name }
+
+func modState(m mods.Mod) string {
+	if m.Enabled {
+		return "enabled"
+	}
+	return "disabled"
+}
+
+type modsLoadedMsg struct {
+	dir  string
+	mods []mods.Mod
+}
+
+type modsErrMsg struct {
+	dir string
+	err error
+}
+
+// modToggledMsg reports that a mod was enabled or disabled; the list is reloaded.
+type modToggledMsg struct {
+	dir string
+	mod mods.Mod
+}
+
+func listModsCmd(g mods.Game) tea.Cmd {
+	return func() tea.Msg {
+		ms, err := g.List()
+		if err != nil {
+			return modsErrMsg{dir: g.Dir, err: fmt.Errorf("list mods: %w", err)}
+		}
+		return modsLoadedMsg{dir: g.Dir, mods: ms}
+	}
+}
+
+func toggleModCmd(g mods.Game, m mods.Mod) tea.Cmd {
+	return func() tea.Msg {
+		change := g.Disable
+		if !m.Enabled {
+			change = g.Enable
+		}
+		got, err := change(m.Name, m.Kind)
+		if err != nil {
+			return modsErrMsg{dir: g.Dir, err: err}
+		}
+		return modToggledMsg{dir: g.Dir, mod: got}
+	}
+}
+
+func newModsList() list.Model {
+	l := list.New([]list.Item{}, list.NewDefaultDelegate(), 40, 8)
+	l.Title = "Mods"
+	l.SetShowHelp(false)
+	l.SetFilteringEnabled(false)
+	l.DisableQuitKeybindings()
+	l.SetStatusBarItemName("file", "files")
+	return l
+}
+
+// game returns the game the mods screen shows.
+func (m *model) game() (mods.Game, bool) {
+	if m.gameIdx < 0 || m.gameIdx >= len(m.games) {
+		return mods.Game{}, false
+	}
+	return m.games[m.gameIdx], true
+}
+
+// startMods (re)loads the mods of the current game.
+func (m *model) startMods() tea.Cmd {
+	g, ok := m.game()
+	if !ok {
+		m.modList.SetItems(nil)
+		return nil
+	}
+	m.modList.Title = "Mods  (" + gameLabel(g) + ")"
+	m.loadingMods = true
+	return listModsCmd(g)
+}
+
+// updateMods handles keys while the mods screen is open.
+func (m *model) updateMods(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+o", "esc":
+		m.showMods = false
+		return *m, nil
+	case "ctrl+r":
+		return *m, m.startMods()
+	case "left", "h", "right", "l":
+		if len(m.games) < 2 {
+			return *m, nil
+		}
+		step := 1
+		if k := msg.String(); k == "left" || k == "h" {
+			step = len(m.games) - 1
+		}
+		m.gameIdx = (m.gameIdx + step) % len(m.games)
+		m.modList.SetItems(nil)
+		return *m, m.startMods()
+	case " ", "enter":
+		g, ok := m.game()
+		it, sel := m.modList.SelectedItem().(modItem)
+		if !ok || !sel || m.loadingMods {
+			return *m, nil
+		}
+		m.loadingMods = true
+		return *m, toggleModCmd(g, it.mod)
+	}
+	var cmd tea.Cmd
+	m.modList, cmd = m.modList.Update(msg)
+	return *m, cmd
+}
+
+// applyMods fills the mods list, keeping the cursor on the same file if possible.
+func (m *model) applyMods(ms []mods.Mod) {
+	var cur mods.Mod
+	if it, ok := m.modList.SelectedItem().(modItem); ok {
+		cur = it.mod
+	}
+	items := make([]list.Item, 0, len(ms))
+	idx := 0
+	for i, mod := range ms {
+		items = append(items, modItem{mod: mod})
+		if mod.Kind == cur.Kind && mod.Name == cur.Name {
+			idx = i
+		}
+	}
+	m.modList.SetItems(items)
+	m.modList.Select(idx)
+}
+
+// modsView renders the mods screen into a panel of width w.
+func (m model) modsView(panel, muted lipgloss.Style, w int) string {
+	var b strings.Builder
+	g, ok := m.game()
+	switch {
+	case !ok:
+		b.WriteString(muted.Render("No games configured. Add a game to the games list with `config edit`,\n" +
+			"or manage a game directory from the command line with `mods --game <dir>`."))
+	case m.loadingMods && len(m.modList.Items()) == 0:
+		fmt.Fprintf(&b, "%s", muted.Render(m.spin.View()+" Loading mods…"))
+	case len(m.modList.Items()) == 0:
+		b.WriteString(muted.Render("No mods, plugins or user libraries in " + g.Dir))
+	default:
+		b.WriteString(m.modList.View())
+	}
+
+	hint := "space: enable/disable • ctrl+r: reload • ctrl+o/esc: close"
+	if len(m.games) > 1 {
+		hint = fmt.Sprintf("←/→: game (%d/%d) • %s", m.gameIdx+1, len(m.games), hint)
+	}
+	fmt.Fprintf(&b, "\n\n%s", muted.Render(hint))
+	return panel.Width(w).Render(b.String())
+}
+
+func gameLabel(g mods.Game) string {
+	if g.Name != "" {
+		return g.Name
+	}
+	return g.Dir
+}
@@ -1,11 +1,89 @@
 package tui
 
 import (
+	"path/filepath"
+	"time"
+
+	"automelonloaderinstallergo/internal/credentials"
+	"automelonloaderinstallergo/internal/mods"
+	"automelonloaderinstallergo/internal/releases"
+
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
-func Run() error {
-	m := newModel()
+// Options configures the TUI.
+type Options struct {
+	// Source is the release backend used for listing versions and downloading.
+	// When nil, the GitHub source is used.
+	Source releases.Source
+
+	// Credential is the GitHub token resolved at startup. A token typed into the
+	// TUI's token field takes precedence over it.
+	Credential credentials.Credential
+
+	// Owner, Repo and Asset seed the editable release target fields.
+	// Empty values fall back to LavaGang/MelonLoader and MelonLoader.x64.zip.
+	Owner string
+	Repo  string
+	Asset string
+
+	// OutputDir is where downloads go when the output field is empty.
+	// Defaults to ./downloads.
+	OutputDir string
+
+	// RefreshTimeout and DownloadTimeout bound version refreshes and downloads.
+	// Zero values use 30s and 2m respectively.
+	RefreshTimeout  time.Duration
+	DownloadTimeout time.Duration
+
+	// Theme is "dark" or "light" and selects the adaptive colour palette.
+	// Empty leaves the terminal's detected background in effect.
+	Theme string
+
+	// Games are the game installations whose mods the mods screen manages.
+	Games []mods.Game
+}
+
+func Run(opts Options) error {
+	src := opts.Source
+	if src == nil {
+		src = releases.NewGitHubSource()
+	}
+	switch opts.Theme {
+	case "dark":
+		lipgloss.SetHasDarkBackground(true)
+	case "light":
+		lipgloss.SetHasDarkBackground(false)
+	}
+
+	m := newModel(src)
+	m.cred = opts.Credential
+	for _, f := range []struct {
+		in  *textinput.Model
+		val string
+	}{
+		{&m.owner, opts.Owner},
+		{&m.repo, opts.Repo},
+		{&m.asset, opts.Asset},
+	} {
+		if f.val != "" {
+			f.in.SetValue(f.val)
+		}
+	}
+	if opts.OutputDir != "" {
+		m.outputDir = opts.OutputDir
+	}
+	if opts.RefreshTimeout > 0 {
+		m.refreshTimeout = opts.RefreshTimeout
+	}
+	if opts.DownloadTimeout > 0 {
+		m.downloadTimeout = opts.DownloadTimeout
+	}
+	m.games = opts.Games
+	m.output.Placeholder = filepath.Join(m.outputDir, "<asset>")
+
 	p := tea.NewProgram(m, tea.WithAltScreen())
 	_, err := p.Run()
 	return err
@@ -4,13 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"sort"
 	"time"
 
 	"automelonloaderinstallergo/internal/releases"
 	"automelonloaderinstallergo/internal/version"
 
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -24,6 +24,16 @@ type versionsErrMsg struct {
 
 type versionsCanceledMsg struct{}
 
+type assetsLoadedMsg struct {
+	tag    string
+	assets []releases.Asset
+}
+
+type assetsErrMsg struct {
+	tag string
+	err error
+}
+
 type downloadDoneMsg struct {
 	out string
 }
@@ -37,6 +47,12 @@ type downloadCanceledMsg struct{}
 // initRefreshMsg triggers the startup auto-refresh flow.
 type initRefreshMsg struct{}
 
+// targetEditedMsg fires targetDebounce after an edit to the owner, repo or asset
+// field. Only the message matching the latest edit triggers a refresh.
+type targetEditedMsg struct {
+	seq int
+}
+
 const focusCount = int(focusToken) + 1
 
 func retryWithBackoff(ctx context.Context, attempts int, baseDelay time.Duration, fn func() error) error {
@@ -67,11 +83,11 @@ func retryWithBackoff(ctx context.Context, attempts int, baseDelay time.Duration
 	return ctx.Err()
 }
 
-func refreshVersionsCmd(ctx context.Context, src releases.Source, token string) tea.Cmd {
+func refreshVersionsCmd(ctx context.Context, src releases.Source, t target, token string) tea.Cmd {
 	return func() tea.Msg {
 		var versions []string
 		err := retryWithBackoff(ctx, 3, 250*time.Millisecond, func() error {
-			v, e := src.ListTags(ctx, hardOwner, hardRepo, token)
+			v, e := src.ListTags(ctx, t.owner, t.repo, token)
 			if e == nil {
 				versions = v
 			}
@@ -87,10 +103,27 @@ func refreshVersionsCmd(ctx context.Context, src releases.Source, token string)
 	}
 }
 
-func downloadCmd(ctx context.Context, src releases.Source, tag, out, token string) tea.Cmd {
+func listAssetsCmd(ctx context.Context, src releases.Source, t target, tag, token string) tea.Cmd {
+	return func() tea.Msg {
+		var assets []releases.Asset
+		err := retryWithBackoff(ctx, 3, 250*time.Millisecond, func() error {
+			a, e := releases.ListAssets(ctx, src, t.owner, t.repo, tag, token)
+			if e == nil {
+				assets = a
+			}
+			return e
+		})
+		if err != nil {
+			return assetsErrMsg{tag: tag, err: fmt.Errorf("list assets: %w", err)}
+		}
+		return assetsLoadedMsg{tag: tag, assets: assets}
+	}
+}
+
+func downloadCmd(ctx context.Context, src releases.Source, t target, tag, out, token string) tea.Cmd {
 	return func() tea.Msg {
 		err := retryWithBackoff(ctx, 3, 500*time.Millisecond, func() error {
-			return src.DownloadAsset(ctx, hardOwner, hardRepo, tag /* raw tag */, hardAsset, out, token)
+			return src.DownloadAsset(ctx, t.owner, t.repo, tag /* raw tag */, t.asset, out, token)
 		})
 		if err != nil {
 			if errors.Is(err, context.Canceled) {
@@ -120,21 +153,113 @@ func (m *model) startRefresh() tea.Cmd {
 		return nil
 	}
 
+	// A different repository has different tags; drop the old list and selection.
+	if t := m.target(); t != m.refreshedTarget {
+		if t.owner != m.refreshedTarget.owner || t.repo != m.refreshedTarget.repo {
+			m.selectedVersionTag = ""
+			m.versions.SetItems(nil)
+			m.cancelAssets()
+			m.assetsTag = ""
+			m.assets.SetItems(nil)
+		}
+		m.refreshedTarget = t
+	}
+	// Release notes may have been edited since they were cached.
+	m.cancelDetails()
+	m.detailsTag = ""
+	m.detailsCache = make(map[string]*releases.Release)
+
 	m.ClearBanner()
 	m.loadingVersions = true
 	m.SetStatus("Refreshing version list…")
 
 	baseCtx, cancel := context.WithCancel(context.Background())
 	m.refreshCancel = cancel
-	ctx, timeoutCancel := context.WithTimeout(baseCtx, 30*time.Second)
+	ctx, timeoutCancel := context.WithTimeout(baseCtx, m.refreshTimeout)
+
+	inner := refreshVersionsCmd(ctx, m.src, m.refreshedTarget, m.resolveToken())
+	return func() tea.Msg {
+		defer timeoutCancel()
+		return inner()
+	}
+}
+
+// startListAssets loads the asset list for the selected version, replacing any
+// in-flight asset request. Sources that cannot list assets skip the retries.
+func (m *model) startListAssets() tea.Cmd {
+	m.cancelAssets()
+	m.assetsTag = m.selectedVersionTag
+	m.assetsUnsupported = false
+	m.assets.SetItems(nil)
+	if m.assetsTag == "" {
+		return nil
+	}
+	if _, ok := m.src.(releases.AssetLister); !ok {
+		m.assetsUnsupported = true
+		return nil
+	}
+
+	m.loadingAssets = true
+	m.assets.Title = "Asset  (" + version.NormalizeTag(m.assetsTag) + ")"
+
+	baseCtx, cancel := context.WithCancel(context.Background())
+	m.assetsCancel = cancel
+	ctx, timeoutCancel := context.WithTimeout(baseCtx, m.refreshTimeout)
 
-	inner := refreshVersionsCmd(ctx, m.src, m.resolveToken())
+	inner := listAssetsCmd(ctx, m.src, m.refreshedTarget, m.assetsTag, m.resolveToken())
 	return func() tea.Msg {
 		defer timeoutCancel()
 		return inner()
 	}
 }
 
+// applyAssets fills the asset list and highlights the asset named in the asset
+// field, or the first asset its glob matches.
+func (m *model) applyAssets(assets []releases.Asset) {
+	items := make([]list.Item, 0, len(assets))
+	for _, a := range assets {
+		items = append(items, assetItem{asset: a})
+	}
+	m.assets.SetItems(items)
+
+	want := m.target().asset
+	matches, _ := releases.MatchAssets(assets, want)
+	if !releases.IsAssetPattern(want) {
+		matches = nil
+		for _, a := range assets {
+			if a.Name == want {
+				matches = append(matches, a)
+			}
+		}
+	}
+	if len(matches) == 0 {
+		if len(assets) > 0 {
+			m.SetStatus(fmt.Sprintf("%s has no asset %q; pick one from the asset list.", version.NormalizeTag(m.assetsTag), want))
+		}
+		return
+	}
+	for i, a := range assets {
+		if a.Name == matches[0].Name {
+			m.assets.Select(i)
+			break
+		}
+	}
+}
+
+// rematchAssets applies an edited asset field to the loaded asset list without
+// refetching tags, since the repository is unchanged.
+func (m *model) rematchAssets() {
+	m.refreshedTarget.asset = m.target().asset
+	if m.assetsTag == "" || m.loadingAssets || m.assetsUnsupported {
+		return
+	}
+	assets := make([]releases.Asset, 0, len(m.assets.Items()))
+	for _, it := range m.assets.Items() {
+		assets = append(assets, it.(assetItem).asset)
+	}
+	m.applyAssets(assets)
+}
+
 func (m *model) startDownload() tea.Cmd {
 	// Cancel/replace policy: starting a download cancels any in-flight work.
 	m.cancelRefresh()
@@ -152,11 +277,14 @@ func (m *model) startDownload() tea.Cmd {
 
 	baseCtx, cancel := context.WithCancel(context.Background())
 	m.downloadCancel = cancel
-	ctx, timeoutCancel := context.WithTimeout(baseCtx, 2*time.Minute)
+	ctx, timeoutCancel := context.WithTimeout(baseCtx, m.downloadTimeout)
 
+	t := m.target()
+	t.asset, _ = m.resolveAsset() // checked by validateDownload
 	inner := downloadCmd(
 		ctx,
 		m.src,
+		t,
 		m.selectedVersionTag,
 		m.resolveOutput(),
 		m.resolveToken(),
@@ -173,21 +301,77 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case initRefreshMsg:
 		return m, m.startRefresh()
 
+	case targetEditedMsg:
+		if msg.seq != m.targetEditSeq || m.target() == m.refreshedTarget {
+			return m, nil
+		}
+		if m.target().sameRepo(m.refreshedTarget) {
+			m.rematchAssets()
+			return m, nil
+		}
+		return m, m.startRefresh()
+
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		m.versions.SetSize(max(msg.Width-4, 40), max(msg.Height-14, 6))
+		// The version and asset lists share the left panel.
+		listH := max(msg.Height-14, 12)
+		m.versions.SetSize(max(msg.Width-4, 40), listH/2)
+		m.assets.SetSize(max(msg.Width-4, 40), listH-listH/2)
+		m.modList.SetSize(max(msg.Width-12, 40), listH)
+		m.resizeDetails()
 		return m, nil
 
 	case tea.KeyMsg:
 		key := msg.String()
 
-		if key == "q" || key == "ctrl+c" {
+		// "q" only quits from the lists so it can be typed into text fields.
+		if key == "ctrl+c" || (key == "q" && (m.focus == focusVersions || m.focus == focusAssets)) {
 			m.cancelRefresh()
 			m.cancelDownload()
+			m.cancelAssets()
+			m.cancelDetails()
 			return m, tea.Quit
 		}
 
+		if m.showMods {
+			return m.updateMods(msg)
+		}
+		// Screen and pane hotkeys are modified so they never swallow typed text.
+		if key == "ctrl+o" {
+			m.showMods = true
+			return m, m.startMods()
+		}
+
+		if mode, ok := map[string]paneMode{"ctrl+t": paneRelease, "ctrl+l": paneChangelog}[key]; ok {
+			if m.showDetails && m.detailsMode == mode {
+				m.closePane()
+				return m, nil
+			}
+			m.showDetails = true
+			m.detailsMode = mode
+			m.detailsTag = "" // force a reload in the new mode
+			m.resizeDetails()
+			return m, m.startPane()
+		}
+
+		if m.showDetails {
+			switch key {
+			case "esc":
+				m.closePane()
+				return m, nil
+			case "shift+down", "J":
+				m.details.ScrollDown(1)
+				return m, nil
+			case "shift+up", "K":
+				m.details.ScrollUp(1)
+				return m, nil
+			case "tab", "shift+tab":
+				// The settings fields are hidden behind the pane.
+				m.closePane()
+			}
+		}
+
 		if key == "esc" {
 			m.ClearBanner()
 			m.SetStatus("Ready")
@@ -198,12 +382,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, m.startRefresh()
 		}
 
-
 		if key == "ctrl+d" {
 			return m, m.startDownload()
 		}
 
-
 		if key == "tab" {
 			m.focus = focusTarget((int(m.focus) + 1) % focusCount)
 			m.applyFocus()
@@ -231,6 +413,28 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					} else {
 						m.SetStatus("Selected version: " + it.display)
 					}
+					if m.assetsTag != it.raw {
+						cmd = tea.Batch(cmd, m.startListAssets())
+					}
+				}
+			}
+			if m.showDetails {
+				// The pane follows the highlighted (and selected) version.
+				cmd = tea.Batch(cmd, m.startPane())
+			}
+			return m, cmd
+		}
+
+		if m.focus == focusAssets {
+			var cmd tea.Cmd
+			m.assets, cmd = m.assets.Update(msg)
+
+			if key == "enter" {
+				if it, ok := m.assets.SelectedItem().(assetItem); ok {
+					// Picking an asset does not change the tag list, so don't refresh.
+					m.asset.SetValue(it.asset.Name)
+					m.refreshedTarget.asset = it.asset.Name
+					m.SetStatus("Selected asset: " + it.asset.Name)
 				}
 			}
 			return m, cmd
@@ -242,11 +446,16 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.loadingVersions = false
 		m.refreshCancel = nil
 
-		items := make([]versionItem, 0, len(msg.versions))
-		for _, t := range msg.versions {
+		// Order and mark versions exactly like `getTags`: newest version first, then
+		// nightly CI builds newest run first.
+		infos := releases.QueryTags(msg.versions, releases.TagQuery{Sort: releases.SortSemver})
+		items := make([]versionItem, 0, len(infos))
+		for _, info := range infos {
 			items = append(items, versionItem{
-				raw:     t,
-				display: version.NormalizeTag(t),
+				raw:      info.Tag,
+				display:  info.Version,
+				isLatest: info.Latest,
+				nightly:  info.Nightly,
 			})
 		}
 
@@ -257,25 +466,22 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
-		// FIXED: sort versions descending, supporting 4+ numeric segments like 0.2.7.4
-		sort.Slice(items, func(i, j int) bool {
-			di := items[i].display
-			dj := items[j].display
-			if di == dj {
-				return items[i].raw > items[j].raw
-			}
-			return version.Greater(di, dj)
-		})
-
-		items[0].isLatest = true
-
 		litems := make([]list.Item, 0, len(items))
 		for _, it := range items {
 			litems = append(litems, it)
 		}
 		m.versions.SetItems(litems)
 
-		selectedIdx := 0
+		// Default to the latest stable release, which a prerelease may precede.
+		defaultIdx := 0
+		for i := range items {
+			if items[i].isLatest {
+				defaultIdx = i
+				break
+			}
+		}
+
+		selectedIdx := defaultIdx
 		if m.selectedVersionTag != "" {
 			found := false
 			for i := range items {
@@ -286,23 +492,107 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 			if !found {
-				m.selectedVersionTag = items[0].raw
-				selectedIdx = 0
+				m.selectedVersionTag = items[defaultIdx].raw
+				selectedIdx = defaultIdx
 			}
 		} else {
-			m.selectedVersionTag = items[0].raw
-			selectedIdx = 0
+			m.selectedVersionTag = items[defaultIdx].raw
 		}
 
 		m.versions.Select(selectedIdx)
 
 		selectedDisplay := items[selectedIdx].display
-		if selectedIdx == 0 {
+		if items[selectedIdx].isLatest {
 			m.SetStatus("Selected version: " + selectedDisplay + " (latest)")
 		} else {
 			m.SetStatus("Selected version: " + selectedDisplay)
 		}
 
+		cmds := []tea.Cmd{m.startListAssets()}
+		if m.showDetails {
+			cmds = append(cmds, m.startPane())
+		}
+		return m, tea.Batch(cmds...)
+
+	case releaseLoadedMsg:
+		rel := msg.rel
+		m.detailsCache[msg.tag] = &rel
+		if msg.tag == m.detailsTag {
+			m.loadingDetails = false
+			m.detailsCancel = nil
+			m.setDetailsContent()
+		}
+		return m, nil
+
+	case changelogLoadedMsg:
+		for i := range msg.rels {
+			rel := msg.rels[i]
+			m.detailsCache[rel.Tag] = &rel
+		}
+		if msg.key == m.detailsTag {
+			m.loadingDetails = false
+			m.detailsCancel = nil
+			m.setDetailsContent()
+		}
+		return m, nil
+
+	case releaseErrMsg:
+		if msg.tag != m.detailsTag {
+			return m, nil
+		}
+		m.loadingDetails = false
+		m.detailsCancel = nil
+		m.detailsErr = msg.err
+		if !errors.Is(msg.err, context.Canceled) && !errors.Is(msg.err, errors.ErrUnsupported) {
+			m.SetError(msg.err)
+		}
+		m.setDetailsContent()
+		return m, nil
+
+	case modsLoadedMsg:
+		if g, ok := m.game(); !ok || msg.dir != g.Dir {
+			return m, nil // stale result for a previously shown game
+		}
+		m.loadingMods = false
+		m.applyMods(msg.mods)
+		return m, nil
+
+	case modToggledMsg:
+		verb := "Enabled"
+		if !msg.mod.Enabled {
+			verb = "Disabled"
+		}
+		m.SetStatus(fmt.Sprintf("%s %s %s", verb, msg.mod.Kind, msg.mod.Name))
+		if g, ok := m.game(); ok && msg.dir == g.Dir {
+			return m, m.startMods()
+		}
+		return m, nil
+
+	case modsErrMsg:
+		if g, ok := m.game(); ok && msg.dir == g.Dir {
+			m.loadingMods = false
+		}
+		m.SetError(msg.err)
+		return m, nil
+
+	case assetsLoadedMsg:
+		if msg.tag != m.assetsTag {
+			return m, nil // stale result for a previously selected version
+		}
+		m.loadingAssets = false
+		m.assetsCancel = nil
+		m.applyAssets(msg.assets)
+		return m, nil
+
+	case assetsErrMsg:
+		if msg.tag != m.assetsTag {
+			return m, nil
+		}
+		m.loadingAssets = false
+		m.assetsCancel = nil
+		if !errors.Is(msg.err, context.Canceled) {
+			m.SetError(msg.err)
+		}
 		return m, nil
 
 	case versionsErrMsg:
@@ -346,6 +636,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m *model) updateFocusedInput(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch m.focus {
+	case focusOwner, focusRepo, focusAsset:
+		return m.updateTargetInput(msg)
 	case focusOutput:
 		m.output, cmd = m.output.Update(msg)
 	case focusToken:
@@ -354,11 +646,60 @@ func (m *model) updateFocusedInput(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return *m, cmd
 }
 
+// updateTargetInput forwards msg to the focused owner, repo or asset field. Enter
+// applies an edited target immediately; other edits apply once typing pauses.
+func (m *model) updateTargetInput(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if k, ok := msg.(tea.KeyMsg); ok && k.String() == "enter" {
+		if m.target() == m.refreshedTarget {
+			return *m, nil
+		}
+		if m.target().sameRepo(m.refreshedTarget) {
+			m.rematchAssets()
+			return *m, nil
+		}
+		return *m, m.startRefresh()
+	}
+
+	in := m.targetInput(m.focus)
+	var cmd tea.Cmd
+	*in, cmd = in.Update(msg)
+	if m.target() == m.refreshedTarget {
+		return *m, cmd
+	}
+
+	m.targetEditSeq++
+	seq := m.targetEditSeq
+	return *m, tea.Batch(cmd, tea.Tick(targetDebounce, func(time.Time) tea.Msg {
+		return targetEditedMsg{seq: seq}
+	}))
+}
+
+// targetInput returns the text input for a target focus, or nil.
+func (m *model) targetInput(f focusTarget) *textinput.Model {
+	switch f {
+	case focusOwner:
+		return &m.owner
+	case focusRepo:
+		return &m.repo
+	case focusAsset:
+		return &m.asset
+	}
+	return nil
+}
+
 func (m *model) applyFocus() {
+	if m.token.Focused() && m.focus != focusToken {
+		m.commitToken()
+	}
+	m.owner.Blur()
+	m.repo.Blur()
+	m.asset.Blur()
 	m.output.Blur()
 	m.token.Blur()
 
 	switch m.focus {
+	case focusOwner, focusRepo, focusAsset:
+		m.targetInput(m.focus).Focus()
 	case focusOutput:
 		m.output.Focus()
 	case focusToken:
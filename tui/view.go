@@ -4,9 +4,30 @@ import (
 	"fmt"
 	"strings"
 
+	"automelonloaderinstallergo/internal/version"
+
 	"github.com/charmbracelet/lipgloss"
 )
 
+const panelGap = 2
+
+// panelWidths splits the content width w between the left (lists) and right panels.
+// The right panel gets two thirds when it shows release details, one third otherwise.
+func panelWidths(w int, details bool) (leftW, rightW int) {
+	avail := w - 2*2 - panelGap
+	leftW = avail * 2 / 3
+	if details {
+		leftW = avail / 3
+	}
+	rightW = avail - leftW
+	if leftW < 40 && !details {
+		leftW = 40
+	}
+	leftW = max(leftW, 30)
+	rightW = max(rightW, 34)
+	return leftW, rightW
+}
+
 func (m model) View() string {
 	// Shrink overall UI width by 4 columns.
 	w := m.width - 4
@@ -49,15 +70,8 @@ func (m model) View() string {
 		footer = lipgloss.NewStyle().MarginTop(1)
 	)
 
-	gap := 2
-	leftW := (w - 2*2 - gap) * 2 / 3
-	rightW := (w - 2*2 - gap) - leftW
-	if leftW < 40 {
-		leftW = 40
-	}
-	if rightW < 34 {
-		rightW = 34
-	}
+	gap := panelGap
+	leftW, rightW := panelWidths(w, m.showDetails)
 
 	// Right panel inner width must account for:
 	// - 2 columns border (left+right)
@@ -66,7 +80,8 @@ func (m model) View() string {
 	rightInnerW = max(rightInnerW, 10)
 
 	title := "MelonLoader Automated Installer Linux Edition"
-	sub := fmt.Sprintf("%s/%s  •  %s", hardOwner, hardRepo, hardAsset)
+	t := m.target()
+	sub := fmt.Sprintf("%s/%s  •  %s", t.owner, t.repo, t.asset)
 	if m.loadingVersions {
 		sub = fmt.Sprintf("%s  •  %s Refreshing Version List…", sub, m.spin.View())
 	}
@@ -83,11 +98,11 @@ func (m model) View() string {
 	)
 
 	versionsPanelStyle := panelBase
-	if m.focus == focusVersions {
+	if m.focus == focusVersions || m.focus == focusAssets {
 		versionsPanelStyle = panelFocused
 	}
 	settingsPanelStyle := panelBase
-	if m.focus == focusOutput || m.focus == focusToken {
+	if m.focus != focusVersions && m.focus != focusAssets {
 		settingsPanelStyle = panelFocused
 	}
 
@@ -101,6 +116,15 @@ func (m model) View() string {
 		fmt.Fprintf(&leftBody, "\n%s", muted.Render("Selected: "+m.selectedVersionTag))
 	}
 
+	switch {
+	case m.assetsUnsupported:
+		fmt.Fprintf(&leftBody, "\n\n%s", muted.Render("This source cannot list assets; the Asset field is used as typed."))
+	case m.loadingAssets:
+		fmt.Fprintf(&leftBody, "\n\n%s", muted.Render(m.spin.View()+" Loading assets…"))
+	case m.assetsTag != "":
+		fmt.Fprintf(&leftBody, "\n\n%s", m.assets.View())
+	}
+
 	versionsPanel := versionsPanelStyle.
 		Width(leftW).
 		Render(leftBody.String())
@@ -109,7 +133,7 @@ func (m model) View() string {
 	var rightBody strings.Builder
 
 	settingsTitle := "Download Settings"
-	if m.focus == focusOutput || m.focus == focusToken {
+	if m.focus != focusVersions && m.focus != focusAssets {
 		settingsTitle = "▶ " + settingsTitle
 	}
 
@@ -118,25 +142,30 @@ func (m model) View() string {
 		muted.Render("Tab/Shift+Tab to change focus."),
 	)
 
-	outputView := m.output.View()
-	tokenView := m.token.View()
-
-	switch m.focus {
-	case focusOutput:
-		outputView = fieldFocused.Render(outputView)
-		tokenView = fieldBlurred.Render(tokenView)
-
-	case focusToken:
-		outputView = fieldBlurred.Render(outputView)
-		tokenView = fieldFocused.Render(tokenView)
-
-	default:
-		outputView = fieldBlurred.Render(outputView)
-		tokenView = fieldBlurred.Render(tokenView)
+	fields := []struct {
+		focus focusTarget
+		view  string
+	}{
+		{focusOwner, m.owner.View()},
+		{focusRepo, m.repo.View()},
+		{focusAsset, m.asset.View()},
+		{focusOutput, m.output.View()},
+		{focusToken, m.token.View()},
 	}
 
-	fmt.Fprintf(&rightBody, "\n%s\n", outputView)
-	fmt.Fprintf(&rightBody, "%s\n", tokenView)
+	rightBody.WriteString("\n")
+	for _, f := range fields {
+		if f.focus == focusOutput {
+			// Separate the release target from the download destination.
+			rightBody.WriteString("\n")
+		}
+		view := fieldBlurred.Render(f.view)
+		if m.focus == f.focus {
+			view = fieldFocused.Render(f.view)
+		}
+		fmt.Fprintf(&rightBody, "%s\n", view)
+	}
+	fmt.Fprintf(&rightBody, "%s\n", muted.Render("Token source: "+m.tokenSource()))
 
 	if strings.TrimSpace(m.Status()) != "" {
 		fmt.Fprintf(&rightBody, "\n%s\n", statusBox.Width(rightInnerW).Render(m.Status()))
@@ -149,6 +178,24 @@ func (m model) View() string {
 		Width(rightW).
 		Render(rightBody.String())
 
+	if m.showDetails {
+		paneTitle, closeKey := "Release Details", "ctrl+t"
+		if m.detailsMode == paneChangelog {
+			paneTitle, closeKey = "Changelog", "ctrl+l"
+			if m.changelogFrom != "" {
+				paneTitle = fmt.Sprintf("Changelog %s → %s",
+					version.NormalizeTag(m.changelogFrom), version.NormalizeTag(m.highlightedTag()))
+			}
+		}
+		var details strings.Builder
+		fmt.Fprintf(&details, "%s\n%s\n\n%s",
+			settingsTitleStyle.Render(paneTitle),
+			muted.Render(fmt.Sprintf("shift+↑/↓ or J/K scroll (%d%%) • %s close", int(m.details.ScrollPercent()*100), closeKey)),
+			m.details.View(),
+		)
+		rightPanel = panelBase.Width(rightW).Render(details.String())
+	}
+
 	content := lipgloss.JoinHorizontal(
 		lipgloss.Top,
 		versionsPanel,
@@ -156,6 +203,17 @@ func (m model) View() string {
 		rightPanel,
 	)
 
+	if m.showMods {
+		var status string
+		switch {
+		case m.Err() != nil:
+			status = errorBox.Render("Error: " + m.Err().Error())
+		case strings.TrimSpace(m.Status()) != "":
+			status = statusBox.Render(m.Status())
+		}
+		content = lipgloss.JoinVertical(lipgloss.Left, m.modsView(panelFocused, muted, w-2*2), status)
+	}
+
 	footerLine := footer.Render(muted.Render(helpText))
 
 	return appPad.Render(